@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type NamespacedFolderEntry struct {
@@ -30,19 +31,94 @@ type ClusterFolderEntry struct {
 	Namespaces   []string `json:"namespaces,omitempty"`
 }
 
+// DeletedMemberPolicy decides what happens to the namespaces and
+// VirtualMachines of the index when they are deleted.
+// +kubebuilder:validation:Enum=Prune;Retain
+type DeletedMemberPolicy string
+
+const (
+	// DeletedMemberPolicyPrune removes deleted members from the index.
+	DeletedMemberPolicyPrune DeletedMemberPolicy = "Prune"
+	// DeletedMemberPolicyRetain keeps deleted members in the index and
+	// flags them in the status of the index.
+	DeletedMemberPolicyRetain DeletedMemberPolicy = "Retain"
+)
+
+// DeletedMember is a namespace or VirtualMachine of the index that was
+// deleted.
+type DeletedMember struct {
+	// +kubebuilder:validation:Enum=Namespace;VirtualMachine
+	Kind string `json:"kind"`
+
+	// Namespace of a VirtualMachine.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	Name string `json:"name"`
+
+	// DeletionTime is when the controller saw the member deleted.
+	DeletionTime metav1.Time `json:"deletionTime"`
+}
+
+// ObservedMember is a namespace or VirtualMachine of the index that the
+// controller saw exist.
+type ObservedMember struct {
+	// +kubebuilder:validation:Enum=Namespace;VirtualMachine
+	Kind string `json:"kind"`
+
+	// Namespace of a VirtualMachine.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	Name string `json:"name"`
+
+	// UID of the member when the controller saw it.
+	UID types.UID `json:"uid"`
+}
+
 // FolderIndexSpec defines the desired state of FolderIndex.
 type FolderIndexSpec struct {
 	ClusterFolderEntries    map[string]ClusterFolderEntry    `json:"clusterFolderEntries,omitempty"`
 	NamespacedFolderEntries map[string]NamespacedFolderEntry `json:"namespacedFolderEntries,omitempty"`
+
+	// DeletedMemberPolicy decides what happens to namespaces and
+	// VirtualMachines of the index that are deleted. Prune, the default,
+	// removes them from the index. Retain keeps them and flags them in
+	// status.deletedMembers. Flagged members get no access or labels from
+	// their folders, so an object later created with the same name does
+	// not inherit them, until they are removed from the index.
+	// +optional
+	DeletedMemberPolicy DeletedMemberPolicy `json:"deletedMemberPolicy,omitempty"`
 }
 
 // FolderIndexStatus defines the observed state of FolderIndex.
 type FolderIndexStatus struct {
+	// ObservedGeneration is the generation of the spec the controller last
+	// reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// DeletedMembers are the members the Retain policy kept in the index
+	// after they were deleted.
+	// +optional
+	DeletedMembers []DeletedMember `json:"deletedMembers,omitempty"`
+
+	// ObservedMembers are the members of the index the controller saw
+	// exist. Only these are taken for deleted when they are missing, so
+	// members filed before they are created stay in the index.
+	// +optional
+	ObservedMembers []ObservedMember `json:"observedMembers,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster
+// +kubebuilder:storageversion
 
 // FolderIndex is the Schema for the folderindices API.
 type FolderIndex struct {
@@ -0,0 +1,57 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FolderMembershipSpec defines the NamespacedFolder a VirtualMachine is in.
+type FolderMembershipSpec struct {
+	// Folder is the name of the NamespacedFolder, in the namespace of the
+	// membership, that holds the VirtualMachine named by the membership.
+	// +kubebuilder:validation:MinLength=1
+	Folder string `json:"folder"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Folder",type=string,JSONPath=`.spec.folder`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// FolderMembership files the VirtualMachine of the same namespace and name
+// into a NamespacedFolder. Memberships are separate objects so that the
+// VirtualMachines of a folder are not bounded by the size of the FolderIndex.
+// A membership takes precedence over the VirtualMachines of the FolderIndex.
+type FolderMembership struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec FolderMembershipSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FolderMembershipList contains a list of FolderMembership.
+type FolderMembershipList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FolderMembership `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FolderMembership{}, &FolderMembershipList{})
+}
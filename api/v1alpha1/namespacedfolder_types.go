@@ -17,28 +17,124 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// FolderLimits constrains the VirtualMachines of a NamespacedFolder and of its
+// descendant NamespacedFolders. Fields that are not set are not limited.
+type FolderLimits struct {
+	// VirtualMachines is the maximum number of VirtualMachines in the folder
+	// and its descendants.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	VirtualMachines *int64 `json:"virtualMachines,omitempty"`
+
+	// CPUPerVirtualMachine is the maximum number of vCPUs of a single
+	// VirtualMachine.
+	// +optional
+	CPUPerVirtualMachine *resource.Quantity `json:"cpuPerVirtualMachine,omitempty"`
+
+	// MemoryPerVirtualMachine is the maximum guest memory of a single
+	// VirtualMachine.
+	// +optional
+	MemoryPerVirtualMachine *resource.Quantity `json:"memoryPerVirtualMachine,omitempty"`
+
+	// Instancetypes are the names of the instancetypes VirtualMachines may
+	// use. When set, VirtualMachines must use one of them.
+	// +listType=set
+	// +optional
+	Instancetypes []string `json:"instancetypes,omitempty"`
+
+	// Preferences are the names of the preferences VirtualMachines may use.
+	// When set, VirtualMachines must use one of them.
+	// +listType=set
+	// +optional
+	Preferences []string `json:"preferences,omitempty"`
+}
+
+// InstancetypeReference names a VirtualMachineInstancetype in the namespace
+// of the folder or a VirtualMachineClusterInstancetype.
+type InstancetypeReference struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Kind is VirtualMachineInstancetype or
+	// VirtualMachineClusterInstancetype, the default.
+	// +kubebuilder:validation:Enum=VirtualMachineInstancetype;VirtualMachineClusterInstancetype
+	// +optional
+	Kind string `json:"kind,omitempty"`
+}
+
+// FolderDefaults are set on the VirtualMachines of a NamespacedFolder and of
+// its descendant NamespacedFolders where they do not set them themselves. The
+// defaults of the nearest folder win.
+type FolderDefaults struct {
+	// Labels are added to the VirtualMachines that do not have them.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Instancetype is set on new VirtualMachines without an instancetype.
+	// +optional
+	Instancetype *InstancetypeReference `json:"instancetype,omitempty"`
+
+	// RunStrategy is set on new VirtualMachines that set neither a run
+	// strategy nor running.
+	// +kubebuilder:validation:Enum=Always;RerunOnFailure;Manual;Halted;Once
+	// +optional
+	RunStrategy string `json:"runStrategy,omitempty"`
+}
+
 // NamespacedFolderSpec defines the desired state of NamespacedFolder.
 type NamespacedFolderSpec struct {
+	// Deprecated: the folders of a NamespacedFolder are kept in the
+	// FolderIndex. This field is unused and not part of v1beta1.
 	// +listType=set
 	// +kubebuilder:validation:MaxItems=250
 	ChildNamespacedFolders []string `json:"childNamespacedFolders,omitempty"`
 
+	// Deprecated: the VirtualMachines of a NamespacedFolder are kept in the
+	// FolderIndex. This field is unused and not part of v1beta1.
 	// +listType=set
 	// +kubebuilder:validation:MaxItems=250
 	VirtualMachines []string `json:"virtualMachines,omitempty"`
 
 	FolderPermissions []FolderPermission `json:"folderPermissions,omitempty"`
+
+	// Inheritance controls which permissions of the ancestors of the folder
+	// reach it.
+	// +optional
+	Inheritance *FolderInheritance `json:"inheritance,omitempty"`
+
+	FolderMetadata `json:",inline"`
+
+	// Limits constrains the VirtualMachines of the folder and of its
+	// descendants.
+	// +optional
+	Limits *FolderLimits `json:"limits,omitempty"`
+
+	// Defaults are set on the VirtualMachines of the folder and of its
+	// descendants.
+	// +optional
+	Defaults *FolderDefaults `json:"defaults,omitempty"`
 }
 
 // NamespacedFolderStatus defines the observed state of NamespacedFolder.
 type NamespacedFolderStatus struct {
+	// ObservedGeneration is the generation of the spec the controller last
+	// reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 
 // NamespacedFolder is the Schema for the namespacedfolders API.
 // +kubebuilder:validation:XValidation:rule="!has(self.spec.childNamespacedFolders) || !(self.metadata.name in self.spec.childNamespacedFolders)",message="parent folder can not contain child folder with the same name as the parent"
@@ -22,6 +22,7 @@ package v1alpha1
 
 import (
 	"k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -31,7 +32,7 @@ func (in *ClusterFolder) DeepCopyInto(out *ClusterFolder) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFolder.
@@ -129,6 +130,17 @@ func (in *ClusterFolderSpec) DeepCopyInto(out *ClusterFolderSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Inheritance != nil {
+		in, out := &in.Inheritance, &out.Inheritance
+		*out = new(FolderInheritance)
+		**out = **in
+	}
+	in.FolderMetadata.DeepCopyInto(&out.FolderMetadata)
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(FolderQuota)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFolderSpec.
@@ -144,6 +156,18 @@ func (in *ClusterFolderSpec) DeepCopy() *ClusterFolderSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterFolderStatus) DeepCopyInto(out *ClusterFolderStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(FolderQuotaStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFolderStatus.
@@ -156,13 +180,56 @@ func (in *ClusterFolderStatus) DeepCopy() *ClusterFolderStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletedMember) DeepCopyInto(out *DeletedMember) {
+	*out = *in
+	in.DeletionTime.DeepCopyInto(&out.DeletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletedMember.
+func (in *DeletedMember) DeepCopy() *DeletedMember {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletedMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderDefaults) DeepCopyInto(out *FolderDefaults) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Instancetype != nil {
+		in, out := &in.Instancetype, &out.Instancetype
+		*out = new(InstancetypeReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderDefaults.
+func (in *FolderDefaults) DeepCopy() *FolderDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FolderIndex) DeepCopyInto(out *FolderIndex) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderIndex.
@@ -247,6 +314,25 @@ func (in *FolderIndexSpec) DeepCopy() *FolderIndexSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FolderIndexStatus) DeepCopyInto(out *FolderIndexStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeletedMembers != nil {
+		in, out := &in.DeletedMembers, &out.DeletedMembers
+		*out = make([]DeletedMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ObservedMembers != nil {
+		in, out := &in.ObservedMembers, &out.ObservedMembers
+		*out = make([]ObservedMember, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderIndexStatus.
@@ -259,6 +345,166 @@ func (in *FolderIndexStatus) DeepCopy() *FolderIndexStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderInheritance) DeepCopyInto(out *FolderInheritance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderInheritance.
+func (in *FolderInheritance) DeepCopy() *FolderInheritance {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderInheritance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderLimits) DeepCopyInto(out *FolderLimits) {
+	*out = *in
+	if in.VirtualMachines != nil {
+		in, out := &in.VirtualMachines, &out.VirtualMachines
+		*out = new(int64)
+		**out = **in
+	}
+	if in.CPUPerVirtualMachine != nil {
+		in, out := &in.CPUPerVirtualMachine, &out.CPUPerVirtualMachine
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MemoryPerVirtualMachine != nil {
+		in, out := &in.MemoryPerVirtualMachine, &out.MemoryPerVirtualMachine
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Instancetypes != nil {
+		in, out := &in.Instancetypes, &out.Instancetypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Preferences != nil {
+		in, out := &in.Preferences, &out.Preferences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderLimits.
+func (in *FolderLimits) DeepCopy() *FolderLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderMembership) DeepCopyInto(out *FolderMembership) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderMembership.
+func (in *FolderMembership) DeepCopy() *FolderMembership {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderMembership)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FolderMembership) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderMembershipList) DeepCopyInto(out *FolderMembershipList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FolderMembership, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderMembershipList.
+func (in *FolderMembershipList) DeepCopy() *FolderMembershipList {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderMembershipList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FolderMembershipList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderMembershipSpec) DeepCopyInto(out *FolderMembershipSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderMembershipSpec.
+func (in *FolderMembershipSpec) DeepCopy() *FolderMembershipSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderMembershipSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderMetadata) DeepCopyInto(out *FolderMetadata) {
+	*out = *in
+	if in.Owners != nil {
+		in, out := &in.Owners, &out.Owners
+		*out = make([]v1.Subject, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PropagatedTags != nil {
+		in, out := &in.PropagatedTags, &out.PropagatedTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderMetadata.
+func (in *FolderMetadata) DeepCopy() *FolderMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FolderPermission) DeepCopyInto(out *FolderPermission) {
 	*out = *in
@@ -280,13 +526,92 @@ func (in *FolderPermission) DeepCopy() *FolderPermission {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderQuota) DeepCopyInto(out *FolderQuota) {
+	*out = *in
+	if in.VirtualMachines != nil {
+		in, out := &in.VirtualMachines, &out.VirtualMachines
+		*out = new(int64)
+		**out = **in
+	}
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderQuota.
+func (in *FolderQuota) DeepCopy() *FolderQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderQuotaStatus) DeepCopyInto(out *FolderQuotaStatus) {
+	*out = *in
+	in.Hard.DeepCopyInto(&out.Hard)
+	in.Used.DeepCopyInto(&out.Used)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderQuotaStatus.
+func (in *FolderQuotaStatus) DeepCopy() *FolderQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderQuotaUsage) DeepCopyInto(out *FolderQuotaUsage) {
+	*out = *in
+	out.CPU = in.CPU.DeepCopy()
+	out.Memory = in.Memory.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderQuotaUsage.
+func (in *FolderQuotaUsage) DeepCopy() *FolderQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstancetypeReference) DeepCopyInto(out *InstancetypeReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstancetypeReference.
+func (in *InstancetypeReference) DeepCopy() *InstancetypeReference {
+	if in == nil {
+		return nil
+	}
+	out := new(InstancetypeReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespacedFolder) DeepCopyInto(out *NamespacedFolder) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolder.
@@ -384,6 +709,22 @@ func (in *NamespacedFolderSpec) DeepCopyInto(out *NamespacedFolderSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Inheritance != nil {
+		in, out := &in.Inheritance, &out.Inheritance
+		*out = new(FolderInheritance)
+		**out = **in
+	}
+	in.FolderMetadata.DeepCopyInto(&out.FolderMetadata)
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(FolderLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(FolderDefaults)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolderSpec.
@@ -399,6 +740,13 @@ func (in *NamespacedFolderSpec) DeepCopy() *NamespacedFolderSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespacedFolderStatus) DeepCopyInto(out *NamespacedFolderStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolderStatus.
@@ -410,3 +758,18 @@ func (in *NamespacedFolderStatus) DeepCopy() *NamespacedFolderStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservedMember) DeepCopyInto(out *ObservedMember) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservedMember.
+func (in *ObservedMember) DeepCopy() *ObservedMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservedMember)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	fuzz "github.com/google/gofuzz"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+const fuzzIterations = 500
+
+// fuzzName returns a name that is valid in a "namespace/name" key.
+func fuzzName(c fuzz.Continue) string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789-"
+	b := make([]byte, 1+c.Intn(8))
+	for i := range b {
+		b[i] = chars[c.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+func fuzzNames(c fuzz.Continue) []string {
+	if c.RandBool() {
+		return nil
+	}
+	names := make([]string, c.Intn(4))
+	for i := range names {
+		names[i] = fuzzName(c)
+	}
+	return names
+}
+
+// newFuzzer returns a fuzzer for the folder types of both versions. Objects
+// are filled the way the API server would hold them: TypeMeta is set by the
+// conversion webhook rather than converted, NamespacedFolders are named by
+// valid keys and v1beta1 NamespacedFolder entries are unique and sorted, as
+// listMapKey requires.
+func newFuzzer(seed int64) *fuzz.Fuzzer {
+	return fuzz.NewWithSeed(seed).NilChance(0.2).NumElements(0, 4).Funcs(
+		func(t *metav1.TypeMeta, c fuzz.Continue) {},
+		func(m *metav1.ObjectMeta, c fuzz.Continue) {
+			m.Name = fuzzName(c)
+			m.Namespace = fuzzName(c)
+			m.Generation = c.Int63()
+			m.ResourceVersion = fuzzName(c)
+			c.Fuzz(&m.Labels)
+			c.Fuzz(&m.Annotations)
+			if len(m.Annotations) == 0 {
+				m.Annotations = nil
+			}
+		},
+		func(cond *metav1.Condition, c fuzz.Continue) {
+			c.FuzzNoCustom(cond)
+			cond.LastTransitionTime = metav1.NewTime(time.Unix(c.Int63n(1<<32), 0).UTC())
+		},
+		func(spec *v1alpha1.FolderIndexSpec, c fuzz.Continue) {
+			c.Fuzz(&spec.ClusterFolderEntries)
+			if c.RandBool() {
+				return
+			}
+			spec.NamespacedFolderEntries = map[string]v1alpha1.NamespacedFolderEntry{}
+			for range c.Intn(4) {
+				entry := v1alpha1.NamespacedFolderEntry{VirtualMachines: fuzzNames(c)}
+				if c.RandBool() {
+					entry.ChildFolders = []string{}
+					for range c.Intn(3) {
+						entry.ChildFolders = append(entry.ChildFolders, fuzzName(c)+"/"+fuzzName(c))
+					}
+				}
+				spec.NamespacedFolderEntries[fuzzName(c)+"/"+fuzzName(c)] = entry
+			}
+		},
+		func(spec *FolderIndexSpec, c fuzz.Continue) {
+			c.Fuzz(&spec.ClusterFolderEntries)
+			if c.RandBool() {
+				return
+			}
+			spec.NamespacedFolderEntries = []NamespacedFolderEntry{}
+			for range c.Intn(4) {
+				entry := NamespacedFolderEntry{
+					Namespace:       fuzzName(c),
+					Name:            fuzzName(c),
+					VirtualMachines: fuzzNames(c),
+				}
+				if c.RandBool() {
+					entry.ChildFolders = []NamespacedFolderReference{}
+					for range c.Intn(3) {
+						entry.ChildFolders = append(entry.ChildFolders, NamespacedFolderReference{Namespace: fuzzName(c), Name: fuzzName(c)})
+					}
+				}
+				spec.NamespacedFolderEntries = append(spec.NamespacedFolderEntries, entry)
+			}
+			compare := func(a, b NamespacedFolderEntry) int {
+				return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+			}
+			slices.SortStableFunc(spec.NamespacedFolderEntries, compare)
+			spec.NamespacedFolderEntries = slices.CompactFunc(spec.NamespacedFolderEntries, func(a, b NamespacedFolderEntry) bool {
+				return compare(a, b) == 0
+			})
+		},
+	)
+}
+
+// hubRoundTrip converts hub to spoke and back into a new hub.
+func hubRoundTrip(hub conversion.Hub, spoke conversion.Convertible, result conversion.Hub) {
+	ExpectWithOffset(1, spoke.ConvertFrom(hub)).To(Succeed())
+	ExpectWithOffset(1, spoke.ConvertTo(result)).To(Succeed())
+}
+
+// spokeRoundTrip converts spoke to hub and back into a new spoke.
+func spokeRoundTrip(spoke conversion.Convertible, hub conversion.Hub, result conversion.Convertible) {
+	ExpectWithOffset(1, spoke.ConvertTo(hub)).To(Succeed())
+	ExpectWithOffset(1, result.ConvertFrom(hub)).To(Succeed())
+}
+
+var _ = Describe("Conversion", func() {
+	Context("round trips", func() {
+		It("should convert v1alpha1 ClusterFolders to v1beta1 and back", func() {
+			f := newFuzzer(GinkgoRandomSeed())
+			for range fuzzIterations {
+				in := &v1alpha1.ClusterFolder{}
+				f.Fuzz(in)
+				out := &v1alpha1.ClusterFolder{}
+				hubRoundTrip(in, &ClusterFolder{}, out)
+				Expect(out).To(Equal(in))
+			}
+		})
+
+		It("should convert v1beta1 ClusterFolders to v1alpha1 and back", func() {
+			f := newFuzzer(GinkgoRandomSeed())
+			for range fuzzIterations {
+				in := &ClusterFolder{}
+				f.Fuzz(in)
+				out := &ClusterFolder{}
+				spokeRoundTrip(in, &v1alpha1.ClusterFolder{}, out)
+				Expect(out).To(Equal(in))
+			}
+		})
+
+		It("should convert v1alpha1 NamespacedFolders to v1beta1 and back", func() {
+			f := newFuzzer(GinkgoRandomSeed())
+			for range fuzzIterations {
+				in := &v1alpha1.NamespacedFolder{}
+				f.Fuzz(in)
+				out := &v1alpha1.NamespacedFolder{}
+				hubRoundTrip(in, &NamespacedFolder{}, out)
+				Expect(out).To(Equal(in))
+			}
+		})
+
+		It("should convert v1beta1 NamespacedFolders to v1alpha1 and back", func() {
+			f := newFuzzer(GinkgoRandomSeed())
+			for range fuzzIterations {
+				in := &NamespacedFolder{}
+				f.Fuzz(in)
+				out := &NamespacedFolder{}
+				spokeRoundTrip(in, &v1alpha1.NamespacedFolder{}, out)
+				Expect(out).To(Equal(in))
+			}
+		})
+
+		It("should convert v1alpha1 FolderIndexes to v1beta1 and back", func() {
+			f := newFuzzer(GinkgoRandomSeed())
+			for range fuzzIterations {
+				in := &v1alpha1.FolderIndex{}
+				f.Fuzz(in)
+				out := &v1alpha1.FolderIndex{}
+				hubRoundTrip(in, &FolderIndex{}, out)
+				Expect(out).To(Equal(in))
+			}
+		})
+
+		It("should convert v1beta1 FolderIndexes to v1alpha1 and back", func() {
+			f := newFuzzer(GinkgoRandomSeed())
+			for range fuzzIterations {
+				in := &FolderIndex{}
+				f.Fuzz(in)
+				out := &FolderIndex{}
+				spokeRoundTrip(in, &v1alpha1.FolderIndex{}, out)
+				Expect(out).To(Equal(in))
+			}
+		})
+	})
+
+	It("should keep removed v1alpha1 fields in an annotation", func() {
+		hub := &v1alpha1.ClusterFolder{
+			ObjectMeta: metav1.ObjectMeta{Name: "operations"},
+			Spec: v1alpha1.ClusterFolderSpec{
+				Namespaces: []string{"prod-web-apps"},
+				FolderPermissions: []v1alpha1.FolderPermission{{
+					Subject:  rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "operation-team"},
+					RoleRefs: []rbacv1.RoleRef{{Kind: "ClusterRole", Name: "admin"}},
+				}},
+			},
+		}
+
+		spoke := &ClusterFolder{}
+		Expect(spoke.ConvertFrom(hub)).To(Succeed())
+		Expect(spoke.Annotations).To(Equal(map[string]string{
+			V1alpha1SpecAnnotation: `{"childClusterFolders":null,"namespaces":["prod-web-apps"]}`,
+		}))
+		Expect(spoke.Spec.FolderPermissions).To(Equal([]FolderPermission{{
+			Subject:  rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "operation-team"},
+			RoleRefs: []rbacv1.RoleRef{{Kind: "ClusterRole", Name: "admin"}},
+		}}))
+
+		// a folder without removed fields gets no annotation
+		hub.Spec.Namespaces = nil
+		spoke = &ClusterFolder{}
+		Expect(spoke.ConvertFrom(hub)).To(Succeed())
+		Expect(spoke.Annotations).To(BeNil())
+	})
+
+	It("should convert NamespacedFolder keys to structured references", func() {
+		hub := &v1alpha1.FolderIndex{
+			ObjectMeta: metav1.ObjectMeta{Name: "root"},
+			Spec: v1alpha1.FolderIndexSpec{
+				NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{
+					"prod-web-apps/prod-web-app-b": {VirtualMachines: []string{"web-app-b"}},
+					"prod-web-apps/prod-web-app-a": {
+						ChildFolders:    []string{"prod-web-apps/db"},
+						VirtualMachines: []string{"web-app-a"},
+					},
+					"prod-web-apps/db": {VirtualMachines: []string{"web-app-a-db"}},
+				},
+			},
+		}
+
+		spoke := &FolderIndex{}
+		Expect(spoke.ConvertFrom(hub)).To(Succeed())
+		Expect(spoke.Spec.NamespacedFolderEntries).To(Equal([]NamespacedFolderEntry{
+			{Namespace: "prod-web-apps", Name: "db", VirtualMachines: []string{"web-app-a-db"}},
+			{
+				Namespace:       "prod-web-apps",
+				Name:            "prod-web-app-a",
+				ChildFolders:    []NamespacedFolderReference{{Namespace: "prod-web-apps", Name: "db"}},
+				VirtualMachines: []string{"web-app-a"},
+			},
+			{Namespace: "prod-web-apps", Name: "prod-web-app-b", VirtualMachines: []string{"web-app-b"}},
+		}))
+	})
+
+	It("should reject NamespacedFolders that cannot be keyed", func() {
+		hub := &v1alpha1.FolderIndex{Spec: v1alpha1.FolderIndexSpec{
+			NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{"prod-web-app-a": {}},
+		}}
+		Expect((&FolderIndex{}).ConvertFrom(hub)).To(MatchError(
+			"invalid namespaced folder [prod-web-app-a], expected the format namespace/name"))
+
+		spoke := &FolderIndex{Spec: FolderIndexSpec{
+			NamespacedFolderEntries: []NamespacedFolderEntry{
+				{Namespace: "prod-web-apps", Name: "prod-web-app-a", ChildFolders: []NamespacedFolderReference{{Name: "db"}}},
+			},
+		}}
+		Expect(spoke.ConvertTo(&v1alpha1.FolderIndex{})).To(MatchError(
+			"invalid namespaced folder reference [/db], namespace and name must be set and not contain a slash"))
+
+		spoke.Spec.NamespacedFolderEntries = []NamespacedFolderEntry{
+			{Namespace: "prod-web-apps", Name: "prod-web-app-a"},
+			{Namespace: "prod-web-apps", Name: "prod-web-app-a"},
+		}
+		Expect(spoke.ConvertTo(&v1alpha1.FolderIndex{})).To(MatchError(
+			"duplicate namespaced folder entry [prod-web-apps/prod-web-app-a]"))
+	})
+})
@@ -0,0 +1,253 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// V1alpha1SpecAnnotation holds the v1alpha1 spec fields that v1beta1 no
+// longer has, so that a folder converted to v1beta1 and back keeps them.
+const V1alpha1SpecAnnotation = "kubevirtfolderview.kubevirt.io.github.com/v1alpha1-spec"
+
+// clusterFolderV1alpha1Spec is the part of the v1alpha1 ClusterFolder spec
+// that is kept in the V1alpha1SpecAnnotation.
+type clusterFolderV1alpha1Spec struct {
+	ChildClusterFolders []string `json:"childClusterFolders"`
+	Namespaces          []string `json:"namespaces"`
+}
+
+// ConvertTo converts this ClusterFolder to the Hub version (v1alpha1).
+func (src *ClusterFolder) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.ClusterFolder)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	removed := clusterFolderV1alpha1Spec{}
+	if err := restoreV1alpha1Spec(&dst.ObjectMeta, &removed); err != nil {
+		return err
+	}
+
+	dst.Spec = v1alpha1.ClusterFolderSpec{
+		ChildClusterFolders: removed.ChildClusterFolders,
+		Namespaces:          removed.Namespaces,
+		FolderPermissions:   convertPermissionsToV1alpha1(src.Spec.FolderPermissions),
+		Inheritance:         convertInheritanceToV1alpha1(src.Spec.Inheritance),
+		FolderMetadata:      convertMetadataToV1alpha1(src.Spec.FolderMetadata),
+		Quota:               convertQuotaToV1alpha1(src.Spec.Quota),
+	}
+	dst.Status = v1alpha1.ClusterFolderStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         copyConditions(src.Status.Conditions),
+		Quota:              convertQuotaStatusToV1alpha1(src.Status.Quota),
+	}
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1alpha1) to this ClusterFolder.
+func (dst *ClusterFolder) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.ClusterFolder)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if src.Spec.ChildClusterFolders != nil || src.Spec.Namespaces != nil {
+		removed := clusterFolderV1alpha1Spec{
+			ChildClusterFolders: src.Spec.ChildClusterFolders,
+			Namespaces:          src.Spec.Namespaces,
+		}
+		if err := saveV1alpha1Spec(&dst.ObjectMeta, removed); err != nil {
+			return err
+		}
+	}
+
+	dst.Spec = ClusterFolderSpec{
+		FolderPermissions: convertPermissionsFromV1alpha1(src.Spec.FolderPermissions),
+		Inheritance:       convertInheritanceFromV1alpha1(src.Spec.Inheritance),
+		FolderMetadata:    convertMetadataFromV1alpha1(src.Spec.FolderMetadata),
+		Quota:             convertQuotaFromV1alpha1(src.Spec.Quota),
+	}
+	dst.Status = ClusterFolderStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         copyConditions(src.Status.Conditions),
+		Quota:              convertQuotaStatusFromV1alpha1(src.Status.Quota),
+	}
+	return nil
+}
+
+// saveV1alpha1Spec stores removed v1alpha1 spec fields in the
+// V1alpha1SpecAnnotation of meta.
+func saveV1alpha1Spec(meta *metav1.ObjectMeta, removed interface{}) error {
+	data, err := json.Marshal(removed)
+	if err != nil {
+		return fmt.Errorf("failed to save v1alpha1 spec fields: %v", err)
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[V1alpha1SpecAnnotation] = string(data)
+	return nil
+}
+
+// restoreV1alpha1Spec reads the removed v1alpha1 spec fields saved by
+// saveV1alpha1Spec into removed and drops the annotation from meta.
+func restoreV1alpha1Spec(meta *metav1.ObjectMeta, removed interface{}) error {
+	data, exists := meta.Annotations[V1alpha1SpecAnnotation]
+	if !exists {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(data), removed); err != nil {
+		return fmt.Errorf("invalid annotation [%s]: %v", V1alpha1SpecAnnotation, err)
+	}
+	delete(meta.Annotations, V1alpha1SpecAnnotation)
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+	return nil
+}
+
+func convertPermissionsToV1alpha1(in []FolderPermission) []v1alpha1.FolderPermission {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1alpha1.FolderPermission, 0, len(in))
+	for _, fp := range in {
+		out = append(out, v1alpha1.FolderPermission{
+			Subject:             fp.Subject,
+			RoleRefs:            slices.Clone(fp.RoleRefs),
+			ExcludeFromChildren: fp.ExcludeFromChildren,
+		})
+	}
+	return out
+}
+
+func convertPermissionsFromV1alpha1(in []v1alpha1.FolderPermission) []FolderPermission {
+	if in == nil {
+		return nil
+	}
+	out := make([]FolderPermission, 0, len(in))
+	for _, fp := range in {
+		out = append(out, FolderPermission{
+			Subject:             fp.Subject,
+			RoleRefs:            slices.Clone(fp.RoleRefs),
+			ExcludeFromChildren: fp.ExcludeFromChildren,
+		})
+	}
+	return out
+}
+
+func convertInheritanceToV1alpha1(in *FolderInheritance) *v1alpha1.FolderInheritance {
+	if in == nil {
+		return nil
+	}
+	return &v1alpha1.FolderInheritance{Block: in.Block}
+}
+
+func convertInheritanceFromV1alpha1(in *v1alpha1.FolderInheritance) *FolderInheritance {
+	if in == nil {
+		return nil
+	}
+	return &FolderInheritance{Block: in.Block}
+}
+
+func copyConditions(in []metav1.Condition) []metav1.Condition {
+	if in == nil {
+		return nil
+	}
+	out := make([]metav1.Condition, len(in))
+	for i := range in {
+		in[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
+
+func convertMetadataToV1alpha1(in FolderMetadata) v1alpha1.FolderMetadata {
+	return v1alpha1.FolderMetadata{
+		DisplayName:    in.DisplayName,
+		Description:    in.Description,
+		Owners:         slices.Clone(in.Owners),
+		Tags:           maps.Clone(in.Tags),
+		PropagatedTags: slices.Clone(in.PropagatedTags),
+	}
+}
+
+func convertMetadataFromV1alpha1(in v1alpha1.FolderMetadata) FolderMetadata {
+	return FolderMetadata{
+		DisplayName:    in.DisplayName,
+		Description:    in.Description,
+		Owners:         slices.Clone(in.Owners),
+		Tags:           maps.Clone(in.Tags),
+		PropagatedTags: slices.Clone(in.PropagatedTags),
+	}
+}
+
+func convertQuotaToV1alpha1(in *FolderQuota) *v1alpha1.FolderQuota {
+	if in == nil {
+		return nil
+	}
+	in = in.DeepCopy()
+	return &v1alpha1.FolderQuota{
+		VirtualMachines: in.VirtualMachines,
+		CPU:             in.CPU,
+		Memory:          in.Memory,
+	}
+}
+
+func convertQuotaFromV1alpha1(in *v1alpha1.FolderQuota) *FolderQuota {
+	if in == nil {
+		return nil
+	}
+	in = in.DeepCopy()
+	return &FolderQuota{
+		VirtualMachines: in.VirtualMachines,
+		CPU:             in.CPU,
+		Memory:          in.Memory,
+	}
+}
+
+func convertQuotaStatusToV1alpha1(in *FolderQuotaStatus) *v1alpha1.FolderQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	return &v1alpha1.FolderQuotaStatus{
+		Hard: *convertQuotaToV1alpha1(&in.Hard),
+		Used: v1alpha1.FolderQuotaUsage{
+			VirtualMachines: in.Used.VirtualMachines,
+			CPU:             in.Used.CPU.DeepCopy(),
+			Memory:          in.Used.Memory.DeepCopy(),
+		},
+	}
+}
+
+func convertQuotaStatusFromV1alpha1(in *v1alpha1.FolderQuotaStatus) *FolderQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	return &FolderQuotaStatus{
+		Hard: *convertQuotaFromV1alpha1(&in.Hard),
+		Used: FolderQuotaUsage{
+			VirtualMachines: in.Used.VirtualMachines,
+			CPU:             in.Used.CPU.DeepCopy(),
+			Memory:          in.Used.Memory.DeepCopy(),
+		},
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReadyCondition is the condition type the folder controllers report the
+// outcome of the last reconcile of a folder with.
+const ReadyCondition = "Ready"
+
+// FolderPermission defines what roles are applied to a subject
+// in order for that subject to have permissions to access the folder
+type FolderPermission struct {
+	Subject rbacv1.Subject `json:"subject"`
+
+	RoleRefs []rbacv1.RoleRef `json:"roleRefs,omitempty"`
+
+	// ExcludeFromChildren applies the roles to the direct members of the
+	// folder only, they are not inherited by its descendant folders.
+	// +optional
+	ExcludeFromChildren bool `json:"excludeFromChildren,omitempty"`
+}
+
+// FolderInheritance controls which permissions of its ancestors reach a
+// folder.
+type FolderInheritance struct {
+	// Block stops the permissions of the ancestors of the folder from
+	// reaching the folder and its descendants. Only the permissions of the
+	// folder itself and of its descendants apply to them.
+	// +optional
+	Block bool `json:"block,omitempty"`
+}
+
+// TagLabelPrefix prefixes the labels that the propagated tags of a folder
+// are copied to on the members of the folder.
+const TagLabelPrefix = "tag.folderview.kubevirt.io/"
+
+// Labels that place the members of folders in the folder tree.
+const (
+	// ClusterFolderLabel is set on a namespace to the ClusterFolder that
+	// holds it.
+	ClusterFolderLabel = "folderview.kubevirt.io/cluster-folder"
+	// FolderPathLabel is set on a namespace to the ClusterFolders from the
+	// top of the tree down to the one that holds it, joined with dots.
+	FolderPathLabel = "folderview.kubevirt.io/path"
+	// FolderLabel is set on a VirtualMachine, and on its
+	// VirtualMachineInstance, to the NamespacedFolder that holds it.
+	FolderLabel = "folderview.kubevirt.io/folder"
+	// FoldersLabel is set to "true" on the namespaces that hold members of
+	// folders: the namespaces of ClusterFolders and the namespaces of the
+	// NamespacedFolders in the FolderIndex. The VirtualMachine webhooks only
+	// run in these namespaces.
+	FoldersLabel = "folderview.kubevirt.io/folders"
+)
+
+// Annotations that place new VirtualMachines in folders.
+const (
+	// FolderAnnotation set on a VirtualMachine when it is created files it
+	// into the NamespacedFolder of that name in its namespace. The manager
+	// files the VirtualMachine with a FolderMembership and then removes the
+	// annotation.
+	FolderAnnotation = "folderview.kubevirt.io/folder"
+	// DefaultFolderAnnotation set on a namespace names the NamespacedFolder
+	// VirtualMachines created in the namespace are filed into, unless they
+	// set the FolderAnnotation or are already in a folder. A default folder
+	// that is not in the FolderIndex is skipped, and the annotation is
+	// removed once the folder is deleted.
+	DefaultFolderAnnotation = "folderview.kubevirt.io/default-folder"
+	// FoldersSinceAnnotation is set by the manager on a namespace with the
+	// FoldersLabel to the time it labeled the namespace. The VirtualMachine
+	// webhooks authorize the FolderAnnotation, so the annotation is only
+	// honored on VirtualMachines created after the namespace was labeled.
+	FoldersSinceAnnotation = "folderview.kubevirt.io/folders-since"
+	// PlacedFolderAnnotation is set by the manager on a VirtualMachine to the
+	// NamespacedFolder it last filed the VirtualMachine in, or to "" for a
+	// VirtualMachine in no folder. A VirtualMachine whose folder differs from
+	// the annotation has been placed in a new folder and gets its defaults.
+	// VirtualMachines without the annotation, such as those that existed
+	// before the manager tracked their folder, never have their spec
+	// defaulted.
+	PlacedFolderAnnotation = "folderview.kubevirt.io/placed-folder"
+)
+
+// FolderMetadata describes a folder to people and to other tools.
+type FolderMetadata struct {
+	// DisplayName is a human readable name of the folder.
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Description of what the folder holds.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Owners are the subjects responsible for the folder. Owners are
+	// informational, they are not granted anything.
+	// +optional
+	Owners []rbacv1.Subject `json:"owners,omitempty"`
+
+	// Tags are free-form key/value pairs describing the folder.
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// PropagatedTags are the keys of the tags that are copied as
+	// tag.folderview.kubevirt.io/<key> labels onto the namespaces of a
+	// ClusterFolder and the VirtualMachines of a NamespacedFolder, including
+	// those of descendant folders. The tag of the nearest folder wins. Tags
+	// whose value is not a valid label value are not propagated.
+	// +listType=set
+	// +kubebuilder:validation:items:MaxLength=63
+	// +kubebuilder:validation:items:Pattern=`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`
+	// +optional
+	PropagatedTags []string `json:"propagatedTags,omitempty"`
+}
+
+// FolderQuota caps the VirtualMachines in the namespaces of a ClusterFolder
+// and of its descendant ClusterFolders. Every VirtualMachine counts, whether
+// it is running or not. Fields that are not set are not limited.
+type FolderQuota struct {
+	// VirtualMachines is the maximum number of VirtualMachines.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	VirtualMachines *int64 `json:"virtualMachines,omitempty"`
+
+	// CPU is the maximum number of vCPUs of all VirtualMachines.
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+
+	// Memory is the maximum guest memory of all VirtualMachines.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+}
+
+// FolderQuotaUsage is the number, vCPUs and guest memory of VirtualMachines.
+type FolderQuotaUsage struct {
+	VirtualMachines int64             `json:"virtualMachines"`
+	CPU             resource.Quantity `json:"cpu"`
+	Memory          resource.Quantity `json:"memory"`
+}
+
+// FolderQuotaStatus reports the usage of the quota of a ClusterFolder.
+type FolderQuotaStatus struct {
+	// Hard is the quota Used was last compared with.
+	Hard FolderQuota `json:"hard"`
+
+	// Used is the usage of the VirtualMachines the quota applies to.
+	Used FolderQuotaUsage `json:"used"`
+}
+
+// ClusterFolderSpec defines the desired state of ClusterFolder. The
+// folders and namespaces a ClusterFolder contains are kept in the
+// FolderIndex.
+type ClusterFolderSpec struct {
+	// +optional
+	FolderPermissions []FolderPermission `json:"folderPermissions,omitempty"`
+
+	// Inheritance controls which permissions of the ancestors of the folder
+	// reach it.
+	// +optional
+	Inheritance *FolderInheritance `json:"inheritance,omitempty"`
+
+	FolderMetadata `json:",inline"`
+
+	// Quota caps the VirtualMachines in the namespaces of the folder and of
+	// its descendants.
+	// +optional
+	Quota *FolderQuota `json:"quota,omitempty"`
+}
+
+// ClusterFolderStatus defines the observed state of ClusterFolder.
+type ClusterFolderStatus struct {
+	// ObservedGeneration is the generation of the spec the controller last
+	// reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions report whether the RoleBindings of the folder are in place.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Quota reports the usage of the quota of the folder.
+	// +optional
+	Quota *FolderQuotaStatus `json:"quota,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// ClusterFolder is the Schema for the folders API.
+type ClusterFolder struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterFolderSpec   `json:"spec,omitempty"`
+	Status ClusterFolderStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterFolderList contains a list of ClusterFolder.
+type ClusterFolderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterFolder `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterFolder{}, &ClusterFolderList{})
+}
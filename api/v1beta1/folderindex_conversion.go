@@ -0,0 +1,187 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// ConvertTo converts this FolderIndex to the Hub version (v1alpha1), where
+// NamespacedFolders are keyed and referenced by "namespace/name".
+func (src *FolderIndex) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.FolderIndex)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = v1alpha1.FolderIndexSpec{}
+
+	if src.Spec.ClusterFolderEntries != nil {
+		dst.Spec.ClusterFolderEntries = make(map[string]v1alpha1.ClusterFolderEntry, len(src.Spec.ClusterFolderEntries))
+		for name, entry := range src.Spec.ClusterFolderEntries {
+			dst.Spec.ClusterFolderEntries[name] = v1alpha1.ClusterFolderEntry{
+				ChildFolders: slices.Clone(entry.ChildFolders),
+				Namespaces:   slices.Clone(entry.Namespaces),
+			}
+		}
+	}
+
+	if src.Spec.NamespacedFolderEntries != nil {
+		dst.Spec.NamespacedFolderEntries = make(map[string]v1alpha1.NamespacedFolderEntry, len(src.Spec.NamespacedFolderEntries))
+		for _, entry := range src.Spec.NamespacedFolderEntries {
+			ref := NamespacedFolderReference{Namespace: entry.Namespace, Name: entry.Name}
+			if err := ref.Validate(); err != nil {
+				return err
+			}
+			key := ref.Key()
+			if _, exists := dst.Spec.NamespacedFolderEntries[key]; exists {
+				return fmt.Errorf("duplicate namespaced folder entry [%s]", key)
+			}
+
+			var children []string
+			if entry.ChildFolders != nil {
+				children = make([]string, 0, len(entry.ChildFolders))
+			}
+			for _, child := range entry.ChildFolders {
+				if err := child.Validate(); err != nil {
+					return err
+				}
+				children = append(children, child.Key())
+			}
+
+			dst.Spec.NamespacedFolderEntries[key] = v1alpha1.NamespacedFolderEntry{
+				ChildFolders:    children,
+				VirtualMachines: slices.Clone(entry.VirtualMachines),
+			}
+		}
+	}
+
+	dst.Spec.DeletedMemberPolicy = v1alpha1.DeletedMemberPolicy(src.Spec.DeletedMemberPolicy)
+
+	dst.Status = v1alpha1.FolderIndexStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         copyConditions(src.Status.Conditions),
+	}
+	if src.Status.DeletedMembers != nil {
+		dst.Status.DeletedMembers = make([]v1alpha1.DeletedMember, 0, len(src.Status.DeletedMembers))
+	}
+	for _, member := range src.Status.DeletedMembers {
+		dst.Status.DeletedMembers = append(dst.Status.DeletedMembers, v1alpha1.DeletedMember{
+			Kind:         member.Kind,
+			Namespace:    member.Namespace,
+			Name:         member.Name,
+			DeletionTime: member.DeletionTime,
+		})
+	}
+	if src.Status.ObservedMembers != nil {
+		dst.Status.ObservedMembers = make([]v1alpha1.ObservedMember, 0, len(src.Status.ObservedMembers))
+	}
+	for _, member := range src.Status.ObservedMembers {
+		dst.Status.ObservedMembers = append(dst.Status.ObservedMembers, v1alpha1.ObservedMember{
+			Kind:      member.Kind,
+			Namespace: member.Namespace,
+			Name:      member.Name,
+			UID:       member.UID,
+		})
+	}
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1alpha1) to this FolderIndex. The
+// NamespacedFolder entries are sorted by namespace and name.
+func (dst *FolderIndex) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.FolderIndex)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = FolderIndexSpec{}
+
+	if src.Spec.ClusterFolderEntries != nil {
+		dst.Spec.ClusterFolderEntries = make(map[string]ClusterFolderEntry, len(src.Spec.ClusterFolderEntries))
+		for name, entry := range src.Spec.ClusterFolderEntries {
+			dst.Spec.ClusterFolderEntries[name] = ClusterFolderEntry{
+				ChildFolders: slices.Clone(entry.ChildFolders),
+				Namespaces:   slices.Clone(entry.Namespaces),
+			}
+		}
+	}
+
+	if src.Spec.NamespacedFolderEntries != nil {
+		dst.Spec.NamespacedFolderEntries = make([]NamespacedFolderEntry, 0, len(src.Spec.NamespacedFolderEntries))
+		for key, entry := range src.Spec.NamespacedFolderEntries {
+			ref, err := ParseNamespacedFolderReference(key)
+			if err != nil {
+				return err
+			}
+
+			var children []NamespacedFolderReference
+			if entry.ChildFolders != nil {
+				children = make([]NamespacedFolderReference, 0, len(entry.ChildFolders))
+			}
+			for _, childKey := range entry.ChildFolders {
+				child, err := ParseNamespacedFolderReference(childKey)
+				if err != nil {
+					return err
+				}
+				children = append(children, child)
+			}
+
+			dst.Spec.NamespacedFolderEntries = append(dst.Spec.NamespacedFolderEntries, NamespacedFolderEntry{
+				Namespace:       ref.Namespace,
+				Name:            ref.Name,
+				ChildFolders:    children,
+				VirtualMachines: slices.Clone(entry.VirtualMachines),
+			})
+		}
+		slices.SortFunc(dst.Spec.NamespacedFolderEntries, func(a, b NamespacedFolderEntry) int {
+			return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+		})
+	}
+
+	dst.Spec.DeletedMemberPolicy = DeletedMemberPolicy(src.Spec.DeletedMemberPolicy)
+
+	dst.Status = FolderIndexStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         copyConditions(src.Status.Conditions),
+	}
+	if src.Status.DeletedMembers != nil {
+		dst.Status.DeletedMembers = make([]DeletedMember, 0, len(src.Status.DeletedMembers))
+	}
+	for _, member := range src.Status.DeletedMembers {
+		dst.Status.DeletedMembers = append(dst.Status.DeletedMembers, DeletedMember{
+			Kind:         member.Kind,
+			Namespace:    member.Namespace,
+			Name:         member.Name,
+			DeletionTime: member.DeletionTime,
+		})
+	}
+	if src.Status.ObservedMembers != nil {
+		dst.Status.ObservedMembers = make([]ObservedMember, 0, len(src.Status.ObservedMembers))
+	}
+	for _, member := range src.Status.ObservedMembers {
+		dst.Status.ObservedMembers = append(dst.Status.ObservedMembers, ObservedMember{
+			Kind:      member.Kind,
+			Namespace: member.Namespace,
+			Name:      member.Name,
+			UID:       member.UID,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NamespacedFolderReference names a NamespacedFolder.
+type NamespacedFolderReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// NamespacedFolderEntry holds the folders and VirtualMachines of the
+// NamespacedFolder it names.
+type NamespacedFolderEntry struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// ChildFolders must be in the namespace of the entry.
+	// +listType=map
+	// +listMapKey=namespace
+	// +listMapKey=name
+	// +optional
+	ChildFolders []NamespacedFolderReference `json:"childFolders,omitempty"`
+	// +listType=set
+	// +optional
+	VirtualMachines []string `json:"virtualMachines,omitempty"`
+}
+
+// ClusterFolderEntry holds the folders and namespaces of a ClusterFolder.
+type ClusterFolderEntry struct {
+	// +listType=set
+	// +optional
+	ChildFolders []string `json:"childFolders,omitempty"`
+	// +listType=set
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// DeletedMemberPolicy decides what happens to the namespaces and
+// VirtualMachines of the index when they are deleted.
+// +kubebuilder:validation:Enum=Prune;Retain
+type DeletedMemberPolicy string
+
+const (
+	// DeletedMemberPolicyPrune removes deleted members from the index.
+	DeletedMemberPolicyPrune DeletedMemberPolicy = "Prune"
+	// DeletedMemberPolicyRetain keeps deleted members in the index and
+	// flags them in the status of the index.
+	DeletedMemberPolicyRetain DeletedMemberPolicy = "Retain"
+)
+
+// DeletedMember is a namespace or VirtualMachine of the index that was
+// deleted.
+type DeletedMember struct {
+	// +kubebuilder:validation:Enum=Namespace;VirtualMachine
+	Kind string `json:"kind"`
+
+	// Namespace of a VirtualMachine.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	Name string `json:"name"`
+
+	// DeletionTime is when the controller saw the member deleted.
+	DeletionTime metav1.Time `json:"deletionTime"`
+}
+
+// ObservedMember is a namespace or VirtualMachine of the index that the
+// controller saw exist.
+type ObservedMember struct {
+	// +kubebuilder:validation:Enum=Namespace;VirtualMachine
+	Kind string `json:"kind"`
+
+	// Namespace of a VirtualMachine.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	Name string `json:"name"`
+
+	// UID of the member when the controller saw it.
+	UID types.UID `json:"uid"`
+}
+
+// FolderIndexSpec defines the desired state of FolderIndex.
+type FolderIndexSpec struct {
+	// ClusterFolderEntries are keyed by the name of the ClusterFolder.
+	// +optional
+	ClusterFolderEntries map[string]ClusterFolderEntry `json:"clusterFolderEntries,omitempty"`
+
+	// NamespacedFolderEntries are keyed by the namespace and name of the
+	// NamespacedFolder, so server-side apply merges entries written by
+	// different field managers.
+	// +listType=map
+	// +listMapKey=namespace
+	// +listMapKey=name
+	// +optional
+	NamespacedFolderEntries []NamespacedFolderEntry `json:"namespacedFolderEntries,omitempty"`
+
+	// DeletedMemberPolicy decides what happens to namespaces and
+	// VirtualMachines of the index that are deleted. Prune, the default,
+	// removes them from the index. Retain keeps them and flags them in
+	// status.deletedMembers. Flagged members get no access or labels from
+	// their folders, so an object later created with the same name does
+	// not inherit them, until they are removed from the index.
+	// +optional
+	DeletedMemberPolicy DeletedMemberPolicy `json:"deletedMemberPolicy,omitempty"`
+}
+
+// FolderIndexStatus defines the observed state of FolderIndex.
+type FolderIndexStatus struct {
+	// ObservedGeneration is the generation of the spec the controller last
+	// reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions report the state of the index.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// DeletedMembers are the members the Retain policy kept in the index
+	// after they were deleted.
+	// +optional
+	DeletedMembers []DeletedMember `json:"deletedMembers,omitempty"`
+
+	// ObservedMembers are the members of the index the controller saw
+	// exist. Only these are taken for deleted when they are missing, so
+	// members filed before they are created stay in the index.
+	// +optional
+	ObservedMembers []ObservedMember `json:"observedMembers,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+
+// FolderIndex is the Schema for the folderindices API.
+type FolderIndex struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FolderIndexSpec   `json:"spec,omitempty"`
+	Status FolderIndexStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FolderIndexList contains a list of FolderIndex.
+type FolderIndexList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FolderIndex `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FolderIndex{}, &FolderIndexList{})
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"maps"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// namespacedFolderV1alpha1Spec is the part of the v1alpha1 NamespacedFolder
+// spec that is kept in the V1alpha1SpecAnnotation.
+type namespacedFolderV1alpha1Spec struct {
+	ChildNamespacedFolders []string `json:"childNamespacedFolders"`
+	VirtualMachines        []string `json:"virtualMachines"`
+}
+
+// ConvertTo converts this NamespacedFolder to the Hub version (v1alpha1).
+func (src *NamespacedFolder) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.NamespacedFolder)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	removed := namespacedFolderV1alpha1Spec{}
+	if err := restoreV1alpha1Spec(&dst.ObjectMeta, &removed); err != nil {
+		return err
+	}
+
+	dst.Spec = v1alpha1.NamespacedFolderSpec{
+		ChildNamespacedFolders: removed.ChildNamespacedFolders,
+		VirtualMachines:        removed.VirtualMachines,
+		FolderPermissions:      convertPermissionsToV1alpha1(src.Spec.FolderPermissions),
+		Inheritance:            convertInheritanceToV1alpha1(src.Spec.Inheritance),
+		FolderMetadata:         convertMetadataToV1alpha1(src.Spec.FolderMetadata),
+		Limits:                 convertLimitsToV1alpha1(src.Spec.Limits),
+		Defaults:               convertDefaultsToV1alpha1(src.Spec.Defaults),
+	}
+	dst.Status = v1alpha1.NamespacedFolderStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         copyConditions(src.Status.Conditions),
+	}
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1alpha1) to this NamespacedFolder.
+func (dst *NamespacedFolder) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.NamespacedFolder)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if src.Spec.ChildNamespacedFolders != nil || src.Spec.VirtualMachines != nil {
+		removed := namespacedFolderV1alpha1Spec{
+			ChildNamespacedFolders: src.Spec.ChildNamespacedFolders,
+			VirtualMachines:        src.Spec.VirtualMachines,
+		}
+		if err := saveV1alpha1Spec(&dst.ObjectMeta, removed); err != nil {
+			return err
+		}
+	}
+
+	dst.Spec = NamespacedFolderSpec{
+		FolderPermissions: convertPermissionsFromV1alpha1(src.Spec.FolderPermissions),
+		Inheritance:       convertInheritanceFromV1alpha1(src.Spec.Inheritance),
+		FolderMetadata:    convertMetadataFromV1alpha1(src.Spec.FolderMetadata),
+		Limits:            convertLimitsFromV1alpha1(src.Spec.Limits),
+		Defaults:          convertDefaultsFromV1alpha1(src.Spec.Defaults),
+	}
+	dst.Status = NamespacedFolderStatus{
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Conditions:         copyConditions(src.Status.Conditions),
+	}
+	return nil
+}
+
+func convertLimitsToV1alpha1(in *FolderLimits) *v1alpha1.FolderLimits {
+	if in == nil {
+		return nil
+	}
+	in = in.DeepCopy()
+	return &v1alpha1.FolderLimits{
+		VirtualMachines:         in.VirtualMachines,
+		CPUPerVirtualMachine:    in.CPUPerVirtualMachine,
+		MemoryPerVirtualMachine: in.MemoryPerVirtualMachine,
+		Instancetypes:           in.Instancetypes,
+		Preferences:             in.Preferences,
+	}
+}
+
+func convertLimitsFromV1alpha1(in *v1alpha1.FolderLimits) *FolderLimits {
+	if in == nil {
+		return nil
+	}
+	in = in.DeepCopy()
+	return &FolderLimits{
+		VirtualMachines:         in.VirtualMachines,
+		CPUPerVirtualMachine:    in.CPUPerVirtualMachine,
+		MemoryPerVirtualMachine: in.MemoryPerVirtualMachine,
+		Instancetypes:           in.Instancetypes,
+		Preferences:             in.Preferences,
+	}
+}
+
+func convertDefaultsToV1alpha1(in *FolderDefaults) *v1alpha1.FolderDefaults {
+	if in == nil {
+		return nil
+	}
+	out := &v1alpha1.FolderDefaults{
+		Labels:      maps.Clone(in.Labels),
+		RunStrategy: in.RunStrategy,
+	}
+	if in.Instancetype != nil {
+		out.Instancetype = &v1alpha1.InstancetypeReference{Name: in.Instancetype.Name, Kind: in.Instancetype.Kind}
+	}
+	return out
+}
+
+func convertDefaultsFromV1alpha1(in *v1alpha1.FolderDefaults) *FolderDefaults {
+	if in == nil {
+		return nil
+	}
+	out := &FolderDefaults{
+		Labels:      maps.Clone(in.Labels),
+		RunStrategy: in.RunStrategy,
+	}
+	if in.Instancetype != nil {
+		out.Instancetype = &InstancetypeReference{Name: in.Instancetype.Name, Kind: in.Instancetype.Kind}
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseNamespacedFolderReference parses the "namespace/name" key the
+// v1alpha1 FolderIndex names a NamespacedFolder by.
+func ParseNamespacedFolderReference(key string) (NamespacedFolderReference, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return NamespacedFolderReference{}, fmt.Errorf("invalid namespaced folder [%s], expected the format namespace/name", key)
+	}
+	return NamespacedFolderReference{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// Validate checks that the reference can be written as a "namespace/name"
+// key.
+func (r NamespacedFolderReference) Validate() error {
+	if r.Namespace == "" || r.Name == "" || strings.Contains(r.Namespace, "/") || strings.Contains(r.Name, "/") {
+		return fmt.Errorf("invalid namespaced folder reference [%s/%s], namespace and name must be set and not contain a slash", r.Namespace, r.Name)
+	}
+	return nil
+}
+
+// Key returns the "namespace/name" key of the reference.
+func (r NamespacedFolderReference) Key() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// String returns the "namespace/name" form of the reference.
+func (r NamespacedFolderReference) String() string {
+	return r.Key()
+}
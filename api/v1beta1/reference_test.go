@@ -0,0 +1,44 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NamespacedFolderReference", func() {
+	It("should parse namespace/name keys", func() {
+		ref, err := ParseNamespacedFolderReference("prod-web-apps/prod-web-app-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ref).To(Equal(NamespacedFolderReference{Namespace: "prod-web-apps", Name: "prod-web-app-a"}))
+		Expect(ref.Key()).To(Equal("prod-web-apps/prod-web-app-a"))
+	})
+
+	It("should reject malformed keys", func() {
+		for _, key := range []string{"", "prod-web-app-a", "/prod-web-app-a", "prod-web-apps/", "prod/web/app"} {
+			_, err := ParseNamespacedFolderReference(key)
+			Expect(err).To(HaveOccurred(), key)
+		}
+	})
+
+	It("should reject references that cannot be keyed", func() {
+		Expect(NamespacedFolderReference{Namespace: "prod-web-apps", Name: "prod-web-app-a"}.Validate()).To(Succeed())
+		Expect(NamespacedFolderReference{Name: "prod-web-app-a"}.Validate()).NotTo(Succeed())
+		Expect(NamespacedFolderReference{Namespace: "prod-web-apps", Name: "a/b"}.Validate()).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,697 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFolder) DeepCopyInto(out *ClusterFolder) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFolder.
+func (in *ClusterFolder) DeepCopy() *ClusterFolder {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFolder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterFolder) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFolderEntry) DeepCopyInto(out *ClusterFolderEntry) {
+	*out = *in
+	if in.ChildFolders != nil {
+		in, out := &in.ChildFolders, &out.ChildFolders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFolderEntry.
+func (in *ClusterFolderEntry) DeepCopy() *ClusterFolderEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFolderEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFolderList) DeepCopyInto(out *ClusterFolderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterFolder, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFolderList.
+func (in *ClusterFolderList) DeepCopy() *ClusterFolderList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFolderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterFolderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFolderSpec) DeepCopyInto(out *ClusterFolderSpec) {
+	*out = *in
+	if in.FolderPermissions != nil {
+		in, out := &in.FolderPermissions, &out.FolderPermissions
+		*out = make([]FolderPermission, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Inheritance != nil {
+		in, out := &in.Inheritance, &out.Inheritance
+		*out = new(FolderInheritance)
+		**out = **in
+	}
+	in.FolderMetadata.DeepCopyInto(&out.FolderMetadata)
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(FolderQuota)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFolderSpec.
+func (in *ClusterFolderSpec) DeepCopy() *ClusterFolderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFolderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterFolderStatus) DeepCopyInto(out *ClusterFolderStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(FolderQuotaStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterFolderStatus.
+func (in *ClusterFolderStatus) DeepCopy() *ClusterFolderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterFolderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletedMember) DeepCopyInto(out *DeletedMember) {
+	*out = *in
+	in.DeletionTime.DeepCopyInto(&out.DeletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletedMember.
+func (in *DeletedMember) DeepCopy() *DeletedMember {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletedMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderDefaults) DeepCopyInto(out *FolderDefaults) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Instancetype != nil {
+		in, out := &in.Instancetype, &out.Instancetype
+		*out = new(InstancetypeReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderDefaults.
+func (in *FolderDefaults) DeepCopy() *FolderDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderIndex) DeepCopyInto(out *FolderIndex) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderIndex.
+func (in *FolderIndex) DeepCopy() *FolderIndex {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderIndex)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FolderIndex) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderIndexList) DeepCopyInto(out *FolderIndexList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FolderIndex, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderIndexList.
+func (in *FolderIndexList) DeepCopy() *FolderIndexList {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderIndexList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FolderIndexList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderIndexSpec) DeepCopyInto(out *FolderIndexSpec) {
+	*out = *in
+	if in.ClusterFolderEntries != nil {
+		in, out := &in.ClusterFolderEntries, &out.ClusterFolderEntries
+		*out = make(map[string]ClusterFolderEntry, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.NamespacedFolderEntries != nil {
+		in, out := &in.NamespacedFolderEntries, &out.NamespacedFolderEntries
+		*out = make([]NamespacedFolderEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderIndexSpec.
+func (in *FolderIndexSpec) DeepCopy() *FolderIndexSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderIndexSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderIndexStatus) DeepCopyInto(out *FolderIndexStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeletedMembers != nil {
+		in, out := &in.DeletedMembers, &out.DeletedMembers
+		*out = make([]DeletedMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ObservedMembers != nil {
+		in, out := &in.ObservedMembers, &out.ObservedMembers
+		*out = make([]ObservedMember, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderIndexStatus.
+func (in *FolderIndexStatus) DeepCopy() *FolderIndexStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderIndexStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderInheritance) DeepCopyInto(out *FolderInheritance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderInheritance.
+func (in *FolderInheritance) DeepCopy() *FolderInheritance {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderInheritance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderLimits) DeepCopyInto(out *FolderLimits) {
+	*out = *in
+	if in.VirtualMachines != nil {
+		in, out := &in.VirtualMachines, &out.VirtualMachines
+		*out = new(int64)
+		**out = **in
+	}
+	if in.CPUPerVirtualMachine != nil {
+		in, out := &in.CPUPerVirtualMachine, &out.CPUPerVirtualMachine
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MemoryPerVirtualMachine != nil {
+		in, out := &in.MemoryPerVirtualMachine, &out.MemoryPerVirtualMachine
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Instancetypes != nil {
+		in, out := &in.Instancetypes, &out.Instancetypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Preferences != nil {
+		in, out := &in.Preferences, &out.Preferences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderLimits.
+func (in *FolderLimits) DeepCopy() *FolderLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderMetadata) DeepCopyInto(out *FolderMetadata) {
+	*out = *in
+	if in.Owners != nil {
+		in, out := &in.Owners, &out.Owners
+		*out = make([]v1.Subject, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PropagatedTags != nil {
+		in, out := &in.PropagatedTags, &out.PropagatedTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderMetadata.
+func (in *FolderMetadata) DeepCopy() *FolderMetadata {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderMetadata)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderPermission) DeepCopyInto(out *FolderPermission) {
+	*out = *in
+	out.Subject = in.Subject
+	if in.RoleRefs != nil {
+		in, out := &in.RoleRefs, &out.RoleRefs
+		*out = make([]v1.RoleRef, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderPermission.
+func (in *FolderPermission) DeepCopy() *FolderPermission {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderPermission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderQuota) DeepCopyInto(out *FolderQuota) {
+	*out = *in
+	if in.VirtualMachines != nil {
+		in, out := &in.VirtualMachines, &out.VirtualMachines
+		*out = new(int64)
+		**out = **in
+	}
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderQuota.
+func (in *FolderQuota) DeepCopy() *FolderQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderQuotaStatus) DeepCopyInto(out *FolderQuotaStatus) {
+	*out = *in
+	in.Hard.DeepCopyInto(&out.Hard)
+	in.Used.DeepCopyInto(&out.Used)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderQuotaStatus.
+func (in *FolderQuotaStatus) DeepCopy() *FolderQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FolderQuotaUsage) DeepCopyInto(out *FolderQuotaUsage) {
+	*out = *in
+	out.CPU = in.CPU.DeepCopy()
+	out.Memory = in.Memory.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FolderQuotaUsage.
+func (in *FolderQuotaUsage) DeepCopy() *FolderQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(FolderQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstancetypeReference) DeepCopyInto(out *InstancetypeReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstancetypeReference.
+func (in *InstancetypeReference) DeepCopy() *InstancetypeReference {
+	if in == nil {
+		return nil
+	}
+	out := new(InstancetypeReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedFolder) DeepCopyInto(out *NamespacedFolder) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolder.
+func (in *NamespacedFolder) DeepCopy() *NamespacedFolder {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedFolder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespacedFolder) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedFolderEntry) DeepCopyInto(out *NamespacedFolderEntry) {
+	*out = *in
+	if in.ChildFolders != nil {
+		in, out := &in.ChildFolders, &out.ChildFolders
+		*out = make([]NamespacedFolderReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.VirtualMachines != nil {
+		in, out := &in.VirtualMachines, &out.VirtualMachines
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolderEntry.
+func (in *NamespacedFolderEntry) DeepCopy() *NamespacedFolderEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedFolderEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedFolderList) DeepCopyInto(out *NamespacedFolderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NamespacedFolder, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolderList.
+func (in *NamespacedFolderList) DeepCopy() *NamespacedFolderList {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedFolderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespacedFolderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedFolderReference) DeepCopyInto(out *NamespacedFolderReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolderReference.
+func (in *NamespacedFolderReference) DeepCopy() *NamespacedFolderReference {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedFolderReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedFolderSpec) DeepCopyInto(out *NamespacedFolderSpec) {
+	*out = *in
+	if in.FolderPermissions != nil {
+		in, out := &in.FolderPermissions, &out.FolderPermissions
+		*out = make([]FolderPermission, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Inheritance != nil {
+		in, out := &in.Inheritance, &out.Inheritance
+		*out = new(FolderInheritance)
+		**out = **in
+	}
+	in.FolderMetadata.DeepCopyInto(&out.FolderMetadata)
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(FolderLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(FolderDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolderSpec.
+func (in *NamespacedFolderSpec) DeepCopy() *NamespacedFolderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedFolderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedFolderStatus) DeepCopyInto(out *NamespacedFolderStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedFolderStatus.
+func (in *NamespacedFolderStatus) DeepCopy() *NamespacedFolderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedFolderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservedMember) DeepCopyInto(out *ObservedMember) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservedMember.
+func (in *ObservedMember) DeepCopy() *ObservedMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservedMember)
+	in.DeepCopyInto(out)
+	return out
+}
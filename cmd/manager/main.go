@@ -40,9 +40,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	virtv1 "kubevirt.io/api/core/v1"
+	instancetypev1beta1 "kubevirt.io/api/instancetype/v1beta1"
 
 	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	kubevirtfolderviewkubevirtiov1beta1 "github.com/davidvossel/kubevirt-folder-view/api/v1beta1"
 	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	webhookkubevirtiov1 "github.com/davidvossel/kubevirt-folder-view/internal/webhook/v1"
 	webhookkubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
@@ -56,9 +59,11 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(kubevirtfolderviewkubevirtiov1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kubevirtfolderviewkubevirtiov1beta1.AddToScheme(scheme))
 	utilruntime.Must(rbacv1.AddToScheme(scheme))
 	utilruntime.Must(corev1.AddToScheme(scheme))
 	utilruntime.Must(virtv1.AddToScheme(scheme))
+	utilruntime.Must(instancetypev1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -231,6 +236,28 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "FolderIndex")
 		os.Exit(1)
 	}
+	if err = (&controller.NamespaceReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Namespace")
+		os.Exit(1)
+	}
+	if err = (&controller.VirtualMachineReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		WebhooksEnabled: os.Getenv("ENABLE_WEBHOOKS") != "false",
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VirtualMachine")
+		os.Exit(1)
+	}
+	if err = (&controller.DeletedMemberReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DeletedMember")
+		os.Exit(1)
+	}
 	// nolint:goconst
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
 		if err = webhookkubevirtfolderviewkubevirtiov1alpha1.SetupFolderIndexWebhookWithManager(mgr); err != nil {
@@ -238,6 +265,34 @@ func main() {
 			os.Exit(1)
 		}
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookkubevirtfolderviewkubevirtiov1alpha1.SetupClusterFolderWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterFolder")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookkubevirtfolderviewkubevirtiov1alpha1.SetupNamespacedFolderWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NamespacedFolder")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookkubevirtfolderviewkubevirtiov1alpha1.SetupFolderMembershipWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "FolderMembership")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookkubevirtiov1.SetupVirtualMachineWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VirtualMachine")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -0,0 +1,353 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	virtv1 "kubevirt.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logger "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// DeletedMemberReconciler takes deleted Namespaces and VirtualMachines out of
+// the FolderIndex, so an object later created with the same name does not
+// inherit the permissions of their folders. Depending on the
+// DeletedMemberPolicy of the index, deleted members are pruned from the spec
+// or retained there and flagged in the status.
+//
+// Delete events are the fast path. Deletions the controllers never see, such
+// as those made while the manager is down, are caught by a sweep at startup
+// and every SweepInterval that compares the index and the FolderMemberships
+// with the live Namespaces and VirtualMachines. The sweep only takes out
+// members it saw exist before, so members filed ahead of their creation stay.
+type DeletedMemberReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// SweepInterval is the period of the sweep, DefaultSweepInterval when
+	// zero.
+	SweepInterval time.Duration
+}
+
+// DefaultSweepInterval is the default period of the sweep for deleted members.
+const DefaultSweepInterval = 10 * time.Minute
+
+// deletedOnly passes delete events only. Requests of the
+// DeletedMemberReconciler therefore always stand for a deletion, even when an
+// object with the same name exists again by the time they are reconciled.
+var deletedOnly = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	UpdateFunc:  func(event.UpdateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return true },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// removeMembers prunes the deleted members from the FolderIndex, or flags
+// them in the status when the index retains deleted members.
+func (r *DeletedMemberReconciler) removeMembers(ctx context.Context, members ...v1alpha1.DeletedMember) error {
+	log := logger.FromContext(ctx)
+
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	orig := root.DeepCopy()
+	changed := false
+	if root.Spec.DeletedMemberPolicy == v1alpha1.DeletedMemberPolicyRetain {
+		for _, member := range members {
+			if !folderindex.InIndex(root, member) || folderindex.IsDeletedMember(root, member.Kind, member.Namespace, member.Name) {
+				continue
+			}
+			member.DeletionTime = metav1.Now()
+			root.Status.DeletedMembers = append(root.Status.DeletedMembers, member)
+			log.Info(fmt.Sprintf("Flagging deleted %s [%s/%s] in the folder index", member.Kind, member.Namespace, member.Name))
+			changed = true
+		}
+		if folderindex.ForgetMembers(root, members...) {
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		return r.Client.Status().Patch(ctx, root, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+	}
+
+	for _, member := range members {
+		removed := false
+		switch member.Kind {
+		case folderindex.NamespaceKind:
+			removed = folderindex.RemoveNamespace(root, member.Name)
+		case folderindex.VirtualMachineKind:
+			removed = folderindex.RemoveVirtualMachine(root, member.Namespace, member.Name)
+		}
+		if removed {
+			log.Info(fmt.Sprintf("Pruning deleted %s [%s/%s] from the folder index", member.Kind, member.Namespace, member.Name))
+			changed = true
+		}
+	}
+	if changed {
+		if err := r.Client.Patch(ctx, root, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); err != nil {
+			return err
+		}
+	}
+
+	// a member filed again under the same name must be seen anew before it
+	// is taken for deleted
+	orig = root.DeepCopy()
+	if !folderindex.ForgetMembers(root, members...) {
+		return nil
+	}
+	return r.Client.Status().Patch(ctx, root, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+}
+
+func (r *DeletedMemberReconciler) reconcileNamespace(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return ctrl.Result{}, r.removeMembers(ctx, v1alpha1.DeletedMember{
+		Kind: folderindex.NamespaceKind,
+		Name: req.Name,
+	})
+}
+
+func (r *DeletedMemberReconciler) reconcileVirtualMachine(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// a FolderMembership outlives its VirtualMachine unless the
+	// VirtualMachine owns it, and would file a new VirtualMachine with the
+	// same name, whatever the policy of the index
+	membership := &v1alpha1.FolderMembership{}
+	if err := r.Client.Get(ctx, req.NamespacedName, membership); err == nil {
+		if err := r.Client.Delete(ctx, membership); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, r.removeMembers(ctx, v1alpha1.DeletedMember{
+		Kind:      folderindex.VirtualMachineKind,
+		Namespace: req.Namespace,
+		Name:      req.Name,
+	})
+}
+
+// sweep takes the members of the FolderIndex that were seen and then deleted
+// out of the index, deletes the FolderMemberships of VirtualMachines that were
+// deleted or recreated since the membership was made for them, and clears
+// default folders that were deleted.
+//
+// A member is seen when a sweep finds it live, and is recorded with its UID
+// in status.observedMembers. Members that were never seen, such as
+// namespaces and VirtualMachines filed before they are created, or members
+// the caches of the live objects do not show yet, are left in the index.
+func (r *DeletedMemberReconciler) sweep(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	memberships := &v1alpha1.FolderMembershipList{}
+	if err := r.Client.List(ctx, memberships); err != nil {
+		return err
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := r.Client.List(ctx, namespaceList); err != nil {
+		return err
+	}
+	namespaces := map[string]types.UID{}
+	for _, namespace := range namespaceList.Items {
+		namespaces[namespace.Name] = namespace.UID
+	}
+	vmList := &virtv1.VirtualMachineList{}
+	if err := r.Client.List(ctx, vmList); err != nil {
+		return err
+	}
+	vms := map[string]*virtv1.VirtualMachine{}
+	vmUIDs := map[string]types.UID{}
+	for i := range vmList.Items {
+		vm := &vmList.Items[i]
+		key := folderindex.NamespacedFolderKey(vm.Namespace, vm.Name)
+		vms[key] = vm
+		vmUIDs[key] = vm.UID
+	}
+
+	if err := r.clearDefaultFolders(ctx, root, namespaceList.Items); err != nil {
+		return err
+	}
+
+	for i := range memberships.Items {
+		membership := &memberships.Items[i]
+		vm, exists := vms[folderindex.NamespacedFolderKey(membership.Namespace, membership.Name)]
+		switch {
+		case !ownedByVirtualMachine(membership):
+			// a membership made before its VirtualMachine is created, or by
+			// kubectl folder, is adopted once the VirtualMachine exists, so
+			// it is deleted with it
+			if !exists {
+				continue
+			}
+			log.Info(fmt.Sprintf("Adopting FolderMembership [%s/%s] by its VirtualMachine", membership.Namespace, membership.Name))
+			orig := membership.DeepCopy()
+			if err := controllerutil.SetOwnerReference(vm, membership, r.Scheme); err != nil {
+				return err
+			}
+			if err := r.Client.Patch(ctx, membership, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		case !exists || ownedByOtherVirtualMachine(membership, vm.UID):
+			log.Info(fmt.Sprintf("Deleting FolderMembership [%s/%s] of a deleted VirtualMachine", membership.Namespace, membership.Name))
+			if err := r.Client.Delete(ctx, membership); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	if root.Name == "" {
+		return nil
+	}
+	if missing := folderindex.MissingMembers(root, namespaces, vmUIDs); len(missing) > 0 {
+		if err := r.removeMembers(ctx, missing...); err != nil {
+			return err
+		}
+	}
+
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	observed := folderindex.ObservedMembers(root, namespaces, vmUIDs)
+	if equality.Semantic.DeepEqual(observed, root.Status.ObservedMembers) {
+		return nil
+	}
+	orig := root.DeepCopy()
+	root.Status.ObservedMembers = observed
+	return r.Client.Status().Patch(ctx, root, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+}
+
+// clearDefaultFolders removes the DefaultFolderAnnotation of the namespaces
+// whose default folder was deleted, so it is not taken for a new folder that
+// is later created with the same name. A default folder is deleted when it is
+// neither in the FolderIndex nor a NamespacedFolder that exists.
+func (r *DeletedMemberReconciler) clearDefaultFolders(ctx context.Context, root *v1alpha1.FolderIndex, namespaces []corev1.Namespace) error {
+	view := root.DeepCopy()
+	folderindex.RemoveDeletedMembers(view)
+	folders := &v1alpha1.NamespacedFolderList{}
+	if err := r.Client.List(ctx, folders); err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for _, folder := range folders.Items {
+		existing[folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)] = true
+	}
+
+	for i := range namespaces {
+		namespace := &namespaces[i]
+		folder := namespace.Annotations[v1alpha1.DefaultFolderAnnotation]
+		if folder == "" {
+			continue
+		}
+		key := folderindex.NamespacedFolderKey(namespace.Name, folder)
+		if _, inIndex := view.Spec.NamespacedFolderEntries[key]; inIndex || existing[key] {
+			continue
+		}
+
+		logger.FromContext(ctx).Info(fmt.Sprintf("Clearing the deleted default folder [%s] of namespace [%s]", folder, namespace.Name))
+		orig := namespace.DeepCopy()
+		delete(namespace.Annotations, v1alpha1.DefaultFolderAnnotation)
+		if err := r.Client.Patch(ctx, namespace, client.MergeFrom(orig)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownedByVirtualMachine reports whether the membership is owned by a
+// VirtualMachine.
+func ownedByVirtualMachine(membership *v1alpha1.FolderMembership) bool {
+	for _, ref := range membership.OwnerReferences {
+		if ref.Kind == "VirtualMachine" {
+			return true
+		}
+	}
+	return false
+}
+
+// ownedByOtherVirtualMachine reports whether the membership is owned by a
+// VirtualMachine other than the one with uid, that is by a deleted
+// VirtualMachine of the same name.
+func ownedByOtherVirtualMachine(membership *v1alpha1.FolderMembership, uid types.UID) bool {
+	for _, ref := range membership.OwnerReferences {
+		if ref.Kind == "VirtualMachine" && ref.UID != uid {
+			return true
+		}
+	}
+	return false
+}
+
+// runSweeps sweeps for deleted members at startup and every SweepInterval
+// until ctx is done.
+func (r *DeletedMemberReconciler) runSweeps(ctx context.Context) error {
+	interval := r.SweepInterval
+	if interval == 0 {
+		interval = DefaultSweepInterval
+	}
+	log := logger.FromContext(ctx).WithName("deletedmembersweep")
+	wait.JitterUntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.sweep(logger.IntoContext(ctx, log)); err != nil {
+			log.Error(err, "Failed to sweep for deleted members")
+		}
+	}, interval, 0.1, true)
+	return nil
+}
+
+// SetupWithManager sets up the controllers for deleted Namespaces and
+// VirtualMachines, and the sweep, with the Manager.
+func (r *DeletedMemberReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.Add(manager.RunnableFunc(r.runSweeps)); err != nil {
+		return err
+	}
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}, builder.WithPredicates(deletedOnly)).
+		Named("deletednamespace").
+		Complete(reconcile.Func(r.reconcileNamespace)); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&virtv1.VirtualMachine{}, builder.WithPredicates(deletedOnly)).
+		Named("deletedvirtualmachine").
+		Complete(reconcile.Func(r.reconcileVirtualMachine))
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("DeletedMember Controller", func() {
+	Context("When namespaces and VMs of the index are deleted", func() {
+		ctx := context.Background()
+
+		root := &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{}
+		controllerReconciler := &DeletedMemberReconciler{}
+
+		BeforeEach(func() {
+			root = &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{
+				ObjectMeta: metav1.ObjectMeta{Name: "root"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderIndexSpec{
+					ClusterFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{
+						"production": {Namespaces: []string{"deleted-namespace", "default"}},
+					},
+					NamespacedFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+						"deleted-namespace/web": {VirtualMachines: []string{"web-app-a"}},
+						"default/db":            {VirtualMachines: []string{"db-a", "db-b"}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, root)).To(Succeed())
+
+			controllerReconciler = &DeletedMemberReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, root)).To(Succeed())
+		})
+
+		It("should prune deleted members from the index", func() {
+			membership := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db-a"},
+				Spec:       kubevirtfolderviewkubevirtiov1alpha1.FolderMembershipSpec{Folder: "db"},
+			}
+			Expect(k8sClient.Create(ctx, membership)).To(Succeed())
+
+			_, err := controllerReconciler.reconcileNamespace(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "deleted-namespace"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = controllerReconciler.reconcileVirtualMachine(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: "default", Name: "db-a"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "root"}, root)).To(Succeed())
+			Expect(root.Spec.ClusterFolderEntries["production"].Namespaces).To(Equal([]string{"default"}))
+			Expect(root.Spec.NamespacedFolderEntries).NotTo(HaveKey("deleted-namespace/web"))
+			Expect(root.Spec.NamespacedFolderEntries["default/db"].VirtualMachines).To(Equal([]string{"db-b"}))
+			Expect(root.Status.DeletedMembers).To(BeEmpty())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "db-a"}, membership)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should flag deleted members when the index retains them", func() {
+			root.Spec.DeletedMemberPolicy = kubevirtfolderviewkubevirtiov1alpha1.DeletedMemberPolicyRetain
+			Expect(k8sClient.Update(ctx, root)).To(Succeed())
+
+			for range 2 {
+				_, err := controllerReconciler.reconcileVirtualMachine(ctx, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: "default", Name: "db-a"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			_, err := controllerReconciler.reconcileNamespace(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "unfiled-namespace"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "root"}, root)).To(Succeed())
+			Expect(root.Spec.NamespacedFolderEntries["default/db"].VirtualMachines).To(Equal([]string{"db-a", "db-b"}))
+			Expect(root.Status.DeletedMembers).To(HaveLen(1))
+			Expect(root.Status.DeletedMembers[0].Kind).To(Equal("VirtualMachine"))
+			Expect(root.Status.DeletedMembers[0].Namespace).To(Equal("default"))
+			Expect(root.Status.DeletedMembers[0].Name).To(Equal("db-a"))
+
+			By("clearing the flag once the member is taken out of the index")
+			root.Spec.NamespacedFolderEntries["default/db"] = kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				VirtualMachines: []string{"db-b"},
+			}
+			Expect(k8sClient.Update(ctx, root)).To(Succeed())
+
+			indexReconciler := &FolderIndexReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err = indexReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "root"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "root"}, root)).To(Succeed())
+			Expect(root.Status.DeletedMembers).To(BeEmpty())
+		})
+
+		It("should sweep members deleted while no one was watching", func() {
+			vm := &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db-b"},
+			}
+			Expect(k8sClient.Create(ctx, vm)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, vm)
+
+			By("recording the members seen before they were deleted")
+			orig := root.DeepCopy()
+			root.Spec.NamespacedFolderEntries["default/db"] = kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				VirtualMachines: []string{"db-a", "db-b", "db-c"},
+			}
+			Expect(k8sClient.Patch(ctx, root, client.MergeFrom(orig))).To(Succeed())
+			orig = root.DeepCopy()
+			root.Status.ObservedMembers = []kubevirtfolderviewkubevirtiov1alpha1.ObservedMember{
+				{Kind: "Namespace", Name: "deleted-namespace", UID: "uid-of-the-deleted-namespace"},
+				{Kind: "VirtualMachine", Namespace: "default", Name: "db-a", UID: "uid-of-the-deleted-vm"},
+			}
+			Expect(k8sClient.Status().Patch(ctx, root, client.MergeFrom(orig))).To(Succeed())
+
+			By("filing a deleted VM, a recreated VM and a VM not created yet with memberships")
+			deleted := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "db-a",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "kubevirt.io/v1",
+						Kind:       "VirtualMachine",
+						Name:       "db-a",
+						UID:        "uid-of-the-deleted-vm",
+					}},
+				},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderMembershipSpec{Folder: "db"},
+			}
+			Expect(k8sClient.Create(ctx, deleted)).To(Succeed())
+			recreated := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      "db-b",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "kubevirt.io/v1",
+						Kind:       "VirtualMachine",
+						Name:       "db-b",
+						UID:        "uid-of-the-deleted-vm",
+					}},
+				},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderMembershipSpec{Folder: "db"},
+			}
+			Expect(k8sClient.Create(ctx, recreated)).To(Succeed())
+			pending := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db-c"},
+				Spec:       kubevirtfolderviewkubevirtiov1alpha1.FolderMembershipSpec{Folder: "db"},
+			}
+			Expect(k8sClient.Create(ctx, pending)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, pending)
+
+			Expect(controllerReconciler.sweep(ctx)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "root"}, root)).To(Succeed())
+			Expect(root.Spec.ClusterFolderEntries["production"].Namespaces).To(Equal([]string{"default"}))
+			Expect(root.Spec.NamespacedFolderEntries).NotTo(HaveKey("deleted-namespace/web"))
+			Expect(root.Spec.NamespacedFolderEntries["default/db"].VirtualMachines).To(Equal([]string{"db-b", "db-c"}))
+			Expect(root.Status.ObservedMembers).To(ConsistOf(
+				HaveField("Name", "default"),
+				HaveField("Name", "db-b"),
+			))
+
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "db-a"}, deleted)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			err = k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "db-b"}, recreated)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "db-c"}, pending)).To(Succeed())
+		})
+
+		It("should keep members filed before they are created", func() {
+			Expect(controllerReconciler.sweep(ctx)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "root"}, root)).To(Succeed())
+			Expect(root.Spec.ClusterFolderEntries["production"].Namespaces).To(Equal([]string{"deleted-namespace", "default"}))
+			Expect(root.Spec.NamespacedFolderEntries["default/db"].VirtualMachines).To(Equal([]string{"db-a", "db-b"}))
+			Expect(root.Status.ObservedMembers).To(ConsistOf(HaveField("Name", "default")))
+		})
+
+		It("should adopt the memberships of VMs that exist", func() {
+			vm := &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db-b"},
+			}
+			Expect(k8sClient.Create(ctx, vm)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, vm)
+			membership := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db-b"},
+				Spec:       kubevirtfolderviewkubevirtiov1alpha1.FolderMembershipSpec{Folder: "db"},
+			}
+			Expect(k8sClient.Create(ctx, membership)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, membership)
+
+			Expect(controllerReconciler.sweep(ctx)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "db-b"}, membership)).To(Succeed())
+			Expect(membership.OwnerReferences).To(ConsistOf(HaveField("UID", vm.UID)))
+		})
+
+		It("should clear default folders that were deleted", func() {
+			namespace := &corev1.Namespace{}
+			setDefaultFolder := func(folder string) {
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, namespace)).To(Succeed())
+				orig := namespace.DeepCopy()
+				if namespace.Annotations == nil {
+					namespace.Annotations = map[string]string{}
+				}
+				namespace.Annotations[kubevirtfolderviewkubevirtiov1alpha1.DefaultFolderAnnotation] = folder
+				Expect(k8sClient.Patch(ctx, namespace, client.MergeFrom(orig))).To(Succeed())
+			}
+			DeferCleanup(func() {
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, namespace)).To(Succeed())
+				orig := namespace.DeepCopy()
+				delete(namespace.Annotations, kubevirtfolderviewkubevirtiov1alpha1.DefaultFolderAnnotation)
+				Expect(k8sClient.Patch(ctx, namespace, client.MergeFrom(orig))).To(Succeed())
+			})
+
+			By("keeping a default folder of the index")
+			setDefaultFolder("db")
+			Expect(controllerReconciler.sweep(ctx)).To(Succeed())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, namespace)).To(Succeed())
+			Expect(namespace.Annotations).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.DefaultFolderAnnotation, "db"))
+
+			By("clearing a default folder that no longer exists")
+			setDefaultFolder("removed")
+			Expect(controllerReconciler.sweep(ctx)).To(Succeed())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, namespace)).To(Succeed())
+			Expect(namespace.Annotations).NotTo(HaveKey(kubevirtfolderviewkubevirtiov1alpha1.DefaultFolderAnnotation))
+		})
+	})
+})
@@ -18,17 +18,26 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	virtv1 "kubevirt.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logger "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -82,80 +91,85 @@ func generateRoleBindingNameHash(folderUID types.UID, namespace string, subject
 	return hex.EncodeToString(bs), nil
 }
 
-func (r *ClusterFolderReconciler) reconcileFolderPermissions(ctx context.Context, folder *v1alpha1.ClusterFolder, namespace string) ([]string, error) {
-	appliedRBs := []string{}
-
-	log := logger.FromContext(ctx)
+// ClusterFolderRoleBindings returns the RoleBindings the ClusterFolder
+// controller maintains in a namespace for the folder's permissions. In a
+// namespace the folder does not hold itself, the namespace inherits the
+// permissions, and those excluded from children are left out.
+func ClusterFolderRoleBindings(folder *v1alpha1.ClusterFolder, namespace string, inherited bool) ([]rbacv1.RoleBinding, error) {
+	roleBindings := []rbacv1.RoleBinding{}
 	ownerRef := getClusterFolderOwnerReference(folder)
 
-	namespaceObj := &corev1.Namespace{}
-	if err := r.Client.Get(ctx, client.ObjectKey{Name: namespace}, namespaceObj); err != nil {
-		if apierrors.IsNotFound(err) {
-			// ignore reconciling role bindings for namespaces that have either
-			// been deleted, or have not been created yet
-			log.Info(fmt.Sprintf("Ignoring non-existent namespace [%s]", namespace))
-			return appliedRBs, nil
-		}
-		return appliedRBs, err
-	}
-
-	for _, fp := range folder.Spec.FolderPermissions {
+	for _, fp := range folderindex.Permissions(folder.Spec.FolderPermissions, inherited) {
 		for _, rr := range fp.RoleRefs {
 			name, err := generateRoleBindingNameHash(folder.UID, namespace, fp.Subject, rr)
 			if err != nil {
-				return appliedRBs, err
+				return roleBindings, err
 			}
 
-			expectedRB := &rbacv1.RoleBinding{}
-			expectedRB.Name = name
-			expectedRB.Namespace = namespace
-			_, err = controllerutil.CreateOrUpdate(ctx, r.Client, expectedRB, func() error {
-				expectedRB.OwnerReferences = []metav1.OwnerReference{
-					*ownerRef,
-				}
-				if expectedRB.Labels == nil {
-					expectedRB.Labels = map[string]string{}
-				}
-				expectedRB.Labels[ClusterFolderOwnershipUIDLabel] = string(folder.UID)
-
-				expectedRB.Subjects = []rbacv1.Subject{fp.Subject}
-				expectedRB.RoleRef = rr
-				return nil
+			roleBindings = append(roleBindings, rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels: map[string]string{
+						ClusterFolderOwnershipUIDLabel: string(folder.UID),
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						*ownerRef,
+					},
+				},
+				Subjects: []rbacv1.Subject{fp.Subject},
+				RoleRef:  rr,
 			})
-			if err != nil {
-				return appliedRBs, err
-			}
-
-			appliedRBs = append(appliedRBs, name)
 		}
 	}
 
-	return appliedRBs, nil
+	return roleBindings, nil
 }
 
-func (r *ClusterFolderReconciler) getAllNamespaces(root *v1alpha1.FolderIndex, folderName string) ([]string, error) {
+func (r *ClusterFolderReconciler) reconcileFolderPermissions(ctx context.Context, folder *v1alpha1.ClusterFolder, namespace string, inherited bool) ([]string, error) {
+	appliedRBs := []string{}
 
-	folderNamespaces := []string{}
+	log := logger.FromContext(ctx)
 
-	entry, exists := root.Spec.ClusterFolderEntries[folderName]
+	namespaceObj := &corev1.Namespace{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: namespace}, namespaceObj); err != nil {
+		if apierrors.IsNotFound(err) {
+			// ignore reconciling role bindings for namespaces that have either
+			// been deleted, or have not been created yet
+			log.Info(fmt.Sprintf("Ignoring non-existent namespace [%s]", namespace))
+			return appliedRBs, nil
+		}
+		return appliedRBs, err
+	}
 
-	// Folder does not exist
-	// TODO garbage collect non existent folders
-	if !exists {
-		return folderNamespaces, nil
+	roleBindings, err := ClusterFolderRoleBindings(folder, namespace, inherited)
+	if err != nil {
+		return appliedRBs, err
 	}
 
-	folderNamespaces = append(folderNamespaces, entry.Namespaces...)
+	for _, rb := range roleBindings {
+		expectedRB := &rbacv1.RoleBinding{}
+		expectedRB.Name = rb.Name
+		expectedRB.Namespace = rb.Namespace
+		_, err = controllerutil.CreateOrUpdate(ctx, r.Client, expectedRB, func() error {
+			expectedRB.OwnerReferences = rb.OwnerReferences
+			if expectedRB.Labels == nil {
+				expectedRB.Labels = map[string]string{}
+			}
+			expectedRB.Labels[ClusterFolderOwnershipUIDLabel] = string(folder.UID)
 
-	for _, childFolderName := range entry.ChildFolders {
-		var err error
-		folderNamespaces, err = r.getAllNamespaces(root, childFolderName)
+			expectedRB.Subjects = rb.Subjects
+			expectedRB.RoleRef = rb.RoleRef
+			return nil
+		})
 		if err != nil {
-			return folderNamespaces, err
+			return appliedRBs, err
 		}
+
+		appliedRBs = append(appliedRBs, rb.Name)
 	}
 
-	return folderNamespaces, nil
+	return appliedRBs, nil
 }
 
 // +kubebuilder:rbac:groups=kubevirtfolderview.kubevirt.io.github.com,resources=folders,verbs=get;list;watch;create;update;patch;delete
@@ -164,8 +178,6 @@ func (r *ClusterFolderReconciler) getAllNamespaces(root *v1alpha1.FolderIndex, f
 func (r *ClusterFolderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := logger.FromContext(ctx)
 
-	root := &v1alpha1.FolderIndex{}
-
 	folder := &v1alpha1.ClusterFolder{}
 
 	log.Info(fmt.Sprintf("Reconciling cluster folder [%s]", req.NamespacedName.Name))
@@ -176,32 +188,87 @@ func (r *ClusterFolderReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	reconcileErr := r.reconcileRoleBindings(ctx, folder)
+	quota, quotaErr := r.quotaStatus(ctx, folder)
+	if quotaErr != nil {
+		quota = folder.Status.Quota
+		reconcileErr = errors.Join(reconcileErr, quotaErr)
+	}
+	return ctrl.Result{}, errors.Join(reconcileErr, r.updateStatus(ctx, folder, quota, reconcileErr))
+}
+
+// quotaStatus returns the limits and current usage of the folder's quota, or
+// nil when the folder has no quota.
+func (r *ClusterFolderReconciler) quotaStatus(ctx context.Context, folder *v1alpha1.ClusterFolder) (*v1alpha1.FolderQuotaStatus, error) {
+	if folder.Spec.Quota == nil {
+		return nil, nil
+	}
+
+	// without an index the folder holds no namespaces and uses nothing
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	folderindex.RemoveDeletedMembers(root)
+
+	used, err := ClusterFolderUsage(ctx, r.Client, root, folder.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &v1alpha1.FolderQuotaStatus{
+		Hard: *folder.Spec.Quota.DeepCopy(),
+		Used: used,
+	}, nil
+}
+
+// reconcileRoleBindings creates the RoleBindings of the folder in the
+// namespaces its permissions reach and deletes the ones it no longer needs.
+// Its permissions reach the namespaces of the folder, and are inherited top
+// down by the namespaces of its descendants, up to the descendants that block
+// inheritance.
+func (r *ClusterFolderReconciler) reconcileRoleBindings(ctx context.Context, folder *v1alpha1.ClusterFolder) error {
+	root := &v1alpha1.FolderIndex{}
+
 	// TODO enforce that only a single folder index named root can exist
-	if err := r.Client.Get(ctx, client.ObjectKey{Name: "root"}, root); err != nil {
-		return ctrl.Result{}, err
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		return err
 	}
+	folderindex.RemoveDeletedMembers(root)
 
-	// Get all namespaces and child folder namespaces for this folder
-	folderNamespaces, err := r.getAllNamespaces(root, folder.Name)
+	blocked, err := BlockedClusterFolders(ctx, r.Client)
 	if err != nil {
-		return ctrl.Result{}, err
+		return err
 	}
 
+	// Get the namespaces of this folder and the child folder namespaces
+	// that inherit its permissions
+	directNamespaces, inheritedNamespaces := folderindex.ClusterFolderNamespaces(root, folder.Name, blocked)
+
 	rbList := rbacv1.RoleBindingList{}
 
 	rbLabels := map[string]string{
 		ClusterFolderOwnershipUIDLabel: string(folder.UID),
 	}
 	if err := r.Client.List(ctx, &rbList, client.MatchingLabels(rbLabels)); err != nil {
-		return ctrl.Result{}, err
+		return err
 	}
 
 	// Create RoleBindings for this folder in every namespace
 	expectedRBs := map[string]bool{}
-	for _, ns := range folderNamespaces {
-		appliedRBs, err := r.reconcileFolderPermissions(ctx, folder, ns)
+	for _, ns := range directNamespaces {
+		appliedRBs, err := r.reconcileFolderPermissions(ctx, folder, ns, false)
 		if err != nil {
-			return ctrl.Result{}, err
+			return err
+		}
+
+		for _, rbName := range appliedRBs {
+			expectedRBs[rbName] = true
+		}
+	}
+	for _, ns := range inheritedNamespaces {
+		appliedRBs, err := r.reconcileFolderPermissions(ctx, folder, ns, true)
+		if err != nil {
+			return err
 		}
 
 		for _, rbName := range appliedRBs {
@@ -215,11 +282,56 @@ func (r *ClusterFolderReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		if !ok {
 			err := r.Client.Delete(ctx, &rb)
 			if err != nil {
-				return ctrl.Result{}, err
+				return err
 			}
 		}
 	}
-	return ctrl.Result{}, nil
+	return nil
+}
+
+// updateStatus reports the outcome of reconciling the folder in its status.
+func (r *ClusterFolderReconciler) updateStatus(ctx context.Context, folder *v1alpha1.ClusterFolder, quota *v1alpha1.FolderQuotaStatus, reconcileErr error) error {
+	orig := folder.DeepCopy()
+	folder.Status.ObservedGeneration = folder.Generation
+	folder.Status.Quota = quota
+	meta.SetStatusCondition(&folder.Status.Conditions, readyCondition(folder.Generation, reconcileErr))
+	if equality.Semantic.DeepEqual(orig.Status, folder.Status) {
+		return nil
+	}
+	return r.Client.Status().Patch(ctx, folder, client.MergeFrom(orig))
+}
+
+// virtualMachineToQuotaFolders maps a VirtualMachine to the ClusterFolders
+// with a quota it counts against.
+func (r *ClusterFolderReconciler) virtualMachineToQuotaFolders(ctx context.Context, obj client.Object) []reconcile.Request {
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		return nil
+	}
+	parent, exists := folderindex.NamespaceParent(root, obj.GetNamespace())
+	if !exists {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, name := range folderindex.ClusterFolderAncestry(root, parent) {
+		folder := &v1alpha1.ClusterFolder{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, folder); err != nil || folder.Spec.Quota == nil {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
+}
+
+// folderIndexToClusterFolders maps the FolderIndex to the ClusterFolders it
+// has entries for.
+func (r *ClusterFolderReconciler) folderIndexToClusterFolders(_ context.Context, root *v1alpha1.FolderIndex) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for name := range root.Spec.ClusterFolderEntries {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -228,6 +340,20 @@ func (r *ClusterFolderReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.ClusterFolder{}).
 		Named("folder").
+		Watches(
+			&virtv1.VirtualMachine{},
+			handler.EnqueueRequestsFromMapFunc(r.virtualMachineToQuotaFolders),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&v1alpha1.FolderIndex{},
+			folderIndexHandler(r.folderIndexToClusterFolders),
+		).
+		Watches(
+			&v1alpha1.ClusterFolder{},
+			handler.EnqueueRequestsFromMapFunc(r.clusterFolderToAncestors),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		//		Watches(
 		//			&rbacv1.RoleBinding{},
 		//			handler.EnqueueRequestsFromMapFunc(),
@@ -23,8 +23,11 @@ import (
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	rbacv1 "k8s.io/api/rbac/v1"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
@@ -97,5 +100,65 @@ var _ = Describe("ClusterFolder Controller", func() {
 			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
+
+		It("should stop inherited RoleBindings at folders that block inheritance", func() {
+			controllerReconciler := &ClusterFolderReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			roleRefs := []rbacv1.RoleRef{{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "view"}}
+
+			By("granting one user access to the folder tree and another to the folder only")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, folder)).To(Succeed())
+			folder.Spec.FolderPermissions = []kubevirtfolderviewkubevirtiov1alpha1.FolderPermission{{
+				Subject:  rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "inherited-user"},
+				RoleRefs: roleRefs,
+			}, {
+				Subject:             rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "folder-user"},
+				RoleRefs:            roleRefs,
+				ExcludeFromChildren: true,
+			}}
+			Expect(k8sClient.Update(ctx, folder)).To(Succeed())
+
+			isolated := &kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{
+				ObjectMeta: metav1.ObjectMeta{Name: "isolated"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderSpec{
+					Inheritance: &kubevirtfolderviewkubevirtiov1alpha1.FolderInheritance{Block: true},
+				},
+			}
+			Expect(k8sClient.Create(ctx, isolated)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, isolated)
+
+			Expect(k8sClient.Get(ctx, rootNamespacedName, root)).To(Succeed())
+			root.Spec.ClusterFolderEntries = map[string]kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{
+				resourceName: {
+					ChildFolders: []string{"child", "isolated"},
+					Namespaces:   []string{"default"},
+				},
+				"child":    {Namespaces: []string{"kube-public"}},
+				"isolated": {Namespaces: []string{"kube-system"}},
+			}
+			Expect(k8sClient.Update(ctx, root)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				g.Expect(err).NotTo(HaveOccurred())
+
+				roleBindings := &rbacv1.RoleBindingList{}
+				g.Expect(k8sClient.List(ctx, roleBindings, client.MatchingLabels{
+					ClusterFolderOwnershipUIDLabel: string(folder.UID),
+				})).To(Succeed())
+
+				reached := map[string][]string{}
+				for _, roleBinding := range roleBindings.Items {
+					reached[roleBinding.Namespace] = append(reached[roleBinding.Namespace], roleBinding.Subjects[0].Name)
+				}
+				g.Expect(reached).To(HaveLen(2))
+				g.Expect(reached["default"]).To(ConsistOf("inherited-user", "folder-user"))
+				g.Expect(reached["kube-public"]).To(ConsistOf("inherited-user"))
+			}).Should(Succeed())
+		})
 	})
 })
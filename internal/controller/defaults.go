@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// NamespacedFolders returns the NamespacedFolder key followed by each of its
+// ancestors. Folders of the index that do not exist are skipped.
+func NamespacedFolders(ctx context.Context, c client.Reader, root *v1alpha1.FolderIndex, key string) ([]*v1alpha1.NamespacedFolder, error) {
+	folders := []*v1alpha1.NamespacedFolder{}
+	for _, ancestor := range folderindex.NamespacedFolderAncestry(root, key) {
+		namespace, name, err := folderindex.SplitNamespacedFolderKey(ancestor)
+		if err != nil {
+			return folders, err
+		}
+		folder := &v1alpha1.NamespacedFolder{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, folder); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return folders, err
+		}
+		folders = append(folders, folder)
+	}
+	return folders, nil
+}
+
+// sizesTemplate reports whether the template of vm sets the vCPUs or memory
+// an instancetype would set, which KubeVirt rejects as a conflict.
+func sizesTemplate(vm *virtv1.VirtualMachine) bool {
+	if vm.Spec.Template == nil {
+		return false
+	}
+	domain := vm.Spec.Template.Spec.Domain
+	return domain.CPU != nil || domain.Memory != nil ||
+		len(domain.Resources.Requests) != 0 || len(domain.Resources.Limits) != 0
+}
+
+// ApplyDefaults sets the defaults of folders, nearest first, that vm does not
+// set itself. The instancetype and run strategy are only set when placing is
+// true, when vm is created or filed into the folder, so VirtualMachines are
+// not resized or started by later changes to the defaults.
+func ApplyDefaults(vm *virtv1.VirtualMachine, folders []*v1alpha1.NamespacedFolder, placing bool) {
+	for _, folder := range folders {
+		defaults := folder.Spec.Defaults
+		if defaults == nil {
+			continue
+		}
+
+		for key, value := range defaults.Labels {
+			if _, exists := vm.Labels[key]; exists {
+				continue
+			}
+			if vm.Labels == nil {
+				vm.Labels = map[string]string{}
+			}
+			vm.Labels[key] = value
+		}
+
+		if !placing {
+			continue
+		}
+		if defaults.Instancetype != nil && vm.Spec.Instancetype == nil && !sizesTemplate(vm) {
+			vm.Spec.Instancetype = &virtv1.InstancetypeMatcher{
+				Name: defaults.Instancetype.Name,
+				Kind: defaults.Instancetype.Kind,
+			}
+		}
+		if defaults.RunStrategy != "" && vm.Spec.RunStrategy == nil && vm.Spec.Running == nil {
+			runStrategy := virtv1.VirtualMachineRunStrategy(defaults.RunStrategy)
+			vm.Spec.RunStrategy = &runStrategy
+		}
+	}
+}
+
+// SetPlacedFolder records the NamespacedFolder key vm is filed in, when filed
+// is true, in its PlacedFolderAnnotation. It reports whether vm is placed in
+// a new folder: the annotation named another folder, or no folder. A vm
+// without the annotation is not placed, its folder is only recorded when it
+// is filed.
+func SetPlacedFolder(vm *virtv1.VirtualMachine, key string, filed bool) bool {
+	folder := ""
+	if filed {
+		folder = strings.TrimPrefix(key, vm.Namespace+"/")
+	}
+	placed, tracked := vm.Annotations[v1alpha1.PlacedFolderAnnotation]
+	if (tracked && placed == folder) || (!tracked && !filed) {
+		return false
+	}
+	if vm.Annotations == nil {
+		vm.Annotations = map[string]string{}
+	}
+	vm.Annotations[v1alpha1.PlacedFolderAnnotation] = folder
+	return tracked && filed
+}
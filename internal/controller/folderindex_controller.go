@@ -19,12 +19,16 @@ package controller
 import (
 	"context"
 
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
 )
 
 // FolderIndexReconciler reconciles a FolderIndex object
@@ -38,7 +42,31 @@ type FolderIndexReconciler struct {
 // +kubebuilder:rbac:groups=kubevirtfolderview.kubevirt.io.github.com,resources=folderindices/finalizers,verbs=update
 
 func (r *FolderIndexReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	logger := log.FromContext(ctx)
+
+	root := &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, req.NamespacedName, root); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// a deleted member stays flagged until it is taken out of the index
+	deletedMembers := []kubevirtfolderviewkubevirtiov1alpha1.DeletedMember{}
+	for _, member := range root.Status.DeletedMembers {
+		if folderindex.InIndex(root, member) {
+			deletedMembers = append(deletedMembers, member)
+		}
+	}
+	if len(deletedMembers) != len(root.Status.DeletedMembers) {
+		orig := root.DeepCopy()
+		root.Status.DeletedMembers = deletedMembers
+		logger.Info(fmt.Sprintf("Clearing %d deleted members no longer in the folder index", len(orig.Status.DeletedMembers)-len(deletedMembers)))
+		if err := r.Client.Status().Patch(ctx, root, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
 	// TODO - The index reconciler should do the following
 	// 1. Verify consistency of the root index (same as validating webhook)
@@ -0,0 +1,173 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// FolderMembershipFolderField indexes FolderMemberships by the name of the
+// NamespacedFolder they file their VirtualMachine into.
+const FolderMembershipFolderField = "spec.folder"
+
+// IndexFolderMemberships registers the FolderMembershipFolderField index with
+// the cache of the manager.
+func IndexFolderMemberships(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &v1alpha1.FolderMembership{}, FolderMembershipFolderField, func(obj client.Object) []string {
+		membership, ok := obj.(*v1alpha1.FolderMembership)
+		if !ok {
+			return nil
+		}
+		return []string{membership.Spec.Folder}
+	})
+}
+
+// FolderVirtualMachines returns the VirtualMachines of the NamespacedFolder key and of all
+// of its descendants, from both the FolderIndex and the FolderMemberships.
+// VirtualMachines of the index that have a membership are left to the folder
+// the membership names. As with the index, only folders in the index are part
+// of the tree.
+func FolderVirtualMachines(ctx context.Context, c client.Reader, root *v1alpha1.FolderIndex, key string) ([]string, error) {
+	return folderVirtualMachines(ctx, c, root, folderindex.NamespacedFolderTree(root, key))
+}
+
+// ReachedVirtualMachines returns the VirtualMachines the permissions of the
+// NamespacedFolder key reach: those of the folder and of the descendants that
+// inherit its permissions, followed by those of the folder alone, which are
+// all that permissions excluded from children reach. Folders in blocked, and
+// their descendants, do not inherit the permissions of the folder.
+func ReachedVirtualMachines(ctx context.Context, c client.Reader, root *v1alpha1.FolderIndex, key string, blocked map[string]bool) ([]string, []string, error) {
+	direct, err := folderVirtualMachines(ctx, c, root, []string{key})
+	if err != nil {
+		return nil, nil, err
+	}
+	vms, err := folderVirtualMachines(ctx, c, root, append([]string{key}, folderindex.InheritingNamespacedFolders(root, key, blocked)...))
+	if err != nil {
+		return nil, nil, err
+	}
+	return vms, direct, nil
+}
+
+// folderVirtualMachines returns the VirtualMachines of the NamespacedFolders
+// keys, which are all in the same namespace.
+func folderVirtualMachines(ctx context.Context, c client.Reader, root *v1alpha1.FolderIndex, keys []string) ([]string, error) {
+	vms := []string{}
+	if len(keys) == 0 {
+		return vms, nil
+	}
+	namespace, _, err := folderindex.SplitNamespacedFolderKey(keys[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, folderKey := range keys {
+		for _, vm := range root.Spec.NamespacedFolderEntries[folderKey].VirtualMachines {
+			membership := &v1alpha1.FolderMembership{}
+			err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: vm}, membership)
+			if err == nil {
+				continue
+			}
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			vms = append(vms, vm)
+		}
+	}
+
+	for _, folderKey := range keys {
+		if _, exists := root.Spec.NamespacedFolderEntries[folderKey]; !exists {
+			continue
+		}
+		_, name, err := folderindex.SplitNamespacedFolderKey(folderKey)
+		if err != nil {
+			return nil, err
+		}
+		memberships := &v1alpha1.FolderMembershipList{}
+		if err := c.List(ctx, memberships, client.InNamespace(namespace), client.MatchingFields{FolderMembershipFolderField: name}); err != nil {
+			return nil, err
+		}
+		names := []string{}
+		for _, membership := range memberships.Items {
+			names = append(names, membership.Name)
+		}
+		// the order of the VMs is part of the generated Role, keep it
+		// stable across reconciles
+		slices.Sort(names)
+		vms = append(vms, names...)
+	}
+
+	return vms, nil
+}
+
+// folderMembershipToFolders maps a FolderMembership to the NamespacedFolders
+// whose VirtualMachines it changes: the folder it names, the folder the index
+// files the VirtualMachine in, and the ancestors of both.
+func (r *NamespacedFolderReconciler) folderMembershipToFolders(ctx context.Context, obj client.Object) []reconcile.Request {
+	membership, ok := obj.(*v1alpha1.FolderMembership)
+	if !ok {
+		return nil
+	}
+
+	key := folderindex.NamespacedFolderKey(membership.Namespace, membership.Spec.Folder)
+	keys := []string{key}
+
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err == nil {
+		keys = folderindex.NamespacedFolderAncestry(root, key)
+		if parent, exists := folderindex.VirtualMachineParent(root, membership.Namespace, membership.Name); exists {
+			keys = append(keys, folderindex.NamespacedFolderAncestry(root, parent)...)
+		}
+	}
+
+	requests := []reconcile.Request{}
+	for _, key := range keys {
+		namespace, name, err := folderindex.SplitNamespacedFolderKey(key)
+		if err != nil {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}})
+	}
+	return requests
+}
+
+// VirtualMachineFolder returns the key of the NamespacedFolder the
+// VirtualMachine is filed in. A FolderMembership takes precedence over the
+// index, and a VirtualMachine whose membership names a folder that is not in
+// the index is in no folder.
+func VirtualMachineFolder(ctx context.Context, c client.Reader, root *v1alpha1.FolderIndex, namespace string, vm string) (string, bool, error) {
+	membership := &v1alpha1.FolderMembership{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: vm}, membership)
+	if err == nil {
+		key := folderindex.NamespacedFolderKey(namespace, membership.Spec.Folder)
+		_, exists := root.Spec.NamespacedFolderEntries[key]
+		return key, exists, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return "", false, err
+	}
+	key, exists := folderindex.VirtualMachineParent(root, namespace, vm)
+	return key, exists, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// blocksInheritance reports whether no permissions of the ancestors of a
+// folder with inheritance reach it.
+func blocksInheritance(inheritance *v1alpha1.FolderInheritance) bool {
+	return inheritance != nil && inheritance.Block
+}
+
+// BlockedClusterFolders returns the names of the ClusterFolders that block
+// the permissions of their ancestors.
+func BlockedClusterFolders(ctx context.Context, c client.Reader) (map[string]bool, error) {
+	folders := &v1alpha1.ClusterFolderList{}
+	if err := c.List(ctx, folders); err != nil {
+		return nil, err
+	}
+
+	blocked := map[string]bool{}
+	for _, folder := range folders.Items {
+		if blocksInheritance(folder.Spec.Inheritance) {
+			blocked[folder.Name] = true
+		}
+	}
+	return blocked, nil
+}
+
+// BlockedNamespacedFolders returns the keys of the NamespacedFolders of
+// namespace that block the permissions of their ancestors.
+func BlockedNamespacedFolders(ctx context.Context, c client.Reader, namespace string) (map[string]bool, error) {
+	folders := &v1alpha1.NamespacedFolderList{}
+	if err := c.List(ctx, folders, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	blocked := map[string]bool{}
+	for _, folder := range folders.Items {
+		if blocksInheritance(folder.Spec.Inheritance) {
+			blocked[folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)] = true
+		}
+	}
+	return blocked, nil
+}
+
+// clusterFolderToAncestors maps a ClusterFolder to its ancestors, whose
+// permissions reach its namespaces depending on its inheritance.
+func (r *ClusterFolderReconciler) clusterFolderToAncestors(ctx context.Context, obj client.Object) []reconcile.Request {
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, name := range folderindex.ClusterFolderAncestry(root, obj.GetName())[1:] {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
+}
+
+// namespacedFolderToAncestors maps a NamespacedFolder to its ancestors, whose
+// permissions reach its VirtualMachines depending on its inheritance.
+func (r *NamespacedFolderReconciler) namespacedFolderToAncestors(ctx context.Context, obj client.Object) []reconcile.Request {
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	key := folderindex.NamespacedFolderKey(obj.GetNamespace(), obj.GetName())
+	for _, ancestor := range folderindex.NamespacedFolderAncestry(root, key)[1:] {
+		namespace, name, err := folderindex.SplitNamespacedFolderKey(ancestor)
+		if err != nil {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}})
+	}
+	return requests
+}
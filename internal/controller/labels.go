@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// syncLabels makes the folder labels of obj equal to desired, leaving all
+// other labels alone. It reports whether any label changed.
+func syncLabels(obj metav1.Object, desired map[string]string) bool {
+	labels := obj.GetLabels()
+	changed := false
+	for key := range labels {
+		if _, exists := desired[key]; folderindex.IsFolderLabel(key) && !exists {
+			delete(labels, key)
+			changed = true
+		}
+	}
+	for key, value := range desired {
+		if current, exists := labels[key]; exists && current == value {
+			continue
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[key] = value
+		changed = true
+	}
+	obj.SetLabels(labels)
+	return changed
+}
+
+// folderIndexHandler enqueues the requests mapFn returns for the FolderIndex.
+// On updates mapFn is applied to both the old and the new index, so members
+// that were taken out of the index are reconciled as well.
+func folderIndexHandler(mapFn func(ctx context.Context, root *v1alpha1.FolderIndex) []reconcile.Request) handler.EventHandler {
+	enqueue := func(ctx context.Context, q workqueue.TypedRateLimitingInterface[reconcile.Request], objs ...client.Object) {
+		for _, obj := range objs {
+			root, ok := obj.(*v1alpha1.FolderIndex)
+			if !ok {
+				continue
+			}
+			for _, request := range mapFn(ctx, root) {
+				q.Add(request)
+			}
+		}
+	}
+
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, q, e.Object)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, q, e.ObjectOld, e.ObjectNew)
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, q, e.Object)
+		},
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// LimitViolations returns a description of every per VirtualMachine limit of
+// limits that vm breaks. oldVM and oldUsage are the VirtualMachine before an
+// update and its usage, or nil when vm is new to the folder. An update is
+// only held to the limits it changes.
+func LimitViolations(limits *v1alpha1.FolderLimits, vm *virtv1.VirtualMachine, usage v1alpha1.FolderQuotaUsage, oldVM *virtv1.VirtualMachine, oldUsage *v1alpha1.FolderQuotaUsage) []string {
+	violations := []string{}
+	if limits.CPUPerVirtualMachine != nil && usage.CPU.Cmp(*limits.CPUPerVirtualMachine) > 0 &&
+		(oldUsage == nil || usage.CPU.Cmp(oldUsage.CPU) > 0) {
+		violations = append(violations, fmt.Sprintf("cpu: %s, limited to %s per VM", usage.CPU.String(), limits.CPUPerVirtualMachine.String()))
+	}
+	if limits.MemoryPerVirtualMachine != nil && usage.Memory.Cmp(*limits.MemoryPerVirtualMachine) > 0 &&
+		(oldUsage == nil || usage.Memory.Cmp(oldUsage.Memory) > 0) {
+		violations = append(violations, fmt.Sprintf("memory: %s, limited to %s per VM", usage.Memory.String(), limits.MemoryPerVirtualMachine.String()))
+	}
+
+	instancetype, oldInstancetype := "", ""
+	if vm.Spec.Instancetype != nil {
+		instancetype = vm.Spec.Instancetype.Name
+	}
+	if oldVM != nil && oldVM.Spec.Instancetype != nil {
+		oldInstancetype = oldVM.Spec.Instancetype.Name
+	}
+	if len(limits.Instancetypes) != 0 && !slices.Contains(limits.Instancetypes, instancetype) &&
+		(oldVM == nil || instancetype != oldInstancetype) {
+		violations = append(violations, fmt.Sprintf("instancetype [%s] is not one of [%s]", instancetype, strings.Join(limits.Instancetypes, ", ")))
+	}
+
+	preference, oldPreference := "", ""
+	if vm.Spec.Preference != nil {
+		preference = vm.Spec.Preference.Name
+	}
+	if oldVM != nil && oldVM.Spec.Preference != nil {
+		oldPreference = oldVM.Spec.Preference.Name
+	}
+	if len(limits.Preferences) != 0 && !slices.Contains(limits.Preferences, preference) &&
+		(oldVM == nil || preference != oldPreference) {
+		violations = append(violations, fmt.Sprintf("preference [%s] is not one of [%s]", preference, strings.Join(limits.Preferences, ", ")))
+	}
+	return violations
+}
+
+// CheckFiledVirtualMachines checks that the VirtualMachines a change of the
+// folder tree files into a NamespacedFolder keep to the limits of the folder
+// and of its ancestors. before and after are the folder views, the FolderIndex
+// with the FolderMemberships filed into it, before and after the change.
+// VirtualMachines that do not exist are not counted, as when VirtualMachines
+// are created.
+func CheckFiledVirtualMachines(ctx context.Context, c client.Reader, before, after *v1alpha1.FolderIndex) error {
+	folders := &v1alpha1.NamespacedFolderList{}
+	if err := c.List(ctx, folders); err != nil {
+		return err
+	}
+
+	live := map[string]map[string]*virtv1.VirtualMachine{}
+	namespaceVMs := func(namespace string) (map[string]*virtv1.VirtualMachine, error) {
+		if vms, exists := live[namespace]; exists {
+			return vms, nil
+		}
+		list := &virtv1.VirtualMachineList{}
+		if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		vms := map[string]*virtv1.VirtualMachine{}
+		for i := range list.Items {
+			vms[list.Items[i].Name] = &list.Items[i]
+		}
+		live[namespace] = vms
+		return vms, nil
+	}
+
+	for i := range folders.Items {
+		folder := &folders.Items[i]
+		limits := folder.Spec.Limits
+		key := folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)
+		if _, exists := after.Spec.NamespacedFolderEntries[key]; limits == nil || !exists {
+			continue
+		}
+
+		filed := map[string]bool{}
+		for _, name := range folderindex.GetAllVMs(before, key) {
+			filed[name] = true
+		}
+		vms, err := namespaceVMs(folder.Namespace)
+		if err != nil {
+			return err
+		}
+		count := int64(0)
+		added := []*virtv1.VirtualMachine{}
+		for _, name := range folderindex.GetAllVMs(after, key) {
+			vm, exists := vms[name]
+			if !exists {
+				continue
+			}
+			count++
+			if !filed[name] {
+				added = append(added, vm)
+			}
+		}
+		if len(added) == 0 {
+			continue
+		}
+
+		violations := []string{}
+		if limits.VirtualMachines != nil && count > *limits.VirtualMachines {
+			violations = append(violations, fmt.Sprintf("virtualMachines: %d, limited to %d", count, *limits.VirtualMachines))
+		}
+		for _, vm := range added {
+			usage, err := VirtualMachineUsage(ctx, c, vm)
+			if err != nil {
+				return err
+			}
+			for _, violation := range LimitViolations(limits, vm, usage, nil, nil) {
+				violations = append(violations, fmt.Sprintf("vm [%s] %s", vm.Name, violation))
+			}
+		}
+		if len(violations) != 0 {
+			return fmt.Errorf("filing vms into namespaced folder [%s] exceeds its limits: %s", key, strings.Join(violations, ", "))
+		}
+	}
+	return nil
+}
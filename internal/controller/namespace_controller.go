@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logger "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// NamespaceReconciler labels the Namespaces of ClusterFolders with their
+// place in the folder tree and the propagated tags of their folders, and the
+// Namespaces of NamespacedFolders with the FoldersLabel the VirtualMachine
+// webhooks select.
+type NamespaceReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// namespaceLabels returns the folder labels of namespace.
+func (r *NamespaceReconciler) namespaceLabels(ctx context.Context, root *v1alpha1.FolderIndex, namespace string) (map[string]string, error) {
+	labels := folderindex.NamespaceLabels(root, namespace)
+	parent, exists := folderindex.NamespaceParent(root, namespace)
+	if !exists {
+		return labels, nil
+	}
+
+	ancestry := []v1alpha1.FolderMetadata{}
+	for _, name := range folderindex.ClusterFolderAncestry(root, parent) {
+		folder := &v1alpha1.ClusterFolder{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, folder); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		ancestry = append(ancestry, folder.Spec.FolderMetadata)
+	}
+	maps.Copy(labels, folderindex.TagLabels(ancestry))
+	return labels, nil
+}
+
+// syncFoldersSince sets the FoldersSinceAnnotation of a namespace that has
+// the FoldersLabel to now, unless it is set already, and removes it from a
+// namespace without the label. It reports whether the annotation changed.
+func syncFoldersSince(namespace *corev1.Namespace, now time.Time) bool {
+	_, labeled := namespace.Labels[v1alpha1.FoldersLabel]
+	_, since := namespace.Annotations[v1alpha1.FoldersSinceAnnotation]
+	switch {
+	case labeled && !since:
+		if namespace.Annotations == nil {
+			namespace.Annotations = map[string]string{}
+		}
+		namespace.Annotations[v1alpha1.FoldersSinceAnnotation] = now.UTC().Format(time.RFC3339)
+		return true
+	case !labeled && since:
+		delete(namespace.Annotations, v1alpha1.FoldersSinceAnnotation)
+		return true
+	}
+	return false
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;update;patch
+
+func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logger.FromContext(ctx)
+
+	namespace := &corev1.Namespace{}
+	if err := r.Client.Get(ctx, req.NamespacedName, namespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// without an index no namespace is in a folder and all folder labels
+	// are removed
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	folderindex.RemoveDeletedMembers(root)
+
+	labels, err := r.namespaceLabels(ctx, root, namespace.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	orig := namespace.DeepCopy()
+	labelsChanged := syncLabels(namespace, labels)
+	if !syncFoldersSince(namespace, time.Now()) && !labelsChanged {
+		return ctrl.Result{}, nil
+	}
+	log.Info(fmt.Sprintf("Updating folder labels of namespace [%s]", namespace.Name))
+	return ctrl.Result{}, r.Client.Patch(ctx, namespace, client.MergeFrom(orig))
+}
+
+// clusterFolderToNamespaces maps a ClusterFolder to the namespaces of the
+// folder and of its descendants.
+func (r *NamespaceReconciler) clusterFolderToNamespaces(ctx context.Context, obj client.Object) []reconcile.Request {
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, namespace := range folderindex.GetAllNamespaces(root, obj.GetName()) {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: namespace}})
+	}
+	return requests
+}
+
+// folderIndexToNamespaces maps the FolderIndex to the namespaces it places
+// in ClusterFolders and the namespaces of its NamespacedFolders.
+func (r *NamespaceReconciler) folderIndexToNamespaces(_ context.Context, root *v1alpha1.FolderIndex) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for _, entry := range root.Spec.ClusterFolderEntries {
+		for _, namespace := range entry.Namespaces {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: namespace}})
+		}
+	}
+	for key := range root.Spec.NamespacedFolderEntries {
+		if namespace, _, err := folderindex.SplitNamespacedFolderKey(key); err == nil {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: namespace}})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		Named("namespace").
+		Watches(
+			&v1alpha1.ClusterFolder{},
+			handler.EnqueueRequestsFromMapFunc(r.clusterFolderToNamespaces),
+		).
+		Watches(
+			&v1alpha1.FolderIndex{},
+			folderIndexHandler(r.folderIndexToNamespaces),
+		).
+		Complete(r)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("Namespace Controller", func() {
+	Context("When reconciling a namespace in a folder", func() {
+		const namespaceName = "tagged-namespace"
+
+		ctx := context.Background()
+
+		namespace := &corev1.Namespace{}
+		parent := &kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{}
+		child := &kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{}
+		root := &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{}
+
+		BeforeEach(func() {
+			namespace = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   namespaceName,
+					Labels: map[string]string{kubevirtfolderviewkubevirtiov1alpha1.TagLabelPrefix + "stale": "true"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+
+			parent = &kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{
+				ObjectMeta: metav1.ObjectMeta{Name: "tagged-parent"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderSpec{
+					FolderMetadata: kubevirtfolderviewkubevirtiov1alpha1.FolderMetadata{
+						Tags:           map[string]string{"cost-center": "cc-42", "env": "ops"},
+						PropagatedTags: []string{"cost-center", "env"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, parent)).To(Succeed())
+
+			child = &kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{
+				ObjectMeta: metav1.ObjectMeta{Name: "tagged-child"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderSpec{
+					FolderMetadata: kubevirtfolderviewkubevirtiov1alpha1.FolderMetadata{
+						DisplayName:    "Production",
+						Tags:           map[string]string{"env": "production"},
+						PropagatedTags: []string{"env"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, child)).To(Succeed())
+
+			root = &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{
+				ObjectMeta: metav1.ObjectMeta{Name: "root"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderIndexSpec{
+					ClusterFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{
+						"tagged-parent": {ChildFolders: []string{"tagged-child"}},
+						"tagged-child":  {Namespaces: []string{namespaceName}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, root)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, root)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, child)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, parent)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+		})
+
+		It("should label the namespace with its folders and their propagated tags", func() {
+			controllerReconciler := &NamespaceReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: namespaceName},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).To(HaveKeyWithValue("tag.folderview.kubevirt.io/env", "production"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("tag.folderview.kubevirt.io/cost-center", "cc-42"))
+			Expect(namespace.Labels).NotTo(HaveKey("tag.folderview.kubevirt.io/stale"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("folderview.kubevirt.io/cluster-folder", "tagged-child"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("folderview.kubevirt.io/path", "tagged-parent.tagged-child"))
+			Expect(namespace.Labels).To(HaveKeyWithValue("folderview.kubevirt.io/folders", "true"))
+			Expect(namespace.Annotations).To(HaveKey("folderview.kubevirt.io/folders-since"))
+
+			By("removing the labels once the namespace leaves the folder")
+			root.Spec.ClusterFolderEntries["tagged-child"] = kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{}
+			Expect(k8sClient.Update(ctx, root)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: namespaceName},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.Labels).NotTo(HaveKey("tag.folderview.kubevirt.io/env"))
+			Expect(namespace.Labels).NotTo(HaveKey("tag.folderview.kubevirt.io/cost-center"))
+			Expect(namespace.Labels).NotTo(HaveKey("folderview.kubevirt.io/cluster-folder"))
+			Expect(namespace.Labels).NotTo(HaveKey("folderview.kubevirt.io/path"))
+			Expect(namespace.Labels).NotTo(HaveKey("folderview.kubevirt.io/folders"))
+			Expect(namespace.Annotations).NotTo(HaveKey("folderview.kubevirt.io/folders-since"))
+		})
+	})
+})
@@ -21,25 +21,38 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logger "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
 	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
 )
 
 const NamespacedFolderOwnershipLabel = "namespaced-owner.folderview.kubevirt.io"
 
+// VirtualMachinesPerRole is the most VirtualMachines a generated Role names.
+// Permissions reaching more VirtualMachines are split across several Roles,
+// each with its own RoleBinding, so no Role outgrows the size of an object.
+const VirtualMachinesPerRole = 200
+
 // NamespacedFolderReconciler reconciles a NamespacedFolder object
 type NamespacedFolderReconciler struct {
 	client.Client
@@ -58,28 +71,6 @@ func getNamespacedFolderOwnerReference(folder *v1alpha1.NamespacedFolder) *metav
 	}
 }
 
-func (r *NamespacedFolderReconciler) getAllVMs(root *v1alpha1.FolderIndex, folderName string) ([]string, error) {
-	vms := []string{}
-
-	entry, exists := root.Spec.NamespacedFolderEntries[folderName]
-	// Folder does not exist
-	if !exists {
-		return vms, nil
-	}
-
-	vms = append(vms, entry.VirtualMachines...)
-
-	for _, childFolderName := range entry.ChildFolders {
-		var err error
-		vms, err = r.getAllVMs(root, childFolderName)
-		if err != nil {
-			return vms, err
-		}
-	}
-
-	return vms, nil
-}
-
 func generateRoleNameHash(folderUID types.UID, namespace string, rules []rbacv1.PolicyRule) (string, error) {
 
 	rulesJson, err := json.Marshal(rules)
@@ -100,44 +91,61 @@ func generateRoleNameHash(folderUID types.UID, namespace string, rules []rbacv1.
 	return hex.EncodeToString(bs), nil
 }
 
-func (r *NamespacedFolderReconciler) reconcileRole(ctx context.Context, folder *v1alpha1.NamespacedFolder, roleRef *rbacv1.RoleRef, vms []string) (string, error) {
-
-	var rules []rbacv1.PolicyRule
-
-	ownerRef := getNamespacedFolderOwnerReference(folder)
+// NamespacedFolderGrant is the Role and RoleBinding the NamespacedFolder
+// controller maintains for a single subject and RoleRef of a folder's
+// permissions.
+type NamespacedFolderGrant struct {
+	Subject     rbacv1.Subject
+	RoleRef     rbacv1.RoleRef
+	Role        rbacv1.Role
+	RoleBinding rbacv1.RoleBinding
+}
 
+// GetRoleRefRules returns the rules of the Role or ClusterRole referenced by
+// roleRef. Roles are looked up in namespace. Nil is returned for roles that
+// do not exist and for unknown kinds.
+func GetRoleRefRules(ctx context.Context, c client.Reader, namespace string, roleRef rbacv1.RoleRef) ([]rbacv1.PolicyRule, error) {
 	if roleRef.Kind == "Role" {
 		role := &rbacv1.Role{}
-		name := client.ObjectKey{Name: roleRef.Name, Namespace: folder.Namespace}
+		name := client.ObjectKey{Name: roleRef.Name, Namespace: namespace}
 
-		if err := r.Client.Get(ctx, name, role); err != nil {
+		if err := c.Get(ctx, name, role); err != nil {
 			if apierrors.IsNotFound(err) {
-				return "", nil
+				return nil, nil
 			}
-			return "", err
+			return nil, err
 		}
 
-		rules = role.Rules
+		return role.Rules, nil
 	} else if roleRef.Kind == "ClusterRole" {
 		clusterRole := &rbacv1.ClusterRole{}
 		name := client.ObjectKey{Name: roleRef.Name}
 
-		if err := r.Client.Get(ctx, name, clusterRole); err != nil {
+		if err := c.Get(ctx, name, clusterRole); err != nil {
 			if apierrors.IsNotFound(err) {
-				return "", nil
+				return nil, nil
 			}
-			return "", err
+			return nil, err
 		}
 
-		rules = clusterRole.Rules
-	} else {
-		// unknown kind, ignore
-		return "", nil
+		return clusterRole.Rules, nil
 	}
 
-	// filter rules and add resource names to them
+	// unknown kind, ignore
+	return nil, nil
+}
+
+// FilterKubeVirtRules reduces rules to the ones that apply to VirtualMachines
+// and VirtualMachineInstances, and restricts them to the given VirtualMachine
+// names. Without any VirtualMachines there is nothing to grant, so no rules
+// are returned rather than rules that would apply to the whole namespace.
+func FilterKubeVirtRules(rules []rbacv1.PolicyRule, vms []string) []rbacv1.PolicyRule {
 	newRules := []rbacv1.PolicyRule{}
 
+	if len(vms) == 0 {
+		return newRules
+	}
+
 	for _, rule := range rules {
 		foundGroups := []string{}
 
@@ -176,96 +184,144 @@ func (r *NamespacedFolderReconciler) reconcileRole(ctx context.Context, folder *
 		}
 
 		newRules = append(newRules, newRule)
-
 	}
 
-	if len(newRules) == 0 {
-		return "", nil
+	return newRules
+}
+
+// NamespacedFolderGrants returns the Roles and RoleBindings the
+// NamespacedFolder controller maintains for the folder's permissions, given
+// the VirtualMachines its permissions reach and the ones the folder holds
+// itself, which are all that permissions excluded from children reach.
+// RoleRefs that resolve to no KubeVirt rules produce no grant, and ones
+// reaching more than VirtualMachinesPerRole VirtualMachines produce a grant
+// per VirtualMachinesPerRole of them.
+func NamespacedFolderGrants(ctx context.Context, c client.Reader, folder *v1alpha1.NamespacedFolder, vms []string, directVMs []string) ([]NamespacedFolderGrant, error) {
+	grants := []NamespacedFolderGrant{}
+	namespace := folder.Namespace
+
+	ownerRef := getNamespacedFolderOwnerReference(folder)
+	ownerLabels := map[string]string{
+		NamespacedFolderOwnershipLabel: string(folder.UID),
 	}
 
-	roleName, err := generateRoleNameHash(folder.UID, folder.Namespace, newRules)
+	for _, fp := range folder.Spec.FolderPermissions {
+		reachedVMs := vms
+		if fp.ExcludeFromChildren {
+			reachedVMs = directVMs
+		}
+		for _, existingRR := range fp.RoleRefs {
+			rules, err := GetRoleRefRules(ctx, c, namespace, existingRR)
+			if err != nil {
+				return grants, err
+			}
+
+			for shard := range slices.Chunk(reachedVMs, VirtualMachinesPerRole) {
+				newRules := FilterKubeVirtRules(rules, shard)
+				if len(newRules) == 0 {
+					// role isn't related to virtual machines
+					break
+				}
 
-	if err != nil {
-		return "", err
+				roleName, err := generateRoleNameHash(folder.UID, namespace, newRules)
+				if err != nil {
+					return grants, err
+				}
+
+				rr := rbacv1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "Role",
+					Name:     roleName,
+				}
+
+				name, err := generateRoleBindingNameHash(folder.UID, namespace, fp.Subject, rr)
+				if err != nil {
+					return grants, err
+				}
+
+				grants = append(grants, NamespacedFolderGrant{
+					Subject: fp.Subject,
+					RoleRef: existingRR,
+					Role: rbacv1.Role{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:            roleName,
+							Namespace:       namespace,
+							Labels:          ownerLabels,
+							OwnerReferences: []metav1.OwnerReference{*ownerRef},
+						},
+						Rules: newRules,
+					},
+					RoleBinding: rbacv1.RoleBinding{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:            name,
+							Namespace:       namespace,
+							Labels:          ownerLabels,
+							OwnerReferences: []metav1.OwnerReference{*ownerRef},
+						},
+						Subjects: []rbacv1.Subject{fp.Subject},
+						RoleRef:  rr,
+					},
+				})
+			}
+		}
 	}
 
+	return grants, nil
+}
+
+func (r *NamespacedFolderReconciler) reconcileRole(ctx context.Context, folder *v1alpha1.NamespacedFolder, role *rbacv1.Role) error {
 	newRole := &rbacv1.Role{}
-	newRole.Name = roleName
-	newRole.Namespace = folder.Namespace
+	newRole.Name = role.Name
+	newRole.Namespace = role.Namespace
 
-	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, newRole, func() error {
-		newRole.OwnerReferences = []metav1.OwnerReference{
-			*ownerRef,
-		}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, newRole, func() error {
+		newRole.OwnerReferences = role.OwnerReferences
 		if newRole.Labels == nil {
 			newRole.Labels = map[string]string{}
 		}
 		newRole.Labels[NamespacedFolderOwnershipLabel] = string(folder.UID)
 
-		newRole.Rules = newRules
+		newRole.Rules = role.Rules
 		return nil
 	})
-	if err != nil {
-		return "", err
-	}
-
-	return roleName, nil
-
+	return err
 }
 
-func (r *NamespacedFolderReconciler) reconcileFolderPermissions(ctx context.Context, folder *v1alpha1.NamespacedFolder, vms []string) ([]string, []string, error) {
+func (r *NamespacedFolderReconciler) reconcileFolderPermissions(ctx context.Context, folder *v1alpha1.NamespacedFolder, vms []string, directVMs []string) ([]string, []string, error) {
 
 	appliedRoleBindings := []string{}
 	appliedRoles := []string{}
-	namespace := folder.Namespace
-
-	ownerRef := getNamespacedFolderOwnerReference(folder)
-	for _, fp := range folder.Spec.FolderPermissions {
-		for _, existingRR := range fp.RoleRefs {
-
-			roleName, err := r.reconcileRole(ctx, folder, &existingRR, vms)
-
-			if err != nil {
-				return appliedRoleBindings, appliedRoles, err
-			} else if roleName == "" {
-				// role isn't related to virtual machines
-				continue
-			}
-
-			appliedRoles = append(appliedRoles, roleName)
-			rr := rbacv1.RoleRef{
-				APIGroup: "rbac.authorization.k8s.io",
-				Kind:     "Role",
-				Name:     roleName,
-			}
-
-			name, err := generateRoleBindingNameHash(folder.UID, namespace, fp.Subject, rr)
-			if err != nil {
-				return appliedRoleBindings, appliedRoles, err
-			}
 
-			expectedRoleBinding := &rbacv1.RoleBinding{}
-			expectedRoleBinding.Name = name
-			expectedRoleBinding.Namespace = namespace
-			_, err = controllerutil.CreateOrUpdate(ctx, r.Client, expectedRoleBinding, func() error {
-				expectedRoleBinding.OwnerReferences = []metav1.OwnerReference{
-					*ownerRef,
-				}
-				if expectedRoleBinding.Labels == nil {
-					expectedRoleBinding.Labels = map[string]string{}
-				}
-				expectedRoleBinding.Labels[NamespacedFolderOwnershipLabel] = string(folder.UID)
+	grants, err := NamespacedFolderGrants(ctx, r.Client, folder, vms, directVMs)
+	if err != nil {
+		return appliedRoleBindings, appliedRoles, err
+	}
 
-				expectedRoleBinding.Subjects = []rbacv1.Subject{fp.Subject}
-				expectedRoleBinding.RoleRef = rr
-				return nil
-			})
-			if err != nil {
-				return appliedRoleBindings, appliedRoles, err
+	for _, grant := range grants {
+		if err := r.reconcileRole(ctx, folder, &grant.Role); err != nil {
+			return appliedRoleBindings, appliedRoles, err
+		}
+		appliedRoles = append(appliedRoles, grant.Role.Name)
+
+		expectedRoleBinding := &rbacv1.RoleBinding{}
+		expectedRoleBinding.Name = grant.RoleBinding.Name
+		expectedRoleBinding.Namespace = grant.RoleBinding.Namespace
+		_, err = controllerutil.CreateOrUpdate(ctx, r.Client, expectedRoleBinding, func() error {
+			expectedRoleBinding.OwnerReferences = grant.RoleBinding.OwnerReferences
+			if expectedRoleBinding.Labels == nil {
+				expectedRoleBinding.Labels = map[string]string{}
 			}
+			expectedRoleBinding.Labels[NamespacedFolderOwnershipLabel] = string(folder.UID)
 
-			appliedRoleBindings = append(appliedRoleBindings, name)
+			expectedRoleBinding.Subjects = grant.RoleBinding.Subjects
+			expectedRoleBinding.RoleRef = grant.RoleBinding.RoleRef
+			return nil
+		})
+		if err != nil {
+			return appliedRoleBindings, appliedRoles, err
 		}
+
+		appliedRoleBindings = append(appliedRoleBindings, grant.RoleBinding.Name)
 	}
 
 	return appliedRoleBindings, appliedRoles, nil
@@ -274,11 +330,11 @@ func (r *NamespacedFolderReconciler) reconcileFolderPermissions(ctx context.Cont
 // +kubebuilder:rbac:groups=kubevirtfolderview.kubevirt.io.github.com,resources=namespacedfolders,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kubevirtfolderview.kubevirt.io.github.com,resources=namespacedfolders/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kubevirtfolderview.kubevirt.io.github.com,resources=namespacedfolders/finalizers,verbs=update
+// +kubebuilder:rbac:groups=kubevirtfolderview.kubevirt.io.github.com,resources=foldermemberships,verbs=get;list;watch
 func (r *NamespacedFolderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 
 	log := logger.FromContext(ctx)
 
-	root := &v1alpha1.FolderIndex{}
 	folder := &v1alpha1.NamespacedFolder{}
 
 	log.Info(fmt.Sprintf("Reconciling namespaced folder [%s]", req.NamespacedName.Name))
@@ -289,15 +345,35 @@ func (r *NamespacedFolderReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
+	reconcileErr := r.reconcileRBAC(ctx, folder)
+	return ctrl.Result{}, errors.Join(reconcileErr, r.updateStatus(ctx, folder, reconcileErr))
+}
+
+// reconcileRBAC creates the Roles and RoleBindings of the folder for the
+// VMs its permissions reach and deletes the ones it no longer needs. Its
+// permissions reach the VMs of the folder, and are inherited top down by the
+// VMs of its descendants, up to the descendants that block inheritance.
+func (r *NamespacedFolderReconciler) reconcileRBAC(ctx context.Context, folder *v1alpha1.NamespacedFolder) error {
+	log := logger.FromContext(ctx)
+
+	root := &v1alpha1.FolderIndex{}
+
 	// TODO enforce that only a single folder index named root can exist
-	if err := r.Client.Get(ctx, client.ObjectKey{Name: "root"}, root); err != nil {
-		return ctrl.Result{}, err
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		return err
 	}
+	folderindex.RemoveDeletedMembers(root)
 
-	// Get all vms and child folder vms for this folder
-	vms, err := r.getAllVMs(root, folder.Name)
+	blocked, err := BlockedNamespacedFolders(ctx, r.Client, folder.Namespace)
 	if err != nil {
-		return ctrl.Result{}, err
+		return err
+	}
+
+	// Get the vms of this folder and the child folder vms that inherit its
+	// permissions. Folders are keyed by namespace/name in the index.
+	vms, directVMs, err := ReachedVirtualMachines(ctx, r.Client, root, folderindex.NamespacedFolderKey(folder.Namespace, folder.Name), blocked)
+	if err != nil {
+		return err
 	}
 
 	ownerLabels := map[string]string{
@@ -306,21 +382,21 @@ func (r *NamespacedFolderReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	rbList := rbacv1.RoleBindingList{}
 	if err := r.Client.List(ctx, &rbList, client.MatchingLabels(ownerLabels)); err != nil {
-		return ctrl.Result{}, err
+		return err
 	}
 
 	rList := rbacv1.RoleList{}
 	if err := r.Client.List(ctx, &rList, client.MatchingLabels(ownerLabels)); err != nil {
-		return ctrl.Result{}, err
+		return err
 	}
 
 	// Create RoleBindings for this folder in every namespace
 	expectedRoleBindings := map[string]bool{}
 	expectedRoles := map[string]bool{}
 
-	appliedRoleBindings, appliedRoles, err := r.reconcileFolderPermissions(ctx, folder, vms)
+	appliedRoleBindings, appliedRoles, err := r.reconcileFolderPermissions(ctx, folder, vms, directVMs)
 	if err != nil {
-		return ctrl.Result{}, err
+		return err
 	}
 
 	for _, rbName := range appliedRoleBindings {
@@ -336,7 +412,7 @@ func (r *NamespacedFolderReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		if !ok {
 			err := r.Client.Delete(ctx, &roleBinding)
 			if err != nil {
-				return ctrl.Result{}, err
+				return err
 			}
 			log.Info(fmt.Sprintf("Deleted unused roleBinding: %s\n", roleBinding.Name))
 		}
@@ -347,20 +423,44 @@ func (r *NamespacedFolderReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		if !ok {
 			err := r.Client.Delete(ctx, &role)
 			if err != nil {
-				return ctrl.Result{}, err
+				return err
 			}
 			log.Info(fmt.Sprintf("Deleted unused role: %s\n", role.Name))
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return nil
+}
+
+// updateStatus reports the outcome of reconciling the folder in its status.
+func (r *NamespacedFolderReconciler) updateStatus(ctx context.Context, folder *v1alpha1.NamespacedFolder, reconcileErr error) error {
+	orig := folder.DeepCopy()
+	folder.Status.ObservedGeneration = folder.Generation
+	meta.SetStatusCondition(&folder.Status.Conditions, readyCondition(folder.Generation, reconcileErr))
+	if equality.Semantic.DeepEqual(orig.Status, folder.Status) {
+		return nil
+	}
+	return r.Client.Status().Patch(ctx, folder, client.MergeFrom(orig))
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *NamespacedFolderReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := IndexFolderMemberships(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{}).
 		Named("namespacedfolder").
+		Watches(
+			&kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{},
+			handler.EnqueueRequestsFromMapFunc(r.folderMembershipToFolders),
+		).
+		Watches(
+			&kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{},
+			handler.EnqueueRequestsFromMapFunc(r.namespacedFolderToAncestors),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		// TODO - reenqueue folder if role, rolebindings change
 		//		Watches(
 		//			&rbacv1.Role{},
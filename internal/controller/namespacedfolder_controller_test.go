@@ -18,11 +18,14 @@ package controller
 
 import (
 	"context"
+	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -85,7 +88,7 @@ var _ = Describe("NamespacedFolder Controller", func() {
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &NamespacedFolderReconciler{
-				Client: k8sClient,
+				Client: reconcileClient,
 				Scheme: k8sClient.Scheme(),
 			}
 
@@ -96,5 +99,174 @@ var _ = Describe("NamespacedFolder Controller", func() {
 			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
+
+		It("should grant access to VMs filed by a FolderMembership", func() {
+			controllerReconciler := &NamespacedFolderReconciler{
+				Client: reconcileClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("granting a KubeVirt role on the folder")
+			clusterRole := &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: "folder-membership-test-vm-viewer"},
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups: []string{"kubevirt.io"},
+					Resources: []string{"virtualmachines"},
+					Verbs:     []string{"get"},
+				}},
+			}
+			Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, clusterRole)
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, namespacedFolder)).To(Succeed())
+			namespacedFolder.Spec.FolderPermissions = []kubevirtfolderviewkubevirtiov1alpha1.FolderPermission{{
+				Subject:  rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "folder-membership-test-user"},
+				RoleRefs: []rbacv1.RoleRef{{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRole.Name}},
+			}}
+			Expect(k8sClient.Update(ctx, namespacedFolder)).To(Succeed())
+
+			By("filing a VM into the folder with a FolderMembership")
+			Expect(k8sClient.Get(ctx, rootNamespacedName, root)).To(Succeed())
+			root.Spec.NamespacedFolderEntries = map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				"default/" + resourceName: {},
+			}
+			Expect(k8sClient.Update(ctx, root)).To(Succeed())
+
+			membership := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{Name: "vm-a", Namespace: "default"},
+				Spec:       kubevirtfolderviewkubevirtiov1alpha1.FolderMembershipSpec{Folder: resourceName},
+			}
+			Expect(k8sClient.Create(ctx, membership)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, membership)
+
+			Eventually(func(g Gomega) {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				g.Expect(err).NotTo(HaveOccurred())
+
+				roles := &rbacv1.RoleList{}
+				g.Expect(k8sClient.List(ctx, roles, client.MatchingLabels{
+					NamespacedFolderOwnershipLabel: string(namespacedFolder.UID),
+				})).To(Succeed())
+				g.Expect(roles.Items).To(HaveLen(1))
+				g.Expect(roles.Items[0].Rules[0].ResourceNames).To(Equal([]string{"vm-a"}))
+			}).Should(Succeed())
+		})
+
+		It("should split grants reaching many VMs across several Roles", func() {
+			clusterRole := &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: "role-shard-test-vm-viewer"},
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups: []string{"kubevirt.io"},
+					Resources: []string{"virtualmachines"},
+					Verbs:     []string{"get"},
+				}},
+			}
+			Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, clusterRole)
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, namespacedFolder)).To(Succeed())
+			namespacedFolder.Spec.FolderPermissions = []kubevirtfolderviewkubevirtiov1alpha1.FolderPermission{{
+				Subject:  rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "role-shard-test-user"},
+				RoleRefs: []rbacv1.RoleRef{{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRole.Name}},
+			}}
+
+			vms := []string{}
+			for i := range 2*VirtualMachinesPerRole + 1 {
+				vms = append(vms, fmt.Sprintf("vm-%d", i))
+			}
+			grants, err := NamespacedFolderGrants(ctx, k8sClient, namespacedFolder, vms, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(grants).To(HaveLen(3))
+
+			granted := []string{}
+			roleNames := map[string]bool{}
+			for _, grant := range grants {
+				Expect(len(grant.Role.Rules[0].ResourceNames)).To(BeNumerically("<=", VirtualMachinesPerRole))
+				Expect(grant.RoleBinding.RoleRef.Name).To(Equal(grant.Role.Name))
+				granted = append(granted, grant.Role.Rules[0].ResourceNames...)
+				roleNames[grant.Role.Name] = true
+			}
+			Expect(granted).To(Equal(vms))
+			Expect(roleNames).To(HaveLen(3))
+		})
+
+		It("should stop inherited grants at folders that block inheritance", func() {
+			controllerReconciler := &NamespacedFolderReconciler{
+				Client: reconcileClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			clusterRole := &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: "inheritance-test-vm-viewer"},
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups: []string{"kubevirt.io"},
+					Resources: []string{"virtualmachines"},
+					Verbs:     []string{"get"},
+				}},
+			}
+			Expect(k8sClient.Create(ctx, clusterRole)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, clusterRole)
+			roleRefs := []rbacv1.RoleRef{{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRole.Name}}
+
+			By("granting one user access to the folder tree and another to the folder only")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, namespacedFolder)).To(Succeed())
+			namespacedFolder.Spec.FolderPermissions = []kubevirtfolderviewkubevirtiov1alpha1.FolderPermission{{
+				Subject:  rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "inherited-user"},
+				RoleRefs: roleRefs,
+			}, {
+				Subject:             rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "folder-user"},
+				RoleRefs:            roleRefs,
+				ExcludeFromChildren: true,
+			}}
+			Expect(k8sClient.Update(ctx, namespacedFolder)).To(Succeed())
+
+			isolated := &kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{
+				ObjectMeta: metav1.ObjectMeta{Name: "isolated", Namespace: "default"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderSpec{
+					Inheritance: &kubevirtfolderviewkubevirtiov1alpha1.FolderInheritance{Block: true},
+				},
+			}
+			Expect(k8sClient.Create(ctx, isolated)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, isolated)
+
+			Expect(k8sClient.Get(ctx, rootNamespacedName, root)).To(Succeed())
+			root.Spec.NamespacedFolderEntries = map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				"default/" + resourceName: {
+					ChildFolders:    []string{"default/child", "default/isolated"},
+					VirtualMachines: []string{"vm-parent"},
+				},
+				"default/child":    {VirtualMachines: []string{"vm-child"}},
+				"default/isolated": {VirtualMachines: []string{"vm-isolated"}},
+			}
+			Expect(k8sClient.Update(ctx, root)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				g.Expect(err).NotTo(HaveOccurred())
+
+				ownerLabels := client.MatchingLabels{NamespacedFolderOwnershipLabel: string(namespacedFolder.UID)}
+				roleBindings := &rbacv1.RoleBindingList{}
+				g.Expect(k8sClient.List(ctx, roleBindings, ownerLabels)).To(Succeed())
+				roles := &rbacv1.RoleList{}
+				g.Expect(k8sClient.List(ctx, roles, ownerLabels)).To(Succeed())
+
+				reached := map[string][]string{}
+				for _, roleBinding := range roleBindings.Items {
+					for _, role := range roles.Items {
+						if role.Name == roleBinding.RoleRef.Name {
+							reached[roleBinding.Subjects[0].Name] = role.Rules[0].ResourceNames
+						}
+					}
+				}
+				g.Expect(reached).To(Equal(map[string][]string{
+					"inherited-user": {"vm-parent", "vm-child"},
+					"folder-user":    {"vm-parent"},
+				}))
+			}).Should(Succeed())
+		})
 	})
 })
@@ -0,0 +1,236 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	virtv1 "kubevirt.io/api/core/v1"
+	instancetypeapi "kubevirt.io/api/instancetype"
+	instancetypev1beta1 "kubevirt.io/api/instancetype/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// templateCPUs returns the vCPUs of a VirtualMachineInstance spec the way
+// KubeVirt derives them: from the CPU topology, else from the CPU limit or
+// request, else a single vCPU.
+func templateCPUs(spec *virtv1.VirtualMachineInstanceSpec) int64 {
+	if cpu := spec.Domain.CPU; cpu != nil && cpu.Sockets+cpu.Cores+cpu.Threads != 0 {
+		return int64(max(cpu.Sockets, 1)) * int64(max(cpu.Cores, 1)) * int64(max(cpu.Threads, 1))
+	}
+	for _, resources := range []corev1.ResourceList{spec.Domain.Resources.Limits, spec.Domain.Resources.Requests} {
+		if quantity, exists := resources[corev1.ResourceCPU]; exists {
+			return max((quantity.MilliValue()+999)/1000, 1)
+		}
+	}
+	return 1
+}
+
+// templateMemory returns the guest memory of a VirtualMachineInstance spec,
+// falling back to the memory request and limit.
+func templateMemory(spec *virtv1.VirtualMachineInstanceSpec) resource.Quantity {
+	if spec.Domain.Memory != nil && spec.Domain.Memory.Guest != nil {
+		return spec.Domain.Memory.Guest.DeepCopy()
+	}
+	for _, resources := range []corev1.ResourceList{spec.Domain.Resources.Requests, spec.Domain.Resources.Limits} {
+		if quantity, exists := resources[corev1.ResourceMemory]; exists {
+			return quantity.DeepCopy()
+		}
+	}
+	return resource.Quantity{}
+}
+
+// instancetypeResources returns the vCPUs and guest memory of the
+// instancetype a VirtualMachine references. It reports false for
+// VirtualMachines without an instancetype and for instancetypes that do not
+// exist.
+func instancetypeResources(ctx context.Context, c client.Reader, vm *virtv1.VirtualMachine) (int64, resource.Quantity, bool, error) {
+	matcher := vm.Spec.Instancetype
+	if matcher == nil || matcher.Name == "" {
+		return 0, resource.Quantity{}, false, nil
+	}
+
+	var spec instancetypev1beta1.VirtualMachineInstancetypeSpec
+	switch strings.ToLower(matcher.Kind) {
+	case instancetypeapi.SingularResourceName, instancetypeapi.PluralResourceName:
+		instancetype := &instancetypev1beta1.VirtualMachineInstancetype{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: vm.Namespace, Name: matcher.Name}, instancetype); err != nil {
+			if apierrors.IsNotFound(err) {
+				return 0, resource.Quantity{}, false, nil
+			}
+			return 0, resource.Quantity{}, false, err
+		}
+		spec = instancetype.Spec
+	default:
+		instancetype := &instancetypev1beta1.VirtualMachineClusterInstancetype{}
+		if err := c.Get(ctx, client.ObjectKey{Name: matcher.Name}, instancetype); err != nil {
+			if apierrors.IsNotFound(err) {
+				return 0, resource.Quantity{}, false, nil
+			}
+			return 0, resource.Quantity{}, false, err
+		}
+		spec = instancetype.Spec
+	}
+	return int64(spec.CPU.Guest), spec.Memory.Guest.DeepCopy(), true, nil
+}
+
+// +kubebuilder:rbac:groups=instancetype.kubevirt.io,resources=virtualmachineinstancetypes;virtualmachineclusterinstancetypes,verbs=get;list;watch
+
+// VirtualMachineUsage returns what a VirtualMachine counts against a
+// FolderQuota: the VirtualMachine itself, its vCPUs and its guest memory.
+// The resources of an instancetype take precedence over the template.
+func VirtualMachineUsage(ctx context.Context, c client.Reader, vm *virtv1.VirtualMachine) (v1alpha1.FolderQuotaUsage, error) {
+	usage := v1alpha1.FolderQuotaUsage{VirtualMachines: 1}
+
+	cpus, memory, exists, err := instancetypeResources(ctx, c, vm)
+	if err != nil {
+		return usage, err
+	}
+	if !exists && vm.Spec.Template != nil {
+		cpus = templateCPUs(&vm.Spec.Template.Spec)
+		memory = templateMemory(&vm.Spec.Template.Spec)
+	}
+
+	usage.CPU = *resource.NewQuantity(cpus, resource.DecimalSI)
+	usage.Memory = memory
+	return usage, nil
+}
+
+// AddUsage adds b to a.
+func AddUsage(a *v1alpha1.FolderQuotaUsage, b v1alpha1.FolderQuotaUsage) {
+	a.VirtualMachines += b.VirtualMachines
+	a.CPU.Add(b.CPU)
+	a.Memory.Add(b.Memory)
+}
+
+// ClusterFolderUsage returns the usage of the VirtualMachines in the
+// namespaces of a ClusterFolder and of its descendants.
+func ClusterFolderUsage(ctx context.Context, c client.Reader, root *v1alpha1.FolderIndex, folder string) (v1alpha1.FolderQuotaUsage, error) {
+	return NamespacesUsage(ctx, c, folderindex.GetAllNamespaces(root, folder))
+}
+
+// SubtractUsage subtracts b from a.
+func SubtractUsage(a *v1alpha1.FolderQuotaUsage, b v1alpha1.FolderQuotaUsage) {
+	a.VirtualMachines -= b.VirtualMachines
+	a.CPU.Sub(b.CPU)
+	a.Memory.Sub(b.Memory)
+}
+
+// NamespacesUsage returns the usage of the VirtualMachines in namespaces.
+func NamespacesUsage(ctx context.Context, c client.Reader, namespaces []string) (v1alpha1.FolderQuotaUsage, error) {
+	usage := v1alpha1.FolderQuotaUsage{
+		CPU:    *resource.NewQuantity(0, resource.DecimalSI),
+		Memory: *resource.NewQuantity(0, resource.BinarySI),
+	}
+	for _, namespace := range namespaces {
+		vms := &virtv1.VirtualMachineList{}
+		if err := c.List(ctx, vms, client.InNamespace(namespace)); err != nil {
+			return usage, err
+		}
+		for i := range vms.Items {
+			vmUsage, err := VirtualMachineUsage(ctx, c, &vms.Items[i])
+			if err != nil {
+				return usage, err
+			}
+			AddUsage(&usage, vmUsage)
+		}
+	}
+	return usage, nil
+}
+
+// GrowingQuota returns the limits of hard that usage grows against compared
+// to oldUsage, so that usage that is already over a quota can still shrink.
+func GrowingQuota(hard v1alpha1.FolderQuota, usage, oldUsage v1alpha1.FolderQuotaUsage) v1alpha1.FolderQuota {
+	growing := *hard.DeepCopy()
+	if usage.VirtualMachines <= oldUsage.VirtualMachines {
+		growing.VirtualMachines = nil
+	}
+	if usage.CPU.Cmp(oldUsage.CPU) <= 0 {
+		growing.CPU = nil
+	}
+	if usage.Memory.Cmp(oldUsage.Memory) <= 0 {
+		growing.Memory = nil
+	}
+	return growing
+}
+
+// CheckFiledNamespaces checks that the namespaces a change of the FolderIndex
+// files into a ClusterFolder keep the folder and its ancestors within their
+// quotas. before and after are the FolderIndex before and after the change.
+func CheckFiledNamespaces(ctx context.Context, c client.Reader, before, after *v1alpha1.FolderIndex) error {
+	folders := &v1alpha1.ClusterFolderList{}
+	if err := c.List(ctx, folders); err != nil {
+		return err
+	}
+
+	for i := range folders.Items {
+		folder := &folders.Items[i]
+		if _, exists := after.Spec.ClusterFolderEntries[folder.Name]; folder.Spec.Quota == nil || !exists {
+			continue
+		}
+
+		oldNamespaces := folderindex.GetAllNamespaces(before, folder.Name)
+		namespaces := folderindex.GetAllNamespaces(after, folder.Name)
+		added := []string{}
+		for _, namespace := range namespaces {
+			if !slices.Contains(oldNamespaces, namespace) {
+				added = append(added, namespace)
+			}
+		}
+		if len(added) == 0 {
+			continue
+		}
+
+		oldUsed, err := NamespacesUsage(ctx, c, oldNamespaces)
+		if err != nil {
+			return err
+		}
+		used, err := NamespacesUsage(ctx, c, namespaces)
+		if err != nil {
+			return err
+		}
+		if exceeded := QuotaExceeded(GrowingQuota(*folder.Spec.Quota, used, oldUsed), used); len(exceeded) != 0 {
+			return fmt.Errorf("filing namespaces [%s] into cluster folder [%s] exceeds its quota: %s", strings.Join(added, ", "), folder.Name, strings.Join(exceeded, ", "))
+		}
+	}
+	return nil
+}
+
+// QuotaExceeded returns a description of every limit of hard that used
+// exceeds.
+func QuotaExceeded(hard v1alpha1.FolderQuota, used v1alpha1.FolderQuotaUsage) []string {
+	exceeded := []string{}
+	if hard.VirtualMachines != nil && used.VirtualMachines > *hard.VirtualMachines {
+		exceeded = append(exceeded, fmt.Sprintf("virtualMachines: used %d, limited to %d", used.VirtualMachines, *hard.VirtualMachines))
+	}
+	if hard.CPU != nil && used.CPU.Cmp(*hard.CPU) > 0 {
+		exceeded = append(exceeded, fmt.Sprintf("cpu: used %s, limited to %s", used.CPU.String(), hard.CPU.String()))
+	}
+	if hard.Memory != nil && used.Memory.Cmp(*hard.Memory) > 0 {
+		exceeded = append(exceeded, fmt.Sprintf("memory: used %s, limited to %s", used.Memory.String(), hard.Memory.String()))
+	}
+	return exceeded
+}
@@ -25,8 +25,12 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -45,6 +49,9 @@ var (
 	testEnv   *envtest.Environment
 	cfg       *rest.Config
 	k8sClient client.Client
+	// reconcileClient reads FolderMemberships from an informer cache with
+	// the membership index, as the client of the manager does.
+	reconcileClient client.Client
 )
 
 func TestControllers(t *testing.T) {
@@ -62,11 +69,17 @@ var _ = BeforeSuite(func() {
 	err = kubevirtfolderviewkubevirtiov1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = virtv1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	// +kubebuilder:scaffold:scheme
 
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "config", "crd", "bases"),
+			filepath.Join("..", "..", "test", "crd"),
+		},
 		ErrorIfCRDPathMissing: true,
 	}
 
@@ -83,6 +96,31 @@ var _ = BeforeSuite(func() {
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(k8sClient).NotTo(BeNil())
+
+	membershipCache, err := cache.New(cfg, cache.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(IndexFolderMemberships(ctx, membershipCache)).To(Succeed())
+	go func() {
+		defer GinkgoRecover()
+		Expect(membershipCache.Start(ctx)).To(Succeed())
+	}()
+
+	reconcileClient, err = client.New(cfg, client.Options{
+		Scheme: scheme.Scheme,
+		Cache: &client.CacheOptions{
+			Reader: membershipCache,
+			DisableFor: []client.Object{
+				&kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{},
+				&kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{},
+				&kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{},
+				&corev1.Namespace{},
+				&rbacv1.ClusterRole{},
+				&rbacv1.Role{},
+				&rbacv1.RoleBinding{},
+			},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
 })
 
 var _ = AfterSuite(func() {
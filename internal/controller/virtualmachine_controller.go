@@ -0,0 +1,289 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	virtv1 "kubevirt.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logger "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// VirtualMachineReconciler files VirtualMachines created with the
+// FolderAnnotation into their folder, and labels the VirtualMachines of
+// NamespacedFolders, and their VirtualMachineInstances, with the folder that
+// holds them and the propagated tags of their folders. VirtualMachines filed
+// into a folder after they were created get the defaults of the folder, once
+// the PlacedFolderAnnotation tracks the folder they were in before.
+type VirtualMachineReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// WebhooksEnabled reports that the VirtualMachine webhooks run. They
+	// authorize the FolderAnnotation, so without them no VirtualMachine is
+	// filed by the annotation.
+	WebhooksEnabled bool
+}
+
+// PlacementGracePeriod is how long after a namespace gets the FoldersLabel
+// the VirtualMachines created in it are still not trusted to have passed the
+// VirtualMachine webhooks. It covers the time the API server takes to see the
+// label and the clock skew between the manager and the API server.
+const PlacementGracePeriod = 30 * time.Second
+
+// virtualMachineLabels returns the folder labels of a VirtualMachine in the
+// NamespacedFolder key, given the folder and its ancestors.
+func virtualMachineLabels(key string, folders []*v1alpha1.NamespacedFolder) map[string]string {
+	labels := folderindex.VirtualMachineLabels(key)
+	ancestry := []v1alpha1.FolderMetadata{}
+	for _, folder := range folders {
+		ancestry = append(ancestry, folder.Spec.FolderMetadata)
+	}
+	maps.Copy(labels, folderindex.TagLabels(ancestry))
+	return labels
+}
+
+// placementAdmitted reports whether the VirtualMachine webhooks admitted the
+// FolderAnnotation of vm: they are enabled, and they ran in its namespace when
+// it was created.
+func (r *VirtualMachineReconciler) placementAdmitted(ctx context.Context, vm *virtv1.VirtualMachine) (bool, error) {
+	if !r.WebhooksEnabled {
+		return false, nil
+	}
+	namespace := &corev1.Namespace{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: vm.Namespace}, namespace); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if _, labeled := namespace.Labels[v1alpha1.FoldersLabel]; !labeled {
+		return false, nil
+	}
+	since, err := time.Parse(time.RFC3339, namespace.Annotations[v1alpha1.FoldersSinceAnnotation])
+	if err != nil {
+		return false, nil
+	}
+	return vm.CreationTimestamp.After(since.Add(PlacementGracePeriod)), nil
+}
+
+// placeVirtualMachine files a VirtualMachine created with the
+// FolderAnnotation into the folder it names with a FolderMembership, unless
+// the VirtualMachine already has one. The membership is owned by the
+// VirtualMachine, so it is deleted along with it. The annotation is removed
+// afterwards, so the VirtualMachine is filed only once and can be moved or
+// unfiled later like any other. Annotations the VirtualMachine webhooks did
+// not admit are removed without filing the VirtualMachine.
+func (r *VirtualMachineReconciler) placeVirtualMachine(ctx context.Context, vm *virtv1.VirtualMachine) error {
+	log := logger.FromContext(ctx)
+
+	folder, exists := vm.Annotations[v1alpha1.FolderAnnotation]
+	if !exists {
+		return nil
+	}
+
+	if folder != "" {
+		admitted, err := r.placementAdmitted(ctx, vm)
+		if err != nil {
+			return err
+		}
+		if !admitted {
+			log.Info(fmt.Sprintf("Not filing virtual machine [%s/%s] into namespaced folder [%s], the VirtualMachine webhooks did not admit it", vm.Namespace, vm.Name, folder))
+		} else {
+			membership := &v1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{Namespace: vm.Namespace, Name: vm.Name},
+				Spec:       v1alpha1.FolderMembershipSpec{Folder: folder},
+			}
+			if err := controllerutil.SetOwnerReference(vm, membership, r.Scheme); err != nil {
+				return err
+			}
+			log.Info(fmt.Sprintf("Filing virtual machine [%s/%s] into namespaced folder [%s]", vm.Namespace, vm.Name, folder))
+			if err := r.Client.Create(ctx, membership); err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+		}
+	}
+
+	orig := vm.DeepCopy()
+	delete(vm.Annotations, v1alpha1.FolderAnnotation)
+	return r.Client.Patch(ctx, vm, client.MergeFrom(orig))
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachines,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachineinstances,verbs=get;list;watch;update;patch
+
+func (r *VirtualMachineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logger.FromContext(ctx)
+
+	vm := &virtv1.VirtualMachine{}
+	if err := r.Client.Get(ctx, req.NamespacedName, vm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if err := r.placeVirtualMachine(ctx, vm); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// without an index no VirtualMachine is in a folder and all folder
+	// labels are removed
+	root := &v1alpha1.FolderIndex{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	folderindex.RemoveDeletedMembers(root)
+
+	key, filed, err := VirtualMachineFolder(ctx, r.Client, root, vm.Namespace, vm.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	orig := vm.DeepCopy()
+	labels := map[string]string{}
+	if filed {
+		folders, err := NamespacedFolders(ctx, r.Client, root, key)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		labels = virtualMachineLabels(key, folders)
+
+		// a VirtualMachine filed into another folder than the one it was
+		// last placed in, by the index or a FolderMembership, gets the
+		// defaults of its new folder like a VirtualMachine created there
+		placed := SetPlacedFolder(vm, key, true)
+		ApplyDefaults(vm, folders, placed)
+	} else {
+		SetPlacedFolder(vm, "", false)
+	}
+	syncLabels(vm, labels)
+	// VirtualMachineInstances, and their virt-launcher pods, take their
+	// labels from the template, so the folder labels are set there too
+	if vm.Spec.Template != nil {
+		syncLabels(&vm.Spec.Template.ObjectMeta, labels)
+	}
+	if !equality.Semantic.DeepEqual(orig, vm) {
+		log.Info(fmt.Sprintf("Updating folder labels and defaults of virtual machine [%s/%s]", vm.Namespace, vm.Name))
+		if err := r.Client.Patch(ctx, vm, client.MergeFrom(orig)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// labels of the template only reach a VirtualMachineInstance when it is
+	// started, so the running instance is labeled directly
+	vmi := &virtv1.VirtualMachineInstance{}
+	if err := r.Client.Get(ctx, req.NamespacedName, vmi); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	origVMI := vmi.DeepCopy()
+	if !syncLabels(vmi, labels) {
+		return ctrl.Result{}, nil
+	}
+	log.Info(fmt.Sprintf("Updating folder labels of virtual machine instance [%s/%s]", vmi.Namespace, vmi.Name))
+	return ctrl.Result{}, r.Client.Patch(ctx, vmi, client.MergeFrom(origVMI))
+}
+
+// namespaceVirtualMachines returns a request for every VirtualMachine in
+// namespace.
+func (r *VirtualMachineReconciler) namespaceVirtualMachines(ctx context.Context, namespace string) []reconcile.Request {
+	vms := &virtv1.VirtualMachineList{}
+	if err := r.Client.List(ctx, vms, client.InNamespace(namespace)); err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, vm := range vms.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: vm.Namespace, Name: vm.Name}})
+	}
+	return requests
+}
+
+// namespacedFolderToVirtualMachines maps a NamespacedFolder to the
+// VirtualMachines of its namespace. Any of them may be in the folder or in
+// one of its descendants.
+func (r *VirtualMachineReconciler) namespacedFolderToVirtualMachines(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.namespaceVirtualMachines(ctx, obj.GetNamespace())
+}
+
+// folderIndexToVirtualMachines maps the FolderIndex to the VirtualMachines of
+// the namespaces it has NamespacedFolders in. This includes the
+// VirtualMachines filed by FolderMemberships, whose folders the index
+// arranges as well.
+func (r *VirtualMachineReconciler) folderIndexToVirtualMachines(ctx context.Context, root *v1alpha1.FolderIndex) []reconcile.Request {
+	namespaces := map[string]bool{}
+	for key := range root.Spec.NamespacedFolderEntries {
+		if namespace := folderindex.NamespacedFolderNamespace(key); namespace != "" {
+			namespaces[namespace] = true
+		}
+	}
+
+	requests := []reconcile.Request{}
+	for namespace := range namespaces {
+		requests = append(requests, r.namespaceVirtualMachines(ctx, namespace)...)
+	}
+	return requests
+}
+
+// folderMembershipToVirtualMachine maps a FolderMembership to its
+// VirtualMachine, which has the same namespace and name.
+func (r *VirtualMachineReconciler) folderMembershipToVirtualMachine(_ context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VirtualMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&virtv1.VirtualMachine{}).
+		Owns(&virtv1.VirtualMachineInstance{}).
+		Named("virtualmachine").
+		Watches(
+			&v1alpha1.NamespacedFolder{},
+			handler.EnqueueRequestsFromMapFunc(r.namespacedFolderToVirtualMachines),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&v1alpha1.FolderMembership{},
+			handler.EnqueueRequestsFromMapFunc(r.folderMembershipToVirtualMachine),
+		).
+		Watches(
+			&v1alpha1.FolderIndex{},
+			folderIndexHandler(r.folderIndexToVirtualMachines),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Complete(r)
+}
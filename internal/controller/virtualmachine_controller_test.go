@@ -0,0 +1,290 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("VirtualMachine Controller", func() {
+	Context("When VMs are filed into NamespacedFolders", func() {
+		ctx := context.Background()
+
+		root := &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{}
+		controllerReconciler := &VirtualMachineReconciler{}
+
+		// newVM creates a VirtualMachine in the default namespace.
+		newVM := func(name string, annotations map[string]string) *virtv1.VirtualMachine {
+			vm := &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Annotations: annotations},
+			}
+			Expect(k8sClient.Create(ctx, vm)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, vm)
+			return vm
+		}
+
+		reconcileVM := func(name string) *virtv1.VirtualMachine {
+			key := types.NamespacedName{Namespace: "default", Name: name}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			vm := &virtv1.VirtualMachine{}
+			Expect(k8sClient.Get(ctx, key, vm)).To(Succeed())
+			return vm
+		}
+
+		BeforeEach(func() {
+			folder := &kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderSpec{
+					FolderMetadata: kubevirtfolderviewkubevirtiov1alpha1.FolderMetadata{
+						Tags:           map[string]string{"team": "web"},
+						PropagatedTags: []string{"team"},
+					},
+					Defaults: &kubevirtfolderviewkubevirtiov1alpha1.FolderDefaults{
+						Labels:      map[string]string{"app": "web"},
+						RunStrategy: "Halted",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, folder)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, folder)
+
+			root = &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{
+				ObjectMeta: metav1.ObjectMeta{Name: "root"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderIndexSpec{
+					NamespacedFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+						"default/web": {VirtualMachines: []string{"filed-later", "already-filed"}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, root)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, root)
+
+			controllerReconciler = &VirtualMachineReconciler{
+				Client: reconcileClient,
+				Scheme: k8sClient.Scheme(),
+			}
+		})
+
+		It("should apply the defaults of the folder a VM is filed into", func() {
+			newVM("filed-later", map[string]string{kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation: ""})
+
+			Eventually(func(g Gomega) {
+				vm := reconcileVM("filed-later")
+				g.Expect(vm.Labels).To(HaveKeyWithValue("app", "web"))
+				g.Expect(vm.Labels).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.FolderLabel, "web"))
+				g.Expect(vm.Labels).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.TagLabelPrefix+"team", "web"))
+				g.Expect(vm.Spec.RunStrategy).NotTo(BeNil())
+				g.Expect(*vm.Spec.RunStrategy).To(Equal(virtv1.RunStrategyHalted))
+				g.Expect(vm.Annotations).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation, "web"))
+			}).Should(Succeed())
+		})
+
+		It("should not start or resize VMs that stay in their folder", func() {
+			newVM("already-filed", map[string]string{kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation: "web"})
+
+			Eventually(func(g Gomega) {
+				vm := reconcileVM("already-filed")
+				g.Expect(vm.Labels).To(HaveKeyWithValue("app", "web"))
+				g.Expect(vm.Spec.RunStrategy).To(BeNil())
+			}).Should(Succeed())
+		})
+
+		It("should not start or resize VMs whose folder was never tracked", func() {
+			newVM("filed-later", nil)
+
+			Eventually(func(g Gomega) {
+				vm := reconcileVM("filed-later")
+				g.Expect(vm.Labels).To(HaveKeyWithValue("app", "web"))
+				g.Expect(vm.Spec.RunStrategy).To(BeNil())
+				g.Expect(vm.Annotations).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation, "web"))
+			}).Should(Succeed())
+		})
+
+		It("should label the template of VMs with their folder", func() {
+			vm := &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "filed-later"},
+				Spec: virtv1.VirtualMachineSpec{
+					Template: &virtv1.VirtualMachineInstanceTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubevirt.io/domain": "filed-later"}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, vm)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, vm)
+
+			Eventually(func(g Gomega) {
+				vm := reconcileVM("filed-later")
+				g.Expect(vm.Spec.Template.ObjectMeta.Labels).To(Equal(map[string]string{
+					"kubevirt.io/domain":                                         "filed-later",
+					kubevirtfolderviewkubevirtiov1alpha1.FolderLabel:             "web",
+					kubevirtfolderviewkubevirtiov1alpha1.TagLabelPrefix + "team": "web",
+				}))
+			}).Should(Succeed())
+		})
+
+		It("should label VMs and their VMIs with their folder and its propagated tags", func() {
+			newVM("filed-later", map[string]string{kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation: ""})
+			vmi := &virtv1.VirtualMachineInstance{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "filed-later"},
+			}
+			Expect(k8sClient.Create(ctx, vmi)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, vmi)
+
+			Eventually(func(g Gomega) {
+				reconcileVM("filed-later")
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(vmi), vmi)).To(Succeed())
+				g.Expect(vmi.Labels).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.FolderLabel, "web"))
+				g.Expect(vmi.Labels).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.TagLabelPrefix+"team", "web"))
+			}).Should(Succeed())
+
+			By("removing the labels once the VM leaves the folder")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(root), root)).To(Succeed())
+			root.Spec.NamespacedFolderEntries["default/web"] = kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{}
+			Expect(k8sClient.Update(ctx, root)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				vm := reconcileVM("filed-later")
+				g.Expect(vm.Labels).NotTo(HaveKey(kubevirtfolderviewkubevirtiov1alpha1.FolderLabel))
+				g.Expect(vm.Labels).NotTo(HaveKey(kubevirtfolderviewkubevirtiov1alpha1.TagLabelPrefix + "team"))
+				g.Expect(vm.Annotations).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation, ""))
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(vmi), vmi)).To(Succeed())
+				g.Expect(vmi.Labels).NotTo(HaveKey(kubevirtfolderviewkubevirtiov1alpha1.FolderLabel))
+			}).Should(Succeed())
+		})
+	})
+
+	Context("When VMs are created with the folder annotation", func() {
+		ctx := context.Background()
+
+		root := &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{}
+		controllerReconciler := &VirtualMachineReconciler{}
+
+		// setFoldersSince labels the default namespace as watched by the
+		// VirtualMachine webhooks since the given time.
+		setFoldersSince := func(since time.Time) {
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, namespace)).To(Succeed())
+			orig := namespace.DeepCopy()
+			if namespace.Labels == nil {
+				namespace.Labels = map[string]string{}
+			}
+			if namespace.Annotations == nil {
+				namespace.Annotations = map[string]string{}
+			}
+			namespace.Labels[kubevirtfolderviewkubevirtiov1alpha1.FoldersLabel] = "true"
+			namespace.Annotations[kubevirtfolderviewkubevirtiov1alpha1.FoldersSinceAnnotation] = since.UTC().Format(time.RFC3339)
+			Expect(k8sClient.Patch(ctx, namespace, client.MergeFrom(orig))).To(Succeed())
+		}
+
+		newAnnotatedVM := func(name string) *virtv1.VirtualMachine {
+			vm := &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "default",
+					Name:        name,
+					Annotations: map[string]string{kubevirtfolderviewkubevirtiov1alpha1.FolderAnnotation: "web"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, vm)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, vm)
+			return vm
+		}
+
+		// placeVM reconciles the VM until its annotation is removed.
+		placeVM := func(name string) {
+			key := types.NamespacedName{Namespace: "default", Name: name}
+			Eventually(func(g Gomega) {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				g.Expect(err).NotTo(HaveOccurred())
+				vm := &virtv1.VirtualMachine{}
+				g.Expect(k8sClient.Get(ctx, key, vm)).To(Succeed())
+				g.Expect(vm.Annotations).NotTo(HaveKey(kubevirtfolderviewkubevirtiov1alpha1.FolderAnnotation))
+			}).Should(Succeed())
+		}
+
+		BeforeEach(func() {
+			root = &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{
+				ObjectMeta: metav1.ObjectMeta{Name: "root"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderIndexSpec{
+					NamespacedFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+						"default/web": {},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, root)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, root)
+
+			DeferCleanup(func() {
+				namespace := &corev1.Namespace{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "default"}, namespace)).To(Succeed())
+				orig := namespace.DeepCopy()
+				delete(namespace.Labels, kubevirtfolderviewkubevirtiov1alpha1.FoldersLabel)
+				delete(namespace.Annotations, kubevirtfolderviewkubevirtiov1alpha1.FoldersSinceAnnotation)
+				Expect(k8sClient.Patch(ctx, namespace, client.MergeFrom(orig))).To(Succeed())
+			})
+
+			controllerReconciler = &VirtualMachineReconciler{
+				Client:          reconcileClient,
+				Scheme:          k8sClient.Scheme(),
+				WebhooksEnabled: true,
+			}
+		})
+
+		It("should file the VM with a membership it owns", func() {
+			setFoldersSince(time.Now().Add(-time.Hour))
+			vm := newAnnotatedVM("placed")
+			placeVM("placed")
+
+			membership := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "placed"}, membership)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, membership)
+			Expect(membership.Spec.Folder).To(Equal("web"))
+			Expect(membership.OwnerReferences).To(ConsistOf(HaveField("UID", vm.UID)))
+		})
+
+		It("should not file VMs the webhooks did not admit", func() {
+			By("leaving out VMs created before the webhooks watched the namespace")
+			setFoldersSince(time.Now())
+			newAnnotatedVM("unwatched")
+			placeVM("unwatched")
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "unwatched"}, &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			By("leaving out VMs when the webhooks are disabled")
+			setFoldersSince(time.Now().Add(-time.Hour))
+			controllerReconciler.WebhooksEnabled = false
+			newAnnotatedVM("disabled")
+			placeVM("disabled")
+			err = k8sClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "disabled"}, &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
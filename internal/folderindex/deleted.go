@@ -0,0 +1,201 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	"maps"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// Kinds of the members of the index that can be deleted.
+const (
+	NamespaceKind      = "Namespace"
+	VirtualMachineKind = "VirtualMachine"
+)
+
+// removeNamespaceFromClusterFolders takes namespace out of every ClusterFolder
+// and reports whether any folder held it.
+func removeNamespaceFromClusterFolders(root *v1alpha1.FolderIndex, namespace string) bool {
+	changed := false
+	for name, entry := range root.Spec.ClusterFolderEntries {
+		if !slices.Contains(entry.Namespaces, namespace) {
+			continue
+		}
+		entry.Namespaces = Remove(entry.Namespaces, namespace)
+		root.Spec.ClusterFolderEntries[name] = entry
+		changed = true
+	}
+	return changed
+}
+
+// RemoveNamespace takes a deleted namespace out of the index: out of every
+// ClusterFolder, along with the NamespacedFolders of the namespace. It
+// reports whether the index changed.
+func RemoveNamespace(root *v1alpha1.FolderIndex, namespace string) bool {
+	changed := removeNamespaceFromClusterFolders(root, namespace)
+	for key := range root.Spec.NamespacedFolderEntries {
+		if NamespacedFolderNamespace(key) == namespace {
+			delete(root.Spec.NamespacedFolderEntries, key)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// RemoveVirtualMachine takes a VirtualMachine out of every NamespacedFolder
+// and reports whether any folder held it.
+func RemoveVirtualMachine(root *v1alpha1.FolderIndex, namespace string, vm string) bool {
+	changed := false
+	for {
+		key, exists := VirtualMachineParent(root, namespace, vm)
+		if !exists {
+			return changed
+		}
+		entry := root.Spec.NamespacedFolderEntries[key]
+		entry.VirtualMachines = Remove(entry.VirtualMachines, vm)
+		root.Spec.NamespacedFolderEntries[key] = entry
+		changed = true
+	}
+}
+
+// InIndex reports whether the index still files member in a folder.
+func InIndex(root *v1alpha1.FolderIndex, member v1alpha1.DeletedMember) bool {
+	switch member.Kind {
+	case NamespaceKind:
+		_, exists := NamespaceParent(root, member.Name)
+		return exists
+	case VirtualMachineKind:
+		_, exists := VirtualMachineParent(root, member.Namespace, member.Name)
+		return exists
+	}
+	return false
+}
+
+// IsDeletedMember reports whether the index flags the member of kind as
+// deleted.
+func IsDeletedMember(root *v1alpha1.FolderIndex, kind string, namespace string, name string) bool {
+	return slices.ContainsFunc(root.Status.DeletedMembers, func(member v1alpha1.DeletedMember) bool {
+		return member.Kind == kind && member.Namespace == namespace && member.Name == name
+	})
+}
+
+// RemoveDeletedMembers takes the members the index flags as deleted out of
+// their folders, so nothing their folders grant reaches an object later
+// created with the same name. The flags themselves are kept.
+func RemoveDeletedMembers(root *v1alpha1.FolderIndex) {
+	for _, member := range root.Status.DeletedMembers {
+		switch member.Kind {
+		case NamespaceKind:
+			removeNamespaceFromClusterFolders(root, member.Name)
+		case VirtualMachineKind:
+			RemoveVirtualMachine(root, member.Namespace, member.Name)
+		}
+	}
+}
+
+// indexMembers returns the namespaces and VirtualMachines the index files in
+// a folder, each once.
+func indexMembers(root *v1alpha1.FolderIndex) []v1alpha1.ObservedMember {
+	members := []v1alpha1.ObservedMember{}
+	seen := map[string]bool{}
+	addNamespace := func(namespace string) {
+		if seen[namespace] {
+			return
+		}
+		seen[namespace] = true
+		members = append(members, v1alpha1.ObservedMember{Kind: NamespaceKind, Name: namespace})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(root.Spec.ClusterFolderEntries)) {
+		for _, namespace := range root.Spec.ClusterFolderEntries[name].Namespaces {
+			addNamespace(namespace)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(root.Spec.NamespacedFolderEntries)) {
+		namespace := NamespacedFolderNamespace(key)
+		addNamespace(namespace)
+		for _, vm := range root.Spec.NamespacedFolderEntries[key].VirtualMachines {
+			members = append(members, v1alpha1.ObservedMember{Kind: VirtualMachineKind, Namespace: namespace, Name: vm})
+		}
+	}
+	return members
+}
+
+// liveUID returns the UID of the live object of a member, given the UIDs of
+// the live namespaces and of the live VirtualMachines keyed by
+// "namespace/name".
+func liveUID(member v1alpha1.ObservedMember, namespaces map[string]types.UID, vms map[string]types.UID) (types.UID, bool) {
+	if member.Kind == NamespaceKind {
+		uid, exists := namespaces[member.Name]
+		return uid, exists
+	}
+	uid, exists := vms[NamespacedFolderKey(member.Namespace, member.Name)]
+	return uid, exists
+}
+
+// ObservedMembers returns the members of the index that are live, with their
+// UIDs, to be recorded in status.observedMembers.
+func ObservedMembers(root *v1alpha1.FolderIndex, namespaces map[string]types.UID, vms map[string]types.UID) []v1alpha1.ObservedMember {
+	observed := []v1alpha1.ObservedMember{}
+	for _, member := range indexMembers(root) {
+		if uid, exists := liveUID(member, namespaces, vms); exists {
+			member.UID = uid
+			observed = append(observed, member)
+		}
+	}
+	return observed
+}
+
+// MissingMembers returns the members of the index that were seen and then
+// deleted: members recorded in status.observedMembers that are no longer live,
+// or whose live object has another UID. Members that were never seen, such as
+// those filed before they are created, are not returned. Neither are members
+// the index already flags as deleted.
+func MissingMembers(root *v1alpha1.FolderIndex, namespaces map[string]types.UID, vms map[string]types.UID) []v1alpha1.DeletedMember {
+	observed := map[v1alpha1.ObservedMember]types.UID{}
+	for _, member := range root.Status.ObservedMembers {
+		observed[v1alpha1.ObservedMember{Kind: member.Kind, Namespace: member.Namespace, Name: member.Name}] = member.UID
+	}
+
+	missing := []v1alpha1.DeletedMember{}
+	for _, member := range indexMembers(root) {
+		seenUID, seen := observed[member]
+		if !seen || IsDeletedMember(root, member.Kind, member.Namespace, member.Name) {
+			continue
+		}
+		if uid, exists := liveUID(member, namespaces, vms); !exists || uid != seenUID {
+			missing = append(missing, v1alpha1.DeletedMember{Kind: member.Kind, Namespace: member.Namespace, Name: member.Name})
+		}
+	}
+	return missing
+}
+
+// ForgetMembers drops the members from status.observedMembers. It reports
+// whether any of them were recorded.
+func ForgetMembers(root *v1alpha1.FolderIndex, members ...v1alpha1.DeletedMember) bool {
+	before := len(root.Status.ObservedMembers)
+	root.Status.ObservedMembers = slices.DeleteFunc(root.Status.ObservedMembers, func(observed v1alpha1.ObservedMember) bool {
+		return slices.ContainsFunc(members, func(member v1alpha1.DeletedMember) bool {
+			return member.Kind == observed.Kind && member.Namespace == observed.Namespace && member.Name == observed.Name
+		})
+	})
+	return len(root.Status.ObservedMembers) != before
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("Deleted members", func() {
+	newRoot := func() *v1alpha1.FolderIndex {
+		return &v1alpha1.FolderIndex{
+			Spec: v1alpha1.FolderIndexSpec{
+				ClusterFolderEntries: map[string]v1alpha1.ClusterFolderEntry{
+					"operations": {ChildFolders: []string{"production"}, Namespaces: []string{"infra"}},
+					"production": {Namespaces: []string{"prod-web-apps", "prod-db"}},
+				},
+				NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{
+					"prod-web-apps/web": {
+						ChildFolders:    []string{"prod-web-apps/db"},
+						VirtualMachines: []string{"web-app-a", "web-app-b"},
+					},
+					"prod-web-apps/db": {VirtualMachines: []string{"db-a"}},
+					"prod-db/primary":  {VirtualMachines: []string{"db-a"}},
+				},
+			},
+		}
+	}
+
+	It("should remove a deleted namespace and its NamespacedFolders", func() {
+		root := newRoot()
+		Expect(RemoveNamespace(root, "prod-web-apps")).To(BeTrue())
+		Expect(root.Spec.ClusterFolderEntries["production"].Namespaces).To(Equal([]string{"prod-db"}))
+		Expect(root.Spec.NamespacedFolderEntries).To(HaveLen(1))
+		Expect(root.Spec.NamespacedFolderEntries).To(HaveKey("prod-db/primary"))
+
+		Expect(RemoveNamespace(root, "prod-web-apps")).To(BeFalse())
+		Expect(RemoveNamespace(root, "default")).To(BeFalse())
+	})
+
+	It("should remove a deleted VM from its namespace only", func() {
+		root := newRoot()
+		Expect(RemoveVirtualMachine(root, "prod-web-apps", "db-a")).To(BeTrue())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/db"].VirtualMachines).To(BeEmpty())
+		Expect(root.Spec.NamespacedFolderEntries["prod-db/primary"].VirtualMachines).To(Equal([]string{"db-a"}))
+
+		Expect(RemoveVirtualMachine(root, "prod-web-apps", "db-a")).To(BeFalse())
+	})
+
+	It("should strip flagged members from their folders", func() {
+		root := newRoot()
+		root.Status.DeletedMembers = []v1alpha1.DeletedMember{
+			{Kind: NamespaceKind, Name: "infra"},
+			{Kind: VirtualMachineKind, Namespace: "prod-web-apps", Name: "web-app-a"},
+		}
+
+		Expect(IsDeletedMember(root, VirtualMachineKind, "prod-web-apps", "web-app-a")).To(BeTrue())
+		Expect(IsDeletedMember(root, VirtualMachineKind, "prod-web-apps", "web-app-b")).To(BeFalse())
+		Expect(IsDeletedMember(root, NamespaceKind, "", "infra")).To(BeTrue())
+		Expect(InIndex(root, root.Status.DeletedMembers[0])).To(BeTrue())
+		Expect(InIndex(root, root.Status.DeletedMembers[1])).To(BeTrue())
+
+		RemoveDeletedMembers(root)
+		Expect(root.Spec.ClusterFolderEntries["operations"].Namespaces).To(BeEmpty())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/web"].VirtualMachines).To(Equal([]string{"web-app-b"}))
+		Expect(InIndex(root, root.Status.DeletedMembers[0])).To(BeFalse())
+		Expect(InIndex(root, root.Status.DeletedMembers[1])).To(BeFalse())
+	})
+
+	It("should find members that were seen and then deleted", func() {
+		root := newRoot()
+		root.Status.DeletedMembers = []v1alpha1.DeletedMember{
+			{Kind: VirtualMachineKind, Namespace: "prod-web-apps", Name: "web-app-b"},
+		}
+		root.Status.ObservedMembers = []v1alpha1.ObservedMember{
+			{Kind: NamespaceKind, Name: "infra", UID: "infra"},
+			{Kind: NamespaceKind, Name: "prod-web-apps", UID: "prod-web-apps"},
+			{Kind: NamespaceKind, Name: "prod-db", UID: "prod-db"},
+			{Kind: VirtualMachineKind, Namespace: "prod-web-apps", Name: "db-a", UID: "db-a-old"},
+			{Kind: VirtualMachineKind, Namespace: "prod-web-apps", Name: "web-app-a", UID: "web-app-a"},
+			{Kind: VirtualMachineKind, Namespace: "prod-web-apps", Name: "web-app-b", UID: "web-app-b"},
+		}
+		namespaces := map[string]types.UID{"infra": "infra", "prod-web-apps": "prod-web-apps"}
+		vms := map[string]types.UID{"prod-web-apps/db-a": "db-a-new"}
+
+		// prod-db/db-a was never seen, so it was filed before it was created.
+		Expect(MissingMembers(root, namespaces, vms)).To(Equal([]v1alpha1.DeletedMember{
+			{Kind: NamespaceKind, Name: "prod-db"},
+			{Kind: VirtualMachineKind, Namespace: "prod-web-apps", Name: "db-a"},
+			{Kind: VirtualMachineKind, Namespace: "prod-web-apps", Name: "web-app-a"},
+		}))
+	})
+
+	It("should record the members that are live", func() {
+		root := newRoot()
+		namespaces := map[string]types.UID{"infra": "infra", "prod-web-apps": "prod-web-apps"}
+		vms := map[string]types.UID{"prod-web-apps/db-a": "db-a", "prod-web-apps/other": "other"}
+
+		Expect(ObservedMembers(root, namespaces, vms)).To(Equal([]v1alpha1.ObservedMember{
+			{Kind: NamespaceKind, Name: "infra", UID: "infra"},
+			{Kind: NamespaceKind, Name: "prod-web-apps", UID: "prod-web-apps"},
+			{Kind: VirtualMachineKind, Namespace: "prod-web-apps", Name: "db-a", UID: "db-a"},
+		}))
+
+		root.Status.ObservedMembers = ObservedMembers(root, namespaces, vms)
+		Expect(ForgetMembers(root, v1alpha1.DeletedMember{Kind: NamespaceKind, Name: "infra"})).To(BeTrue())
+		Expect(ForgetMembers(root, v1alpha1.DeletedMember{Kind: NamespaceKind, Name: "prod-db"})).To(BeFalse())
+		Expect(root.Status.ObservedMembers).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,245 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package folderindex contains helpers for navigating the parent and child
+// relationships stored in the root FolderIndex.
+package folderindex
+
+import (
+	"fmt"
+	"slices"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	v1beta1 "github.com/davidvossel/kubevirt-folder-view/api/v1beta1"
+)
+
+// RootName is the name of the single FolderIndex the controllers and CLI operate on.
+const RootName = "root"
+
+// NamespacedFolderKey returns the "namespace/name" key a NamespacedFolder is
+// referenced by in NamespacedFolderEntries and ChildFolders.
+func NamespacedFolderKey(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// SplitNamespacedFolderKey splits a "namespace/name" key into its namespace and name.
+func SplitNamespacedFolderKey(key string) (string, string, error) {
+	ref, err := v1beta1.ParseNamespacedFolderReference(key)
+	if err != nil {
+		return "", "", err
+	}
+	return ref.Namespace, ref.Name, nil
+}
+
+// NamespacedFolderNamespace returns the namespace of a "namespace/name" key,
+// or "" when the key is malformed.
+func NamespacedFolderNamespace(key string) string {
+	ref, err := v1beta1.ParseNamespacedFolderReference(key)
+	if err != nil {
+		return ""
+	}
+	return ref.Namespace
+}
+
+// ClusterFolderParent returns the ClusterFolder that lists folder as a child folder.
+func ClusterFolderParent(root *v1alpha1.FolderIndex, folder string) (string, bool) {
+	for parent, entry := range root.Spec.ClusterFolderEntries {
+		if slices.Contains(entry.ChildFolders, folder) {
+			return parent, true
+		}
+	}
+	return "", false
+}
+
+// NamespaceParent returns the ClusterFolder that lists namespace as a member.
+func NamespaceParent(root *v1alpha1.FolderIndex, namespace string) (string, bool) {
+	for parent, entry := range root.Spec.ClusterFolderEntries {
+		if slices.Contains(entry.Namespaces, namespace) {
+			return parent, true
+		}
+	}
+	return "", false
+}
+
+// NamespacedFolderParent returns the key of the NamespacedFolder that lists
+// the folder key as a child folder.
+func NamespacedFolderParent(root *v1alpha1.FolderIndex, key string) (string, bool) {
+	for parent, entry := range root.Spec.NamespacedFolderEntries {
+		if slices.Contains(entry.ChildFolders, key) {
+			return parent, true
+		}
+	}
+	return "", false
+}
+
+// VirtualMachineParent returns the key of the NamespacedFolder that lists the
+// VirtualMachine as a member.
+func VirtualMachineParent(root *v1alpha1.FolderIndex, namespace string, vm string) (string, bool) {
+	for parent, entry := range root.Spec.NamespacedFolderEntries {
+		ns, _, err := SplitNamespacedFolderKey(parent)
+		if err != nil || ns != namespace {
+			continue
+		}
+		if slices.Contains(entry.VirtualMachines, vm) {
+			return parent, true
+		}
+	}
+	return "", false
+}
+
+// Remove returns a copy of list without any occurrences of item.
+func Remove(list []string, item string) []string {
+	newList := []string{}
+	for _, s := range list {
+		if s != item {
+			newList = append(newList, s)
+		}
+	}
+	return newList
+}
+
+// GetAllNamespaces returns the namespaces of a ClusterFolder and of all of
+// its descendant ClusterFolders.
+func GetAllNamespaces(root *v1alpha1.FolderIndex, folder string) []string {
+	namespaces := []string{}
+	visited := map[string]bool{}
+
+	var walk func(folder string)
+	walk = func(folder string) {
+		if visited[folder] {
+			return
+		}
+		visited[folder] = true
+
+		entry, exists := root.Spec.ClusterFolderEntries[folder]
+		if !exists {
+			return
+		}
+		namespaces = append(namespaces, entry.Namespaces...)
+		for _, child := range entry.ChildFolders {
+			walk(child)
+		}
+	}
+	walk(folder)
+
+	return namespaces
+}
+
+// GetAllVMs returns the VirtualMachines of a NamespacedFolder and of all of
+// its descendant NamespacedFolders. The folder is given by its
+// "namespace/name" key.
+func GetAllVMs(root *v1alpha1.FolderIndex, key string) []string {
+	vms := []string{}
+	visited := map[string]bool{}
+
+	var walk func(key string)
+	walk = func(key string) {
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+
+		entry, exists := root.Spec.NamespacedFolderEntries[key]
+		if !exists {
+			return
+		}
+		vms = append(vms, entry.VirtualMachines...)
+		for _, child := range entry.ChildFolders {
+			walk(child)
+		}
+	}
+	walk(key)
+
+	return vms
+}
+
+// NamespacedFolderTree returns the folder key followed by the keys of all of
+// its descendant NamespacedFolders.
+func NamespacedFolderTree(root *v1alpha1.FolderIndex, key string) []string {
+	keys := []string{}
+	visited := map[string]bool{}
+
+	var walk func(key string)
+	walk = func(key string) {
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+
+		keys = append(keys, key)
+		for _, child := range root.Spec.NamespacedFolderEntries[key].ChildFolders {
+			walk(child)
+		}
+	}
+	walk(key)
+
+	return keys
+}
+
+// AddMemberships files the VirtualMachine of each FolderMembership into the
+// NamespacedFolder it names. A membership takes precedence over the index, so
+// the VirtualMachine is first taken out of any folder the index files it in.
+// Memberships naming a folder that is not in the index are ignored.
+func AddMemberships(root *v1alpha1.FolderIndex, memberships []v1alpha1.FolderMembership) {
+	for _, membership := range memberships {
+		for {
+			oldFolder, exists := VirtualMachineParent(root, membership.Namespace, membership.Name)
+			if !exists {
+				break
+			}
+			entry := root.Spec.NamespacedFolderEntries[oldFolder]
+			entry.VirtualMachines = Remove(entry.VirtualMachines, membership.Name)
+			root.Spec.NamespacedFolderEntries[oldFolder] = entry
+		}
+	}
+	for _, membership := range memberships {
+		key := NamespacedFolderKey(membership.Namespace, membership.Spec.Folder)
+		entry, exists := root.Spec.NamespacedFolderEntries[key]
+		if !exists {
+			continue
+		}
+		entry.VirtualMachines = append(entry.VirtualMachines, membership.Name)
+		root.Spec.NamespacedFolderEntries[key] = entry
+	}
+}
+
+// ClusterFolderAncestry returns folder followed by each of its ancestors,
+// ending with the root ClusterFolder of its tree.
+func ClusterFolderAncestry(root *v1alpha1.FolderIndex, folder string) []string {
+	ancestry := []string{folder}
+	for {
+		parent, exists := ClusterFolderParent(root, folder)
+		if !exists || slices.Contains(ancestry, parent) {
+			return ancestry
+		}
+		ancestry = append(ancestry, parent)
+		folder = parent
+	}
+}
+
+// NamespacedFolderAncestry returns the folder key followed by the keys of each
+// of its ancestors, ending with the top level NamespacedFolder in its namespace.
+func NamespacedFolderAncestry(root *v1alpha1.FolderIndex, key string) []string {
+	ancestry := []string{key}
+	for {
+		parent, exists := NamespacedFolderParent(root, key)
+		if !exists || slices.Contains(ancestry, parent) {
+			return ancestry
+		}
+		ancestry = append(ancestry, parent)
+		key = parent
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("FolderIndex helpers", func() {
+	root := &v1alpha1.FolderIndex{
+		Spec: v1alpha1.FolderIndexSpec{
+			ClusterFolderEntries: map[string]v1alpha1.ClusterFolderEntry{
+				"infra-admins": {ChildFolders: []string{"operations"}, Namespaces: []string{"infra"}},
+				"operations":   {ChildFolders: []string{"production", "staging"}},
+				"production":   {Namespaces: []string{"prod-web-apps"}},
+				"staging":      {Namespaces: []string{"staging-web-apps"}},
+			},
+			NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{
+				"prod-web-apps/prod-web-app-a": {
+					ChildFolders:    []string{"prod-web-apps/db"},
+					VirtualMachines: []string{"web-app-a"},
+				},
+				"prod-web-apps/db": {VirtualMachines: []string{"web-app-a-db"}},
+			},
+		},
+	}
+
+	It("should collect namespaces of a folder and all of its descendants", func() {
+		Expect(GetAllNamespaces(root, "infra-admins")).To(ConsistOf("infra", "prod-web-apps", "staging-web-apps"))
+		Expect(GetAllNamespaces(root, "production")).To(ConsistOf("prod-web-apps"))
+		Expect(GetAllNamespaces(root, "missing")).To(BeEmpty())
+	})
+
+	It("should collect VMs of a folder and all of its descendants", func() {
+		Expect(GetAllVMs(root, "prod-web-apps/prod-web-app-a")).To(ConsistOf("web-app-a", "web-app-a-db"))
+		Expect(GetAllVMs(root, "prod-web-apps/db")).To(ConsistOf("web-app-a-db"))
+	})
+
+	It("should collect a folder and all of its descendants", func() {
+		Expect(NamespacedFolderTree(root, "prod-web-apps/prod-web-app-a")).To(Equal([]string{"prod-web-apps/prod-web-app-a", "prod-web-apps/db"}))
+		Expect(NamespacedFolderTree(root, "prod-web-apps/missing")).To(Equal([]string{"prod-web-apps/missing"}))
+	})
+
+	It("should file memberships over the VMs of the index", func() {
+		merged := root.DeepCopy()
+		AddMemberships(merged, []v1alpha1.FolderMembership{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-a-db"}, Spec: v1alpha1.FolderMembershipSpec{Folder: "prod-web-app-a"}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-c"}, Spec: v1alpha1.FolderMembershipSpec{Folder: "db"}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "staging-web-apps", Name: "web-app-a"}, Spec: v1alpha1.FolderMembershipSpec{Folder: "staging-web-app-a"}},
+		})
+		Expect(merged.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].VirtualMachines).To(Equal([]string{"web-app-a", "web-app-a-db"}))
+		Expect(merged.Spec.NamespacedFolderEntries["prod-web-apps/db"].VirtualMachines).To(Equal([]string{"web-app-c"}))
+		Expect(merged.Spec.NamespacedFolderEntries).NotTo(HaveKey("staging-web-apps/staging-web-app-a"))
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/db"].VirtualMachines).To(Equal([]string{"web-app-a-db"}))
+	})
+
+	It("should resolve parents and ancestry", func() {
+		Expect(ClusterFolderAncestry(root, "production")).To(Equal([]string{"production", "operations", "infra-admins"}))
+		Expect(NamespacedFolderAncestry(root, "prod-web-apps/db")).To(Equal([]string{"prod-web-apps/db", "prod-web-apps/prod-web-app-a"}))
+
+		parent, exists := NamespaceParent(root, "staging-web-apps")
+		Expect(exists).To(BeTrue())
+		Expect(parent).To(Equal("staging"))
+
+		parent, exists = VirtualMachineParent(root, "prod-web-apps", "web-app-a-db")
+		Expect(exists).To(BeTrue())
+		Expect(parent).To(Equal("prod-web-apps/db"))
+
+		_, exists = VirtualMachineParent(root, "staging-web-apps", "web-app-a-db")
+		Expect(exists).To(BeFalse())
+	})
+
+	It("should split namespaced folder keys", func() {
+		namespace, name, err := SplitNamespacedFolderKey("prod-web-apps/prod-web-app-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(namespace).To(Equal("prod-web-apps"))
+		Expect(name).To(Equal("prod-web-app-a"))
+
+		_, _, err = SplitNamespacedFolderKey("prod-web-app-a")
+		Expect(err).To(HaveOccurred())
+
+		Expect(NamespacedFolderNamespace("prod-web-apps/prod-web-app-a")).To(Equal("prod-web-apps"))
+		Expect(NamespacedFolderNamespace("prod-web-app-a")).To(BeEmpty())
+	})
+})
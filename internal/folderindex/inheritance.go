@@ -0,0 +1,121 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// Permissions returns the permissions of a folder that reach its members.
+// All of them reach the direct members of the folder, while the members of
+// its descendants only inherit the ones not excluded from children.
+func Permissions(permissions []v1alpha1.FolderPermission, inherited bool) []v1alpha1.FolderPermission {
+	if !inherited {
+		return permissions
+	}
+	reached := []v1alpha1.FolderPermission{}
+	for _, permission := range permissions {
+		if !permission.ExcludeFromChildren {
+			reached = append(reached, permission)
+		}
+	}
+	return reached
+}
+
+// InheritedAncestry returns the folders of ancestry whose permissions reach
+// the members of its first folder. ancestry is ordered nearest first, as
+// ClusterFolderAncestry and NamespacedFolderAncestry order it, and is cut
+// after the first folder in blocked, which no permissions of its ancestors
+// reach.
+func InheritedAncestry(ancestry []string, blocked map[string]bool) []string {
+	for i, folder := range ancestry {
+		if blocked[folder] {
+			return ancestry[:i+1]
+		}
+	}
+	return ancestry
+}
+
+// InheritingClusterFolders returns the descendant ClusterFolders that inherit
+// the permissions of folder. Folders in blocked and their descendants do not.
+func InheritingClusterFolders(root *v1alpha1.FolderIndex, folder string, blocked map[string]bool) []string {
+	folders := []string{}
+	visited := map[string]bool{folder: true}
+
+	var walk func(folder string)
+	walk = func(folder string) {
+		for _, child := range root.Spec.ClusterFolderEntries[folder].ChildFolders {
+			if visited[child] || blocked[child] {
+				continue
+			}
+			visited[child] = true
+			folders = append(folders, child)
+			walk(child)
+		}
+	}
+	walk(folder)
+
+	return folders
+}
+
+// InheritingNamespacedFolders returns the keys of the descendant
+// NamespacedFolders that inherit the permissions of the folder key. Folders
+// in blocked and their descendants do not.
+func InheritingNamespacedFolders(root *v1alpha1.FolderIndex, key string, blocked map[string]bool) []string {
+	keys := []string{}
+	visited := map[string]bool{key: true}
+
+	var walk func(key string)
+	walk = func(key string) {
+		for _, child := range root.Spec.NamespacedFolderEntries[key].ChildFolders {
+			if visited[child] || blocked[child] {
+				continue
+			}
+			visited[child] = true
+			keys = append(keys, child)
+			walk(child)
+		}
+	}
+	walk(key)
+
+	return keys
+}
+
+// ClusterFolderNamespaces returns the namespaces the permissions of a
+// ClusterFolder reach: the namespaces it holds directly, which all of its
+// permissions reach, and the namespaces of the descendants that inherit its
+// permissions.
+func ClusterFolderNamespaces(root *v1alpha1.FolderIndex, folder string, blocked map[string]bool) ([]string, []string) {
+	direct := append([]string{}, root.Spec.ClusterFolderEntries[folder].Namespaces...)
+	inherited := []string{}
+	for _, child := range InheritingClusterFolders(root, folder, blocked) {
+		inherited = append(inherited, root.Spec.ClusterFolderEntries[child].Namespaces...)
+	}
+	return direct, inherited
+}
+
+// NamespacedFolderVirtualMachines returns the VirtualMachines the index files
+// in a NamespacedFolder, which all of its permissions reach, and those it
+// files in the descendants that inherit the permissions of the folder.
+func NamespacedFolderVirtualMachines(root *v1alpha1.FolderIndex, key string, blocked map[string]bool) ([]string, []string) {
+	direct := append([]string{}, root.Spec.NamespacedFolderEntries[key].VirtualMachines...)
+	inherited := []string{}
+	for _, child := range InheritingNamespacedFolders(root, key, blocked) {
+		inherited = append(inherited, root.Spec.NamespacedFolderEntries[child].VirtualMachines...)
+	}
+	return direct, inherited
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("Folder inheritance", func() {
+	root := &v1alpha1.FolderIndex{
+		Spec: v1alpha1.FolderIndexSpec{
+			ClusterFolderEntries: map[string]v1alpha1.ClusterFolderEntry{
+				"operations": {ChildFolders: []string{"production", "staging"}, Namespaces: []string{"infra"}},
+				"production": {ChildFolders: []string{"restricted"}, Namespaces: []string{"prod-web-apps"}},
+				"restricted": {Namespaces: []string{"prod-secrets"}},
+				"staging":    {Namespaces: []string{"staging-web-apps"}},
+			},
+			NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{
+				"prod-web-apps/prod-web-app-b": {
+					ChildFolders:    []string{"prod-web-apps/temp-folder-debug", "prod-web-apps/db"},
+					VirtualMachines: []string{"web-app-b"},
+				},
+				"prod-web-apps/temp-folder-debug": {VirtualMachines: []string{"web-app-b-debug"}},
+				"prod-web-apps/db":                {VirtualMachines: []string{"web-app-b-db"}},
+			},
+		},
+	}
+
+	It("should leave permissions excluded from children to the folder itself", func() {
+		permissions := []v1alpha1.FolderPermission{
+			{Subject: rbacv1.Subject{Kind: "Group", Name: "operation-team"}},
+			{Subject: rbacv1.Subject{Kind: "User", Name: "steve"}, ExcludeFromChildren: true},
+		}
+		Expect(Permissions(permissions, false)).To(Equal(permissions))
+		Expect(Permissions(permissions, true)).To(Equal(permissions[:1]))
+	})
+
+	It("should stop inheritance at blocking folders", func() {
+		blocked := map[string]bool{"restricted": true, "prod-web-apps/temp-folder-debug": true}
+
+		Expect(InheritedAncestry(ClusterFolderAncestry(root, "restricted"), blocked)).To(Equal([]string{"restricted"}))
+		Expect(InheritedAncestry(ClusterFolderAncestry(root, "production"), blocked)).To(Equal([]string{"production", "operations"}))
+		Expect(InheritedAncestry([]string{"staging", "operations"}, map[string]bool{"operations": true})).To(Equal([]string{"staging", "operations"}))
+
+		Expect(InheritingClusterFolders(root, "operations", blocked)).To(Equal([]string{"production", "staging"}))
+		Expect(InheritingClusterFolders(root, "operations", nil)).To(Equal([]string{"production", "restricted", "staging"}))
+		Expect(InheritingNamespacedFolders(root, "prod-web-apps/prod-web-app-b", blocked)).To(Equal([]string{"prod-web-apps/db"}))
+	})
+
+	It("should return the members the permissions of a folder reach", func() {
+		blocked := map[string]bool{"restricted": true, "prod-web-apps/temp-folder-debug": true}
+
+		direct, inherited := ClusterFolderNamespaces(root, "operations", blocked)
+		Expect(direct).To(Equal([]string{"infra"}))
+		Expect(inherited).To(Equal([]string{"prod-web-apps", "staging-web-apps"}))
+
+		direct, inherited = ClusterFolderNamespaces(root, "restricted", blocked)
+		Expect(direct).To(Equal([]string{"prod-secrets"}))
+		Expect(inherited).To(BeEmpty())
+
+		direct, inherited = NamespacedFolderVirtualMachines(root, "prod-web-apps/prod-web-app-b", blocked)
+		Expect(direct).To(Equal([]string{"web-app-b"}))
+		Expect(inherited).To(Equal([]string{"web-app-b-db"}))
+	})
+})
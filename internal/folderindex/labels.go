@@ -0,0 +1,96 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// TagLabels returns the labels the propagated tags of a folder and of its
+// ancestors put on the members of the folder. The metadata is ordered as
+// ClusterFolderAncestry and NamespacedFolderAncestry order folders, nearest
+// first, and the tag of the nearest folder wins. Tags whose value is not a
+// valid label value are skipped.
+func TagLabels(ancestry []v1alpha1.FolderMetadata) map[string]string {
+	labels := map[string]string{}
+	for i := len(ancestry) - 1; i >= 0; i-- {
+		metadata := ancestry[i]
+		for _, key := range metadata.PropagatedTags {
+			value, exists := metadata.Tags[key]
+			if !exists || len(validation.IsValidLabelValue(value)) != 0 {
+				continue
+			}
+			labels[v1alpha1.TagLabelPrefix+key] = value
+		}
+	}
+	return labels
+}
+
+// NamespaceLabels returns the labels that place namespace in the folder
+// tree: the FoldersLabel, when the namespace is in a ClusterFolder or holds
+// NamespacedFolders, and the ClusterFolder that holds it and the path down to
+// that folder. Other namespaces get no labels, and values that are not valid
+// label values, such as paths longer than 63 characters, are left out.
+func NamespaceLabels(root *v1alpha1.FolderIndex, namespace string) map[string]string {
+	labels := map[string]string{}
+	for key := range root.Spec.NamespacedFolderEntries {
+		if folderNamespace, _, err := SplitNamespacedFolderKey(key); err == nil && folderNamespace == namespace {
+			labels[v1alpha1.FoldersLabel] = "true"
+			break
+		}
+	}
+
+	parent, exists := NamespaceParent(root, namespace)
+	if !exists {
+		return labels
+	}
+
+	labels[v1alpha1.FoldersLabel] = "true"
+	if len(validation.IsValidLabelValue(parent)) == 0 {
+		labels[v1alpha1.ClusterFolderLabel] = parent
+	}
+	if path := strings.Join(clusterFolderSegments(root, parent), "."); len(validation.IsValidLabelValue(path)) == 0 {
+		labels[v1alpha1.FolderPathLabel] = path
+	}
+	return labels
+}
+
+// VirtualMachineLabels returns the labels that place a VirtualMachine in the
+// NamespacedFolder key.
+func VirtualMachineLabels(key string) map[string]string {
+	labels := map[string]string{}
+	_, name, err := SplitNamespacedFolderKey(key)
+	if err != nil || len(validation.IsValidLabelValue(name)) != 0 {
+		return labels
+	}
+	labels[v1alpha1.FolderLabel] = name
+	return labels
+}
+
+// IsFolderLabel reports whether key is one of the labels folders put on
+// their members.
+func IsFolderLabel(key string) bool {
+	switch key {
+	case v1alpha1.ClusterFolderLabel, v1alpha1.FolderPathLabel, v1alpha1.FolderLabel, v1alpha1.FoldersLabel:
+		return true
+	}
+	return strings.HasPrefix(key, v1alpha1.TagLabelPrefix)
+}
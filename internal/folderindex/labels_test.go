@@ -0,0 +1,77 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("Folder labels", func() {
+	It("should propagate the tags of the nearest folder", func() {
+		production := v1alpha1.FolderMetadata{
+			Tags:           map[string]string{"env": "production", "owner": "Web Team"},
+			PropagatedTags: []string{"env", "owner"},
+		}
+		operations := v1alpha1.FolderMetadata{
+			Tags:           map[string]string{"env": "ops", "cost-center": "cc-42", "owner": "ops", "tier": "gold"},
+			PropagatedTags: []string{"env", "cost-center", "owner", "missing"},
+		}
+
+		Expect(TagLabels([]v1alpha1.FolderMetadata{production, operations})).To(Equal(map[string]string{
+			"tag.folderview.kubevirt.io/env":         "production",
+			"tag.folderview.kubevirt.io/cost-center": "cc-42",
+			"tag.folderview.kubevirt.io/owner":       "ops",
+		}))
+		Expect(TagLabels(nil)).To(BeEmpty())
+	})
+
+	It("should place namespaces and VMs in the folder tree", func() {
+		root := &v1alpha1.FolderIndex{
+			Spec: v1alpha1.FolderIndexSpec{
+				ClusterFolderEntries: map[string]v1alpha1.ClusterFolderEntry{
+					"infra-admins": {ChildFolders: []string{"operations"}},
+					"operations":   {ChildFolders: []string{"production"}},
+					"production":   {Namespaces: []string{"prod-web-apps"}},
+				},
+				NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{
+					"dev-web-apps/web": {VirtualMachines: []string{"web-app-a"}},
+				},
+			},
+		}
+
+		Expect(NamespaceLabels(root, "prod-web-apps")).To(Equal(map[string]string{
+			"folderview.kubevirt.io/folders":        "true",
+			"folderview.kubevirt.io/cluster-folder": "production",
+			"folderview.kubevirt.io/path":           "infra-admins.operations.production",
+		}))
+		Expect(NamespaceLabels(root, "dev-web-apps")).To(Equal(map[string]string{
+			"folderview.kubevirt.io/folders": "true",
+		}))
+		Expect(NamespaceLabels(root, "default")).To(BeEmpty())
+		Expect(VirtualMachineLabels("prod-web-apps/prod-web-app-a")).To(Equal(map[string]string{
+			"folderview.kubevirt.io/folder": "prod-web-app-a",
+		}))
+
+		Expect(IsFolderLabel("folderview.kubevirt.io/path")).To(BeTrue())
+		Expect(IsFolderLabel("folderview.kubevirt.io/folders")).To(BeTrue())
+		Expect(IsFolderLabel("tag.folderview.kubevirt.io/env")).To(BeTrue())
+		Expect(IsFolderLabel("folderview.kubevirt.io/other")).To(BeFalse())
+	})
+})
@@ -0,0 +1,212 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// Kinds of the entries a path addresses.
+const (
+	KindTop              = ""
+	KindClusterFolder    = "ClusterFolder"
+	KindNamespace        = "Namespace"
+	KindNamespacedFolder = "NamespacedFolder"
+	KindVirtualMachine   = "VirtualMachine"
+)
+
+// PathEntry is a node of the folder hierarchy when it is viewed as a
+// filesystem. ClusterFolders hold ClusterFolders and namespaces, a namespace
+// holds its top level NamespacedFolders, and NamespacedFolders hold
+// NamespacedFolders and VirtualMachines.
+type PathEntry struct {
+	Kind string
+	// Name is the ClusterFolder or namespace name, or the namespace/name key
+	// of a NamespacedFolder or VirtualMachine. It is empty for the top.
+	Name string
+}
+
+// Base returns the path segment that names the entry within its parent.
+func (e PathEntry) Base() string {
+	if e.Kind == KindNamespacedFolder || e.Kind == KindVirtualMachine {
+		if _, name, err := SplitNamespacedFolderKey(e.Name); err == nil {
+			return name
+		}
+	}
+	return e.Name
+}
+
+// PathChildren returns the entries the index places directly below entry,
+// folders first, each kind sorted by name. The top holds the ClusterFolders
+// without a parent and the namespaces with NamespacedFolders that are not in
+// any ClusterFolder. VirtualMachines that are not in a NamespacedFolder are
+// not part of the index and are not returned.
+func PathChildren(root *v1alpha1.FolderIndex, entry PathEntry) []PathEntry {
+	children := []PathEntry{}
+	add := func(kind string, names []string) {
+		sort.Strings(names)
+		for _, name := range names {
+			children = append(children, PathEntry{Kind: kind, Name: name})
+		}
+	}
+
+	switch entry.Kind {
+	case KindTop:
+		folders := []string{}
+		for name := range root.Spec.ClusterFolderEntries {
+			if _, exists := ClusterFolderParent(root, name); !exists {
+				folders = append(folders, name)
+			}
+		}
+		add(KindClusterFolder, folders)
+
+		namespaces := []string{}
+		for key := range root.Spec.NamespacedFolderEntries {
+			namespace, _, err := SplitNamespacedFolderKey(key)
+			if err != nil || slices.Contains(namespaces, namespace) {
+				continue
+			}
+			if _, exists := NamespaceParent(root, namespace); !exists {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+		add(KindNamespace, namespaces)
+	case KindClusterFolder:
+		clusterEntry := root.Spec.ClusterFolderEntries[entry.Name]
+		add(KindClusterFolder, slices.Clone(clusterEntry.ChildFolders))
+		add(KindNamespace, slices.Clone(clusterEntry.Namespaces))
+	case KindNamespace:
+		folders := []string{}
+		for key := range root.Spec.NamespacedFolderEntries {
+			namespace, _, err := SplitNamespacedFolderKey(key)
+			if err != nil || namespace != entry.Name {
+				continue
+			}
+			if _, exists := NamespacedFolderParent(root, key); !exists {
+				folders = append(folders, key)
+			}
+		}
+		add(KindNamespacedFolder, folders)
+	case KindNamespacedFolder:
+		namespacedEntry := root.Spec.NamespacedFolderEntries[entry.Name]
+		namespace := NamespacedFolderNamespace(entry.Name)
+		add(KindNamespacedFolder, slices.Clone(namespacedEntry.ChildFolders))
+
+		vms := []string{}
+		for _, vm := range namespacedEntry.VirtualMachines {
+			vms = append(vms, NamespacedFolderKey(namespace, vm))
+		}
+		add(KindVirtualMachine, vms)
+	}
+
+	return children
+}
+
+// SplitPath returns the segments of a slash separated path, ignoring empty
+// segments so that "/a//b/" and "a/b" are the same path.
+func SplitPath(path string) []string {
+	segments := []string{}
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// ResolvePath returns the entry a path such as
+// /infra-admins/operations/production/prod-web-apps/prod-web-app-a names.
+// Each segment is looked up among the PathChildren of the entry before it.
+// When a folder and a namespace or VirtualMachine share a name, the folder
+// is chosen.
+func ResolvePath(root *v1alpha1.FolderIndex, path string) (PathEntry, error) {
+	entry := PathEntry{Kind: KindTop}
+
+	for i, segment := range SplitPath(path) {
+		found := false
+		for _, child := range PathChildren(root, entry) {
+			if child.Base() == segment {
+				entry = child
+				found = true
+				break
+			}
+		}
+		if !found {
+			return PathEntry{}, fmt.Errorf("path [/%s] does not exist in the folder index",
+				strings.Join(SplitPath(path)[:i+1], "/"))
+		}
+	}
+
+	return entry, nil
+}
+
+// EntryPath returns the absolute path of an entry, the inverse of
+// ResolvePath. ClusterFolders and namespaces without a parent are at the top.
+// A VirtualMachine that is not in a NamespacedFolder is placed directly below
+// its namespace.
+func EntryPath(root *v1alpha1.FolderIndex, entry PathEntry) string {
+	segments := []string{}
+
+	switch entry.Kind {
+	case KindClusterFolder:
+		segments = clusterFolderSegments(root, entry.Name)
+	case KindNamespace:
+		segments = namespaceSegments(root, entry.Name)
+	case KindNamespacedFolder:
+		namespace := NamespacedFolderNamespace(entry.Name)
+		segments = append(namespaceSegments(root, namespace), namespacedFolderSegments(root, entry.Name)...)
+	case KindVirtualMachine:
+		namespace, vm, _ := SplitNamespacedFolderKey(entry.Name)
+		if parent, exists := VirtualMachineParent(root, namespace, vm); exists {
+			segments = append(namespaceSegments(root, namespace), namespacedFolderSegments(root, parent)...)
+		} else {
+			segments = namespaceSegments(root, namespace)
+		}
+		segments = append(segments, vm)
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+func clusterFolderSegments(root *v1alpha1.FolderIndex, folder string) []string {
+	segments := ClusterFolderAncestry(root, folder)
+	slices.Reverse(segments)
+	return segments
+}
+
+func namespaceSegments(root *v1alpha1.FolderIndex, namespace string) []string {
+	segments := []string{}
+	if parent, exists := NamespaceParent(root, namespace); exists {
+		segments = clusterFolderSegments(root, parent)
+	}
+	return append(segments, namespace)
+}
+
+func namespacedFolderSegments(root *v1alpha1.FolderIndex, key string) []string {
+	segments := []string{}
+	ancestry := NamespacedFolderAncestry(root, key)
+	slices.Reverse(ancestry)
+	for _, ancestor := range ancestry {
+		segments = append(segments, PathEntry{Kind: KindNamespacedFolder, Name: ancestor}.Base())
+	}
+	return segments
+}
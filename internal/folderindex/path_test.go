@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package folderindex
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("FolderIndex paths", func() {
+	root := &v1alpha1.FolderIndex{
+		Spec: v1alpha1.FolderIndexSpec{
+			ClusterFolderEntries: map[string]v1alpha1.ClusterFolderEntry{
+				"infra-admins": {ChildFolders: []string{"operations"}},
+				"operations":   {ChildFolders: []string{"production"}},
+				"production":   {Namespaces: []string{"prod-web-apps"}},
+			},
+			NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{
+				"prod-web-apps/prod-web-app-a": {
+					ChildFolders:    []string{"prod-web-apps/db"},
+					VirtualMachines: []string{"web-app-a"},
+				},
+				"prod-web-apps/db": {VirtualMachines: []string{"web-app-a-db"}},
+				"dev/sandbox":      {VirtualMachines: []string{"scratch"}},
+			},
+		},
+	}
+
+	It("should list the children of each layer", func() {
+		Expect(PathChildren(root, PathEntry{Kind: KindTop})).To(Equal([]PathEntry{
+			{Kind: KindClusterFolder, Name: "infra-admins"},
+			{Kind: KindNamespace, Name: "dev"},
+		}))
+		Expect(PathChildren(root, PathEntry{Kind: KindClusterFolder, Name: "production"})).To(Equal([]PathEntry{
+			{Kind: KindNamespace, Name: "prod-web-apps"},
+		}))
+		Expect(PathChildren(root, PathEntry{Kind: KindNamespace, Name: "prod-web-apps"})).To(Equal([]PathEntry{
+			{Kind: KindNamespacedFolder, Name: "prod-web-apps/prod-web-app-a"},
+		}))
+		Expect(PathChildren(root, PathEntry{Kind: KindNamespacedFolder, Name: "prod-web-apps/prod-web-app-a"})).To(Equal([]PathEntry{
+			{Kind: KindNamespacedFolder, Name: "prod-web-apps/db"},
+			{Kind: KindVirtualMachine, Name: "prod-web-apps/web-app-a"},
+		}))
+	})
+
+	It("should resolve paths across the cluster and namespaced layers", func() {
+		entry, err := ResolvePath(root, "/infra-admins/operations/production/prod-web-apps/prod-web-app-a/db/web-app-a-db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry).To(Equal(PathEntry{Kind: KindVirtualMachine, Name: "prod-web-apps/web-app-a-db"}))
+
+		entry, err = ResolvePath(root, "dev//sandbox/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry).To(Equal(PathEntry{Kind: KindNamespacedFolder, Name: "dev/sandbox"}))
+
+		entry, err = ResolvePath(root, "/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry).To(Equal(PathEntry{Kind: KindTop}))
+
+		_, err = ResolvePath(root, "/infra-admins/staging/x")
+		Expect(err).To(MatchError("path [/infra-admins/staging] does not exist in the folder index"))
+	})
+
+	It("should return the path of an entry", func() {
+		Expect(EntryPath(root, PathEntry{Kind: KindVirtualMachine, Name: "prod-web-apps/web-app-a-db"})).To(
+			Equal("/infra-admins/operations/production/prod-web-apps/prod-web-app-a/db/web-app-a-db"))
+		Expect(EntryPath(root, PathEntry{Kind: KindVirtualMachine, Name: "prod-web-apps/unfiled"})).To(
+			Equal("/infra-admins/operations/production/prod-web-apps/unfiled"))
+		Expect(EntryPath(root, PathEntry{Kind: KindNamespacedFolder, Name: "dev/sandbox"})).To(Equal("/dev/sandbox"))
+		Expect(EntryPath(root, PathEntry{Kind: KindClusterFolder, Name: "operations"})).To(Equal("/infra-admins/operations"))
+	})
+})
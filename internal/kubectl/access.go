@@ -0,0 +1,268 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// accessVerbs are the verbs and KubeVirt actions reported for each target.
+var accessVerbs = []string{
+	"get", "list", "watch", "create", "update", "patch", "delete",
+	"start", "stop", "restart", "migrate", "console", "vnc", "pause", "unpause", "softreboot",
+}
+
+// accessEntry is the access a folder grant gives a subject on one target,
+// either a whole namespace or a single VirtualMachine.
+type accessEntry struct {
+	grant  folderGrant
+	target string
+	verbs  []string
+}
+
+func allowedVerbs(rules []rbacv1.PolicyRule, name string) []string {
+	verbs := []string{}
+	for _, verb := range accessVerbs {
+		if rulesAllow(rules, vmAccessFor(verb), name) {
+			verbs = append(verbs, verb)
+		}
+	}
+	return verbs
+}
+
+func subjectMatches(subjects []rbacv1.Subject, subject rbacv1.Subject) bool {
+	for _, s := range subjects {
+		if s.Kind == subject.Kind && s.Name == subject.Name && s.Namespace == subject.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func folderHasSubject(permissions []v1alpha1.FolderPermission, subjects []rbacv1.Subject) bool {
+	for _, fp := range permissions {
+		if subjectMatches(subjects, fp.Subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectAccess returns the access each of the subjects holds through folder
+// permissions, resolved with the same code the folder controllers use.
+func subjectAccess(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, subjects []rbacv1.Subject) ([]accessEntry, error) {
+	entries := []accessEntry{}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := cl.List(ctx, namespaceList); err != nil {
+		return entries, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+	existingNamespaces := map[string]bool{}
+	for _, ns := range namespaceList.Items {
+		existingNamespaces[ns.Name] = true
+	}
+
+	clusterFolders := &v1alpha1.ClusterFolderList{}
+	if err := cl.List(ctx, clusterFolders); err != nil {
+		return entries, fmt.Errorf("failed to list cluster folders: %v", err)
+	}
+	sort.Slice(clusterFolders.Items, func(i, j int) bool {
+		return clusterFolders.Items[i].Name < clusterFolders.Items[j].Name
+	})
+	blocked, err := controller.BlockedClusterFolders(ctx, cl)
+	if err != nil {
+		return entries, err
+	}
+
+	for i := range clusterFolders.Items {
+		folder := &clusterFolders.Items[i]
+		if !folderHasSubject(folder.Spec.FolderPermissions, subjects) {
+			continue
+		}
+
+		directNamespaces, inheritedNamespaces := folderindex.ClusterFolderNamespaces(root, folder.Name, blocked)
+		reached := false
+		for i, ns := range append(directNamespaces, inheritedNamespaces...) {
+			if !existingNamespaces[ns] {
+				continue
+			}
+			grants, err := resolveClusterFolderGrants(ctx, cl, folder, ns, i >= len(directNamespaces))
+			if err != nil {
+				return entries, err
+			}
+			for _, grant := range grants {
+				if !subjectMatches(subjects, grant.subject) {
+					continue
+				}
+				reached = true
+				entries = append(entries, accessEntry{
+					grant:  grant,
+					target: fmt.Sprintf("Namespace/%s", ns),
+					verbs:  allowedVerbs(grant.rules, ""),
+				})
+			}
+		}
+		if !reached {
+			entries = append(entries, accessEntry{
+				grant: folderGrant{folder: fmt.Sprintf("ClusterFolder/%s", folder.Name)},
+			})
+		}
+	}
+
+	namespacedFolders := &v1alpha1.NamespacedFolderList{}
+	if err := cl.List(ctx, namespacedFolders); err != nil {
+		return entries, fmt.Errorf("failed to list namespaced folders: %v", err)
+	}
+	sort.Slice(namespacedFolders.Items, func(i, j int) bool {
+		a, b := namespacedFolders.Items[i], namespacedFolders.Items[j]
+		return folderindex.NamespacedFolderKey(a.Namespace, a.Name) < folderindex.NamespacedFolderKey(b.Namespace, b.Name)
+	})
+
+	for i := range namespacedFolders.Items {
+		folder := &namespacedFolders.Items[i]
+		if !folderHasSubject(folder.Spec.FolderPermissions, subjects) {
+			continue
+		}
+
+		grants, err := resolveNamespacedFolderGrants(ctx, cl, root, folder)
+		if err != nil {
+			return entries, err
+		}
+
+		reached := false
+		for _, grant := range grants {
+			if !subjectMatches(subjects, grant.subject) {
+				continue
+			}
+			for _, vm := range grant.vms {
+				reached = true
+				entries = append(entries, accessEntry{
+					grant:  grant,
+					target: fmt.Sprintf("VM/%s/%s", folder.Namespace, vm),
+					verbs:  allowedVerbs(grant.rules, vm),
+				})
+			}
+		}
+		if !reached {
+			entries = append(entries, accessEntry{
+				grant: folderGrant{folder: fmt.Sprintf("NamespacedFolder/%s", folderindex.NamespacedFolderKey(folder.Namespace, folder.Name))},
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+func printAccess(out io.Writer, entries []accessEntry) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FOLDER\tSUBJECT\tROLEREF\tTARGET\tVERBS")
+	for _, entry := range entries {
+		subject, roleRef, target, verbs := "-", "-", "-", "-"
+		if entry.target != "" {
+			subject = formatSubject(entry.grant.subject)
+			roleRef = formatRoleRef(entry.grant.roleRef)
+			target = entry.target
+		}
+		if len(entry.verbs) != 0 {
+			verbs = strings.Join(entry.verbs, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.grant.folder, subject, roleRef, target, verbs)
+	}
+	w.Flush()
+}
+
+func newAccessCmd() *cobra.Command {
+	var user string
+	var groups []string
+	var serviceAccount string
+
+	cmd := &cobra.Command{
+		Use:   "access",
+		Short: "List the folders, namespaces and VirtualMachines a subject can access",
+		Long: `List the folders, namespaces and VirtualMachines a subject can access.
+
+Every folder whose permissions name the subject is listed, together with each
+namespace (for ClusterFolders) or VirtualMachine (for NamespacedFolders) the
+permission reaches through the folder hierarchy, and the verbs and KubeVirt
+actions it allows there.
+
+Access is resolved into the same Roles and RoleBindings the folder
+controllers generate. A folder listed without a target names the subject, but
+currently grants nothing, for instance because it is empty or its role has no
+KubeVirt rules.
+
+--subject-user, --subject-group and --subject-serviceaccount may be combined
+to report the access of a user together with the groups they belong to.`,
+		Example: `  kubectl folder access --subject-user steve
+  kubectl folder access --subject-user steve --subject-group dev-team-a
+  kubectl folder access --subject-serviceaccount prod-web-apps/deployer`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			subjects := []rbacv1.Subject{}
+			if user != "" {
+				subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: user})
+			}
+			for _, group := range groups {
+				subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: group})
+			}
+			if serviceAccount != "" {
+				namespace, name, err := folderindex.SplitNamespacedFolderKey(serviceAccount)
+				if err != nil {
+					return fmt.Errorf("invalid serviceaccount [%s], expected the format namespace/name", serviceAccount)
+				}
+				subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name})
+			}
+			if len(subjects) == 0 {
+				return fmt.Errorf("at least one of --subject-user, --subject-group or --subject-serviceaccount is required")
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			root, err := getFolderView(ctx, cl)
+			if err != nil {
+				return err
+			}
+
+			entries, err := subjectAccess(ctx, cl, root, subjects)
+			if err != nil {
+				return fmt.Errorf("failed to resolve folder permissions: %v", err)
+			}
+
+			if len(entries) == 0 {
+				names := []string{}
+				for _, subject := range subjects {
+					names = append(names, formatSubject(subject))
+				}
+				slices.Sort(names)
+				fmt.Fprintf(cmd.OutOrStdout(), "No folder permissions found for [%s]\n", strings.Join(names, ", "))
+				return nil
+			}
+			printAccess(cmd.OutOrStdout(), entries)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&user, "subject-user", "", "Name of the user to report access for")
+	cmd.Flags().StringSliceVar(&groups, "subject-group", nil, "Name of a group to report access for, may be repeated")
+	cmd.Flags().StringVar(&serviceAccount, "subject-serviceaccount", "", "ServiceAccount to report access for, in the format namespace/name")
+
+	return cmd
+}
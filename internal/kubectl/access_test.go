@@ -0,0 +1,49 @@
+package kubectl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("access", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+	var root *v1alpha1.FolderIndex
+
+	BeforeEach(func() {
+		cl, root = newFolderFixture()
+	})
+
+	It("should list the namespaces reached through a ClusterFolder", func() {
+		subjects := []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "operation-team"}}
+		entries, err := subjectAccess(ctx, cl, root, subjects)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].grant.folder).To(Equal("ClusterFolder/operations"))
+		Expect(entries[0].target).To(Equal("Namespace/prod-web-apps"))
+		Expect(entries[0].verbs).To(ContainElements("delete", "start", "console"))
+	})
+
+	It("should list the VMs reached through a NamespacedFolder", func() {
+		subjects := []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "dev-team-a"}}
+		entries, err := subjectAccess(ctx, cl, root, subjects)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].target).To(Equal("VM/prod-web-apps/web-app-a"))
+		Expect(entries[0].verbs).To(Equal([]string{"get", "list", "watch"}))
+	})
+
+	It("should report nothing for subjects without folder permissions", func() {
+		subjects := []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "steve"}}
+		entries, err := subjectAccess(ctx, cl, root, subjects)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+})
@@ -0,0 +1,119 @@
+package kubectl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("browse", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+	var b *browser
+
+	BeforeEach(func() {
+		cl, _ = newFolderFixture()
+		for _, name := range []string{"web-app-a", "web-app-b", "scratch"} {
+			Expect(cl.Create(ctx, &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: name},
+			})).To(Succeed())
+		}
+
+		var err error
+		b, err = newBrowser(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	rowKeys := func() []string {
+		keys := []string{}
+		for _, row := range b.rows {
+			keys = append(keys, row.key())
+		}
+		return keys
+	}
+
+	press := func(keys ...string) {
+		for _, key := range keys {
+			Expect(b.handleKey(ctx, key)).To(BeFalse())
+		}
+	}
+
+	getRoot := func() *v1alpha1.FolderIndex {
+		root := &v1alpha1.FolderIndex{}
+		Expect(cl.Get(ctx, client.ObjectKey{Name: "root"}, root)).To(Succeed())
+		return root
+	}
+
+	It("should expand and collapse folders", func() {
+		Expect(rowKeys()).To(Equal([]string{"ClusterFolder/operations"}))
+
+		press("right", "down", "right")
+		Expect(rowKeys()).To(Equal([]string{
+			"ClusterFolder/operations",
+			"ClusterFolder/production",
+			"Namespace/prod-web-apps",
+		}))
+
+		press("left")
+		Expect(rowKeys()).To(HaveLen(2))
+		press("left")
+		Expect(b.cursor).To(Equal(0))
+		Expect(b.handleKey(ctx, "q")).To(BeTrue())
+	})
+
+	It("should reveal a VM found by search", func() {
+		press("/", "a", "p", "p", "-", "b", "enter")
+		Expect(b.rows[b.cursor].key()).To(Equal("VM/prod-web-apps/web-app-b"))
+		Expect(rowKeys()).To(ContainElement("NamespacedFolder/prod-web-apps/prod-web-app-b"))
+
+		press("n")
+		Expect(b.rows[b.cursor].key()).To(Equal("VM/prod-web-apps/web-app-b"))
+
+		press("/", "x", "enter")
+		Expect(b.status).To(Equal("no vm matches [x]"))
+	})
+
+	It("should move a marked VM into the selected folder", func() {
+		press("/", "s", "c", "r", "enter", "m")
+		Expect(b.selectRow("NamespacedFolder/prod-web-apps/prod-web-app-a")).To(BeTrue())
+		press("p")
+
+		Expect(b.status).To(Equal("moved VM: [scratch] into NamespacedFolder: [prod-web-app-a]"))
+		Expect(b.rows[b.cursor].key()).To(Equal("VM/prod-web-apps/scratch"))
+		Expect(getRoot().Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].VirtualMachines).To(ConsistOf("web-app-a", "scratch"))
+
+		press("u")
+		Expect(getRoot().Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].VirtualMachines).To(ConsistOf("web-app-a"))
+	})
+
+	It("should refuse invalid moves", func() {
+		press("m", "right", "down")
+		press("p")
+		Expect(b.status).To(ContainSubstring("cannot move cluster folder [operations] into itself or one of its descendants"))
+
+		press("m", "right", "down", "p")
+		Expect(b.status).To(Equal("cannot move ClusterFolder: [production] into Namespace: [prod-web-apps]"))
+		Expect(getRoot().Spec.ClusterFolderEntries["operations"].ChildFolders).To(ConsistOf("production"))
+	})
+
+	It("should show permissions and effective access", func() {
+		Expect(b.details(ctx, b.rows[0])).To(ContainElement("  Group/operation-team: ClusterRole/admin"))
+
+		press("/", "w", "e", "b", "-", "a", "p", "p", "-", "a", "enter")
+		Expect(b.details(ctx, b.rows[b.cursor])).To(ContainElements(
+			"  Group/dev-team-a: get,list,watch (from NamespacedFolder/prod-web-apps/prod-web-app-a)",
+		))
+
+		out := b.render(ctx, 160, 10)
+		Expect(out).To(ContainSubstring("▾ ClusterFolder: [operations]"))
+		Expect(out).To(ContainSubstring("Effective access:"))
+		Expect(out).To(ContainSubstring("/ search vm"))
+	})
+})
@@ -0,0 +1,405 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// currentUser asks the API server who the credentials in use, including any
+// impersonation, belong to.
+func currentUser(ctx context.Context, cl client.Client) (authenticationv1.UserInfo, error) {
+	review := &authenticationv1.SelfSubjectReview{}
+	if err := cl.Create(ctx, review); err != nil {
+		return authenticationv1.UserInfo{}, fmt.Errorf("failed to determine the current user: %v", err)
+	}
+	return review.Status.UserInfo, nil
+}
+
+// userSubjects returns the RBAC subjects folder permissions can name to
+// reach the user: the user, each of their groups and, for ServiceAccount
+// tokens, the ServiceAccount.
+func userSubjects(info authenticationv1.UserInfo) []rbacv1.Subject {
+	subjects := []rbacv1.Subject{}
+	if namespace, name, ok := splitServiceAccountUsername(info.Username); ok {
+		subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name})
+	} else if info.Username != "" {
+		subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: info.Username})
+	}
+	for _, group := range info.Groups {
+		subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: group})
+	}
+	return subjects
+}
+
+// splitServiceAccountUsername returns the namespace and name of a
+// ServiceAccount username, system:serviceaccount:NAMESPACE:NAME.
+func splitServiceAccountUsername(username string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(username, "system:serviceaccount:")
+	if !ok {
+		return "", "", false
+	}
+	namespace, name, ok := strings.Cut(rest, ":")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, ":") {
+		return "", "", false
+	}
+	return namespace, name, true
+}
+
+// reviewAccess asks the API server whether the current user is allowed the
+// access on the VirtualMachine.
+func reviewAccess(ctx context.Context, cl client.Client, access vmAccess, namespace string, vm string) (bool, error) {
+	resource, subresource, _ := strings.Cut(access.resource, "/")
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        access.verb,
+				Group:       access.apiGroup,
+				Resource:    resource,
+				Subresource: subresource,
+				Name:        vm,
+			},
+		},
+	}
+	if err := cl.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("failed to review access: %v", err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// canIExplanation relates the answer of an access review to the folder
+// permissions of the user.
+type canIExplanation struct {
+	// granting are the folder grants to the user that allow the access
+	granting []folderGrant
+	// lacking are the folder grants to the user on the VirtualMachine whose
+	// filtered roles do not allow the access
+	lacking []folderGrant
+	// folders holds the VirtualMachine, nearest first. Adding the user to
+	// any of them with a suitable role grants the access.
+	folders []string
+}
+
+// explainCanI resolves the folder grants on the VirtualMachine the same way
+// the folder controllers do and sorts the ones reaching the user by whether
+// they allow the access.
+func explainCanI(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, subjects []rbacv1.Subject, access vmAccess, namespace string, vm string) (*canIExplanation, error) {
+	explanation := &canIExplanation{}
+
+	grants, err := resolveVMGrants(ctx, cl, root, namespace, vm)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		if !subjectMatches(subjects, grant.subject) {
+			continue
+		}
+		if rulesAllow(grant.rules, access, vm) {
+			explanation.granting = append(explanation.granting, grant)
+		} else {
+			explanation.lacking = append(explanation.lacking, grant)
+		}
+	}
+
+	if parent, exists := folderindex.VirtualMachineParent(root, namespace, vm); exists {
+		for _, key := range folderindex.NamespacedFolderAncestry(root, parent) {
+			folderNamespace, folderName, err := folderindex.SplitNamespacedFolderKey(key)
+			if err != nil {
+				return nil, err
+			}
+			exists, err := objectExists(ctx, cl, client.ObjectKey{Namespace: folderNamespace, Name: folderName}, &v1alpha1.NamespacedFolder{})
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				explanation.folders = append(explanation.folders, "NamespacedFolder/"+key)
+			}
+		}
+	}
+	if parent, exists := folderindex.NamespaceParent(root, namespace); exists {
+		for _, name := range folderindex.ClusterFolderAncestry(root, parent) {
+			exists, err := objectExists(ctx, cl, client.ObjectKey{Name: name}, &v1alpha1.ClusterFolder{})
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				explanation.folders = append(explanation.folders, "ClusterFolder/"+name)
+			}
+		}
+	}
+
+	return explanation, nil
+}
+
+func objectExists(ctx context.Context, cl client.Reader, key client.ObjectKey, obj client.Object) (bool, error) {
+	if err := cl.Get(ctx, key, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func printCanI(w io.Writer, allowed bool, explanation *canIExplanation, access vmAccess, namespace string, vm string) {
+	if allowed {
+		fmt.Fprintln(w, "yes")
+	} else {
+		fmt.Fprintln(w, "no")
+	}
+
+	for _, grant := range explanation.granting {
+		if allowed {
+			fmt.Fprintf(w, "  granted by %s to %s with %s\n", grant.folder, formatSubject(grant.subject), formatRoleRef(grant.roleRef))
+		} else {
+			fmt.Fprintf(w, "  %s grants it to %s with %s, but RoleBinding [%s] is not in effect, see kubectl folder describe\n",
+				grant.folder, formatSubject(grant.subject), formatRoleRef(grant.roleRef), grant.roleBinding)
+		}
+	}
+	if allowed {
+		if len(explanation.granting) == 0 {
+			fmt.Fprintln(w, "  not granted by a folder, the access comes from RBAC outside of folders")
+		}
+		return
+	}
+
+	for _, grant := range explanation.lacking {
+		fmt.Fprintf(w, "  %s grants %s %s, which does not allow %s\n",
+			grant.folder, formatSubject(grant.subject), formatRoleRef(grant.roleRef), access)
+	}
+	if len(explanation.granting) != 0 {
+		return
+	}
+	if len(explanation.folders) == 0 {
+		fmt.Fprintf(w, "  vm [%s/%s] is in no folder, it has to be filed into a NamespacedFolder or its namespace into a ClusterFolder that grants access\n", namespace, vm)
+		return
+	}
+	fmt.Fprintf(w, "  to be allowed, be added with a role that allows %s to one of:\n", access)
+	for _, folder := range explanation.folders {
+		fmt.Fprintf(w, "    %s\n", folder)
+	}
+}
+
+// reviewRules asks the API server for the rules the current user holds in
+// the namespace. The rules are incomplete when the server could not
+// evaluate all of them.
+func reviewRules(ctx context.Context, cl client.Client, namespace string) ([]rbacv1.PolicyRule, bool, error) {
+	review := &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}
+	if err := cl.Create(ctx, review); err != nil {
+		return nil, false, fmt.Errorf("failed to review rules: %v", err)
+	}
+
+	rules := []rbacv1.PolicyRule{}
+	for _, rule := range review.Status.ResourceRules {
+		rules = append(rules, rbacv1.PolicyRule{
+			Verbs:         rule.Verbs,
+			APIGroups:     rule.APIGroups,
+			Resources:     rule.Resources,
+			ResourceNames: rule.ResourceNames,
+		})
+	}
+	return rules, review.Status.Incomplete, nil
+}
+
+// canIListEntry is the access the user has on one VirtualMachine, and the
+// folder grants that contribute to it.
+type canIListEntry struct {
+	vm     string
+	verbs  []string
+	grants []string
+}
+
+// listCanI evaluates the rules of the user for every VirtualMachine of the
+// namespace, in the cluster or in the index, and attributes them to folder
+// grants.
+func listCanI(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, subjects []rbacv1.Subject, namespace string, rules []rbacv1.PolicyRule) ([]canIListEntry, error) {
+	vms, err := existingVMs(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for key := range vms {
+		vmNamespace, vm, _ := folderindex.SplitNamespacedFolderKey(key)
+		if vmNamespace == namespace {
+			names[vm] = true
+		}
+	}
+	for key, entry := range root.Spec.NamespacedFolderEntries {
+		if folderindex.NamespacedFolderNamespace(key) != namespace {
+			continue
+		}
+		for _, vm := range entry.VirtualMachines {
+			names[vm] = true
+		}
+	}
+
+	entries := []canIListEntry{}
+	for vm := range names {
+		entry := canIListEntry{vm: vm, verbs: allowedVerbs(rules, vm)}
+
+		grants, err := resolveVMGrants(ctx, cl, root, namespace, vm)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range grants {
+			if !subjectMatches(subjects, grant.subject) {
+				continue
+			}
+			verbs := allowedVerbs(grant.rules, vm)
+			if len(verbs) == 0 {
+				continue
+			}
+			entry.grants = append(entry.grants, fmt.Sprintf("%s (%s: %s)", grant.folder, formatSubject(grant.subject), strings.Join(verbs, ",")))
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].vm < entries[j].vm
+	})
+	return entries, nil
+}
+
+func printCanIList(w io.Writer, entries []canIListEntry, incomplete bool) {
+	if incomplete {
+		fmt.Fprintln(w, "warning: the API server could not evaluate all rules, the list may be incomplete")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VM\tVERBS\tFOLDER GRANTS")
+	for _, entry := range entries {
+		verbs, grants := "-", "-"
+		if len(entry.verbs) != 0 {
+			verbs = strings.Join(entry.verbs, ",")
+		}
+		if len(entry.grants) != 0 {
+			grants = strings.Join(entry.grants, ", ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", entry.vm, verbs, grants)
+	}
+	tw.Flush()
+}
+
+func completeCanIArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if list, _ := cmd.Flags().GetBool("list"); list {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeWhoCanArgs(cmd, args, toComplete)
+}
+
+func newCanICmd() *cobra.Command {
+	var list bool
+	var quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "can-i (VERB vm NAMESPACE/NAME | --list)",
+		Short: "Check whether you can act on a VirtualMachine and which folder decides it",
+		Long: `Check whether you can act on a VirtualMachine and which folder decides it.
+
+The API server answers the question with a SelfSubjectAccessReview, for the
+credentials in use including --as. The answer is then explained in terms of
+folders: the folder grants to you, or to one of your groups, that allow the
+action, those whose roles do not allow it after the folder controllers
+reduce them to VirtualMachine rules, and the folders holding the
+VirtualMachine you could be added to.
+
+VERB is the same as for who-can. The command exits with status 1 when the
+answer is no.
+
+--list asks for all your rules in the namespace with a
+SelfSubjectRulesReview and shows the verbs they allow on each VirtualMachine,
+along with the folder grants that contribute to them.`,
+		Example: `  kubectl folder can-i start vm prod-web-apps/web-app-a
+  kubectl folder can-i --list -n prod-web-apps
+  kubectl folder can-i console vm prod-web-apps/web-app-a --as steve`,
+		ValidArgsFunction: completeCanIArgs,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if list {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(3)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			root, err := getFolderView(ctx, cl)
+			if err != nil {
+				return err
+			}
+
+			info, err := currentUser(ctx, cl)
+			if err != nil {
+				return err
+			}
+			subjects := userSubjects(info)
+
+			if list {
+				namespace, err := contextNamespace()
+				if err != nil {
+					return err
+				}
+
+				rules, incomplete, err := reviewRules(ctx, cl, namespace)
+				if err != nil {
+					return err
+				}
+				entries, err := listCanI(ctx, cl, root, subjects, namespace, rules)
+				if err != nil {
+					return fmt.Errorf("failed to resolve folder permissions: %v", err)
+				}
+				printCanIList(os.Stdout, entries, incomplete)
+				return nil
+			}
+
+			access := vmAccessFor(args[0])
+			namespace, vm, err := parseVMArgs(args[1], args[2])
+			if err != nil {
+				return err
+			}
+
+			allowed, err := reviewAccess(ctx, cl, access, namespace, vm)
+			if err != nil {
+				return err
+			}
+
+			if !quiet {
+				explanation, err := explainCanI(ctx, cl, root, subjects, access, namespace, vm)
+				if err != nil {
+					return fmt.Errorf("failed to resolve folder permissions: %v", err)
+				}
+				printCanI(os.Stdout, allowed, explanation, access, namespace, vm)
+			}
+			if !allowed {
+				return errSilentExit
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "List the verbs allowed on every VirtualMachine of the namespace")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print nothing, only exit with status 1 when the answer is no")
+
+	return cmd
+}
@@ -0,0 +1,87 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("can-i", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+	var root *v1alpha1.FolderIndex
+
+	BeforeEach(func() {
+		cl, root = newFolderFixture()
+	})
+
+	devTeamA := userSubjects(authenticationv1.UserInfo{Username: "steve", Groups: []string{"dev-team-a", "system:authenticated"}})
+
+	explain := func(subjects []rbacv1.Subject, verb string, allowed bool) string {
+		access := vmAccessFor(verb)
+		explanation, err := explainCanI(ctx, cl, root, subjects, access, "prod-web-apps", "web-app-a")
+		Expect(err).NotTo(HaveOccurred())
+		var out bytes.Buffer
+		printCanI(&out, allowed, explanation, access, "prod-web-apps", "web-app-a")
+		return out.String()
+	}
+
+	It("should map users, groups and service accounts to subjects", func() {
+		Expect(devTeamA).To(ConsistOf(
+			rbacv1.Subject{Kind: "User", APIGroup: rbacv1.GroupName, Name: "steve"},
+			rbacv1.Subject{Kind: "Group", APIGroup: rbacv1.GroupName, Name: "dev-team-a"},
+			rbacv1.Subject{Kind: "Group", APIGroup: rbacv1.GroupName, Name: "system:authenticated"},
+		))
+		Expect(userSubjects(authenticationv1.UserInfo{Username: "system:serviceaccount:prod-web-apps:deployer"})).To(Equal([]rbacv1.Subject{
+			{Kind: "ServiceAccount", Namespace: "prod-web-apps", Name: "deployer"},
+		}))
+	})
+
+	It("should name the folder grant that allows the access", func() {
+		Expect(explain(devTeamA, "get", true)).To(Equal(
+			"yes\n  granted by NamespacedFolder/prod-web-apps/prod-web-app-a to Group/dev-team-a with ClusterRole/view\n"))
+	})
+
+	It("should explain a denial with the filtered role and the folders to be added to", func() {
+		Expect(explain(devTeamA, "start", false)).To(Equal(`no
+  NamespacedFolder/prod-web-apps/prod-web-app-a grants Group/dev-team-a ClusterRole/view, which does not allow update virtualmachines/start.subresources.kubevirt.io
+  to be allowed, be added with a role that allows update virtualmachines/start.subresources.kubevirt.io to one of:
+    NamespacedFolder/prod-web-apps/prod-web-app-a
+    ClusterFolder/operations
+`))
+	})
+
+	It("should point out folder grants that are not in effect and access from outside folders", func() {
+		operators := []rbacv1.Subject{{Kind: "Group", Name: "operation-team"}}
+		Expect(explain(operators, "start", false)).To(ContainSubstring(
+			"ClusterFolder/operations grants it to Group/operation-team with ClusterRole/admin, but RoleBinding"))
+		Expect(explain(nil, "get", true)).To(Equal(
+			"yes\n  not granted by a folder, the access comes from RBAC outside of folders\n"))
+	})
+
+	It("should attribute the reviewed rules to folder grants for every VM", func() {
+		rules := []rbacv1.PolicyRule{{
+			Verbs:         []string{"get", "list", "watch"},
+			APIGroups:     []string{"kubevirt.io"},
+			Resources:     []string{"virtualmachines"},
+			ResourceNames: []string{"web-app-a"},
+		}}
+		entries, err := listCanI(ctx, cl, root, devTeamA, "prod-web-apps", rules)
+		Expect(err).NotTo(HaveOccurred())
+
+		var out bytes.Buffer
+		printCanIList(&out, entries, false)
+		Expect(out.String()).To(Equal(`VM         VERBS           FOLDER GRANTS
+web-app-a  get,list,watch  NamespacedFolder/prod-web-apps/prod-web-app-a (Group/dev-team-a: get,list,watch)
+web-app-b  -               -
+`))
+	})
+})
@@ -0,0 +1,138 @@
+package kubectl
+
+import (
+	"context"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// Completions are read from the live cluster. Any error, such as a missing
+// kubeconfig or index, results in no suggestions rather than a failure.
+
+// folderCompletions returns the ClusterFolders in the index, or the names
+// of the NamespacedFolders in namespace when it is set.
+func folderCompletions(ctx context.Context, cl client.Reader, namespace string) []string {
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return nil
+	}
+
+	names := []string{}
+	if namespace == "" {
+		for name := range root.Spec.ClusterFolderEntries {
+			names = append(names, name)
+		}
+	} else {
+		for key := range root.Spec.NamespacedFolderEntries {
+			ns, name, err := folderindex.SplitNamespacedFolderKey(key)
+			if err == nil && ns == namespace {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// namespaceCompletions returns the namespaces in the cluster.
+func namespaceCompletions(ctx context.Context, cl client.Reader) []string {
+	namespaceList := &corev1.NamespaceList{}
+	if err := cl.List(ctx, namespaceList); err != nil {
+		return nil
+	}
+
+	names := []string{}
+	for _, ns := range namespaceList.Items {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clusterRoleCompletions returns the ClusterRoles in the cluster.
+func clusterRoleCompletions(ctx context.Context, cl client.Reader) []string {
+	clusterRoleList := &rbacv1.ClusterRoleList{}
+	if err := cl.List(ctx, clusterRoleList); err != nil {
+		return nil
+	}
+
+	names := []string{}
+	for _, role := range clusterRoleList.Items {
+		names = append(names, role.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// vmCompletions returns the namespace/name of the VirtualMachines in the
+// cluster and those filed in the index.
+func vmCompletions(ctx context.Context, cl client.Reader) []string {
+	vms := map[string]struct{}{}
+
+	vmList := &virtv1.VirtualMachineList{}
+	if err := cl.List(ctx, vmList); err == nil {
+		for _, vm := range vmList.Items {
+			vms[folderindex.NamespacedFolderKey(vm.Namespace, vm.Name)] = struct{}{}
+		}
+	}
+	if root, err := getFolderView(ctx, cl); err == nil {
+		for key, entry := range root.Spec.NamespacedFolderEntries {
+			namespace := folderindex.NamespacedFolderNamespace(key)
+			for _, vm := range entry.VirtualMachines {
+				vms[folderindex.NamespacedFolderKey(namespace, vm)] = struct{}{}
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range vms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeWith runs a completion against a client for the cluster selected
+// by the kubectl flags on the command line being completed.
+func completeWith(complete func(ctx context.Context, cl client.Reader) []string) ([]string, cobra.ShellCompDirective) {
+	cl, err := newClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return complete(context.Background(), cl), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeNamespaceFlag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeWith(namespaceCompletions)
+}
+
+// completeFolderNames completes a folder name, a NamespacedFolder when
+// --namespace is given and a ClusterFolder otherwise.
+func completeFolderNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	namespace := namespaceFlag(cmd)
+	return completeWith(func(ctx context.Context, cl client.Reader) []string {
+		return folderCompletions(ctx, cl, namespace)
+	})
+}
+
+// completeFolderArg completes the single folder argument of a command.
+func completeFolderArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeFolderNames(cmd, args, toComplete)
+}
+
+// completeFixed completes a flag with a fixed set of values.
+func completeFixed(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
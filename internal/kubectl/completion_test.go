@@ -0,0 +1,37 @@
+package kubectl
+
+import (
+	"context"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("completion", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+
+	BeforeEach(func() {
+		cl, _ = newFolderFixture()
+	})
+
+	It("should complete cluster folders, or namespaced folders of a namespace", func() {
+		Expect(folderCompletions(ctx, cl, "")).To(Equal([]string{"operations", "production"}))
+		Expect(folderCompletions(ctx, cl, "prod-web-apps")).To(Equal([]string{"prod-web-app-a", "prod-web-app-b"}))
+		Expect(folderCompletions(ctx, cl, "missing")).To(BeEmpty())
+	})
+
+	It("should complete namespaces and filed VMs", func() {
+		Expect(namespaceCompletions(ctx, cl)).To(ContainElement("prod-web-apps"))
+		Expect(vmCompletions(ctx, cl)).To(Equal([]string{"prod-web-apps/web-app-a", "prod-web-apps/web-app-b"}))
+	})
+
+	It("should complete who-can verbs once each", func() {
+		verbs := whoCanVerbs()
+		Expect(verbs).To(ContainElements("get", "start", "console"))
+		Expect(slices.Compact(slices.Clone(verbs))).To(Equal(verbs))
+	})
+})
@@ -0,0 +1,582 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// Drift states of the RBAC objects a folder owns.
+const (
+	driftInSync     = "InSync"
+	driftMissing    = "Missing"
+	driftModified   = "Modified"
+	driftUnexpected = "Unexpected"
+)
+
+// rbacDrift compares an RBAC object the controller would generate for a
+// folder with the one found in the cluster. Missing objects have not been
+// created yet, Unexpected objects carry the folder's ownership label but
+// would be deleted by the controller.
+type rbacDrift struct {
+	kind      string
+	namespace string
+	name      string
+	summary   string
+	state     string
+}
+
+// folderDescription is everything describe shows for a single folder.
+type folderDescription struct {
+	kind      string
+	name      string
+	uid       string
+	indexed   bool
+	path      []string
+	metadata  v1alpha1.FolderMetadata
+	members   []string
+	inherited []string
+
+	permissions          []string
+	inheritedPermissions []string
+
+	rbac      []rbacDrift
+	events    []corev1.Event
+	eventsErr error
+}
+
+// describeEventLimit is the number of most recent events describe shows.
+const describeEventLimit = 10
+
+// describeKinds maps the accepted spellings of the kind argument to the
+// folder kind.
+var describeKinds = map[string]string{
+	"clusterfolder":     "ClusterFolder",
+	"clusterfolders":    "ClusterFolder",
+	"namespacedfolder":  "NamespacedFolder",
+	"namespacedfolders": "NamespacedFolder",
+}
+
+func describeClusterFolder(ctx context.Context, cl client.Reader, name string) (*folderDescription, error) {
+	root, err := getFolderView(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &v1alpha1.ClusterFolder{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: name}, folder); err != nil {
+		return nil, fmt.Errorf("failed to get cluster folder [%s]: %v", name, err)
+	}
+
+	d := &folderDescription{
+		kind:     "ClusterFolder",
+		name:     name,
+		uid:      string(folder.UID),
+		metadata: folder.Spec.FolderMetadata,
+	}
+
+	entry, indexed := root.Spec.ClusterFolderEntries[name]
+	d.indexed = indexed
+	d.path = reversed(folderindex.ClusterFolderAncestry(root, name))
+
+	for _, child := range entry.ChildFolders {
+		d.members = append(d.members, "ClusterFolder/"+child)
+	}
+	for _, namespace := range entry.Namespaces {
+		d.members = append(d.members, "Namespace/"+namespace)
+	}
+	for _, child := range entry.ChildFolders {
+		for _, namespace := range folderindex.GetAllNamespaces(root, child) {
+			d.inherited = append(d.inherited, fmt.Sprintf("Namespace/%s (from ClusterFolder/%s)", namespace, child))
+		}
+	}
+
+	blocked, err := controller.BlockedClusterFolders(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	d.permissions = formatPermissions(folder.Spec.FolderPermissions, "")
+	for _, ancestor := range folderindex.InheritedAncestry(folderindex.ClusterFolderAncestry(root, name), blocked)[1:] {
+		d.inheritedPermissions = append(d.inheritedPermissions, clusterFolderPermissions(ctx, cl, ancestor, true)...)
+	}
+
+	expected := []client.Object{}
+	if indexed {
+		namespaces, err := existingNamespaces(ctx, cl)
+		if err != nil {
+			return nil, err
+		}
+		directNamespaces, inheritedNamespaces := folderindex.ClusterFolderNamespaces(root, name, blocked)
+		for i, namespace := range append(directNamespaces, inheritedNamespaces...) {
+			if !namespaces[namespace] {
+				continue
+			}
+			roleBindings, err := controller.ClusterFolderRoleBindings(folder, namespace, i >= len(directNamespaces))
+			if err != nil {
+				return nil, err
+			}
+			for i := range roleBindings {
+				expected = append(expected, &roleBindings[i])
+			}
+		}
+	}
+
+	ownerLabels := client.MatchingLabels{controller.ClusterFolderOwnershipUIDLabel: string(folder.UID)}
+	actual, err := listOwnedRBAC(ctx, cl, ownerLabels, false)
+	if err != nil {
+		return nil, err
+	}
+	d.rbac = compareRBAC(expected, actual)
+
+	d.events, d.eventsErr = folderEvents(ctx, cl, folder)
+	return d, nil
+}
+
+func describeNamespacedFolder(ctx context.Context, cl client.Reader, key string) (*folderDescription, error) {
+	namespace, name, err := folderindex.SplitNamespacedFolderKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespaced folder [%s], expected the format namespace/name", key)
+	}
+
+	root, err := getFolderView(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &v1alpha1.NamespacedFolder{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, folder); err != nil {
+		return nil, fmt.Errorf("failed to get namespaced folder [%s]: %v", key, err)
+	}
+
+	d := &folderDescription{
+		kind:     "NamespacedFolder",
+		name:     key,
+		uid:      string(folder.UID),
+		metadata: folder.Spec.FolderMetadata,
+	}
+
+	entry, indexed := root.Spec.NamespacedFolderEntries[key]
+	d.indexed = indexed
+
+	clusterAncestors := []string{}
+	if parent, ok := folderindex.NamespaceParent(root, namespace); ok {
+		clusterAncestors = folderindex.ClusterFolderAncestry(root, parent)
+	}
+	d.path = reversed(clusterAncestors)
+	d.path = append(d.path, namespace)
+	for _, ancestor := range reversed(folderindex.NamespacedFolderAncestry(root, key)) {
+		_, ancestorName, _ := folderindex.SplitNamespacedFolderKey(ancestor)
+		d.path = append(d.path, ancestorName)
+	}
+
+	for _, child := range entry.ChildFolders {
+		d.members = append(d.members, "NamespacedFolder/"+child)
+	}
+	for _, vm := range entry.VirtualMachines {
+		d.members = append(d.members, "VM/"+folderindex.NamespacedFolderKey(namespace, vm))
+	}
+	for _, child := range entry.ChildFolders {
+		for _, vm := range folderindex.GetAllVMs(root, child) {
+			d.inherited = append(d.inherited, fmt.Sprintf("VM/%s (from NamespacedFolder/%s)", folderindex.NamespacedFolderKey(namespace, vm), child))
+		}
+	}
+
+	blocked, err := controller.BlockedNamespacedFolders(ctx, cl, namespace)
+	if err != nil {
+		return nil, err
+	}
+	blockedClusterFolders, err := controller.BlockedClusterFolders(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	d.permissions = formatPermissions(folder.Spec.FolderPermissions, "")
+	for _, ancestor := range folderindex.InheritedAncestry(folderindex.NamespacedFolderAncestry(root, key), blocked)[1:] {
+		ancestorNamespace, ancestorName, _ := folderindex.SplitNamespacedFolderKey(ancestor)
+		ancestorFolder := &v1alpha1.NamespacedFolder{}
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: ancestorNamespace, Name: ancestorName}, ancestorFolder); err != nil {
+			continue
+		}
+		d.inheritedPermissions = append(d.inheritedPermissions, formatPermissions(folderindex.Permissions(ancestorFolder.Spec.FolderPermissions, true), "NamespacedFolder/"+ancestor)...)
+	}
+	// the ClusterFolder holding the namespace grants all of its permissions,
+	// its ancestors the ones they pass on to their children
+	for i, ancestor := range folderindex.InheritedAncestry(clusterAncestors, blockedClusterFolders) {
+		d.inheritedPermissions = append(d.inheritedPermissions, clusterFolderPermissions(ctx, cl, ancestor, i > 0)...)
+	}
+
+	vms, directVMs, err := namespacedFolderVMs(ctx, cl, root, key)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := controller.NamespacedFolderGrants(ctx, cl, folder, vms, directVMs)
+	if err != nil {
+		return nil, err
+	}
+	expected := []client.Object{}
+	for i := range grants {
+		expected = append(expected, &grants[i].Role, &grants[i].RoleBinding)
+	}
+
+	ownerLabels := client.MatchingLabels{controller.NamespacedFolderOwnershipLabel: string(folder.UID)}
+	actual, err := listOwnedRBAC(ctx, cl, ownerLabels, true)
+	if err != nil {
+		return nil, err
+	}
+	d.rbac = compareRBAC(expected, actual)
+
+	d.events, d.eventsErr = folderEvents(ctx, cl, folder)
+	return d, nil
+}
+
+// clusterFolderPermissions returns the formatted permissions of an ancestor
+// ClusterFolder, leaving out the ones excluded from children when they are
+// inherited. Folders that only exist in the index grant nothing.
+func clusterFolderPermissions(ctx context.Context, cl client.Reader, name string, inherited bool) []string {
+	folder := &v1alpha1.ClusterFolder{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: name}, folder); err != nil {
+		return nil
+	}
+	return formatPermissions(folderindex.Permissions(folder.Spec.FolderPermissions, inherited), "ClusterFolder/"+name)
+}
+
+func existingNamespaces(ctx context.Context, cl client.Reader) (map[string]bool, error) {
+	namespaceList := &corev1.NamespaceList{}
+	if err := cl.List(ctx, namespaceList); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+	namespaces := map[string]bool{}
+	for _, ns := range namespaceList.Items {
+		namespaces[ns.Name] = true
+	}
+	return namespaces, nil
+}
+
+// listOwnedRBAC returns the RoleBindings, and Roles when withRoles is set,
+// carrying a folder's ownership label.
+func listOwnedRBAC(ctx context.Context, cl client.Reader, ownerLabels client.MatchingLabels, withRoles bool) ([]client.Object, error) {
+	objs := []client.Object{}
+
+	if withRoles {
+		roles := &rbacv1.RoleList{}
+		if err := cl.List(ctx, roles, ownerLabels); err != nil {
+			return nil, fmt.Errorf("failed to list roles: %v", err)
+		}
+		for i := range roles.Items {
+			objs = append(objs, &roles.Items[i])
+		}
+	}
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := cl.List(ctx, roleBindings, ownerLabels); err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %v", err)
+	}
+	for i := range roleBindings.Items {
+		objs = append(objs, &roleBindings.Items[i])
+	}
+
+	return objs, nil
+}
+
+// compareRBAC matches the expected Roles and RoleBindings with the actual
+// ones by namespace and name and reports the drift state of each.
+func compareRBAC(expected []client.Object, actual []client.Object) []rbacDrift {
+	drifts := []rbacDrift{}
+
+	actualByKey := map[string]client.Object{}
+	for _, obj := range actual {
+		actualByKey[rbacKey(obj)] = obj
+	}
+
+	seen := map[string]bool{}
+	for _, obj := range expected {
+		key := rbacKey(obj)
+		seen[key] = true
+
+		state := driftMissing
+		if existing, exists := actualByKey[key]; exists {
+			state = driftModified
+			if rbacContentEqual(obj, existing) {
+				state = driftInSync
+			}
+		}
+		drifts = append(drifts, newRBACDrift(obj, state))
+	}
+	for _, obj := range actual {
+		if !seen[rbacKey(obj)] {
+			drifts = append(drifts, newRBACDrift(obj, driftUnexpected))
+		}
+	}
+
+	sort.SliceStable(drifts, func(i, j int) bool {
+		if drifts[i].namespace != drifts[j].namespace {
+			return drifts[i].namespace < drifts[j].namespace
+		}
+		if drifts[i].kind != drifts[j].kind {
+			return drifts[i].kind < drifts[j].kind
+		}
+		return drifts[i].name < drifts[j].name
+	})
+	return drifts
+}
+
+func rbacKey(obj client.Object) string {
+	kind := "RoleBinding"
+	if _, ok := obj.(*rbacv1.Role); ok {
+		kind = "Role"
+	}
+	return fmt.Sprintf("%s/%s/%s", kind, obj.GetNamespace(), obj.GetName())
+}
+
+func rbacContentEqual(expected client.Object, actual client.Object) bool {
+	switch e := expected.(type) {
+	case *rbacv1.Role:
+		a := actual.(*rbacv1.Role)
+		return equality.Semantic.DeepEqual(e.Rules, a.Rules)
+	case *rbacv1.RoleBinding:
+		a := actual.(*rbacv1.RoleBinding)
+		return equality.Semantic.DeepEqual(e.Subjects, a.Subjects) && equality.Semantic.DeepEqual(e.RoleRef, a.RoleRef)
+	}
+	return false
+}
+
+func newRBACDrift(obj client.Object, state string) rbacDrift {
+	drift := rbacDrift{namespace: obj.GetNamespace(), name: obj.GetName(), state: state}
+	switch o := obj.(type) {
+	case *rbacv1.Role:
+		drift.kind = "Role"
+		drift.summary = fmt.Sprintf("%d rules", len(o.Rules))
+	case *rbacv1.RoleBinding:
+		drift.kind = "RoleBinding"
+		subjects := []string{}
+		for _, subject := range o.Subjects {
+			subjects = append(subjects, formatSubject(subject))
+		}
+		drift.summary = fmt.Sprintf("%s -> %s", strings.Join(subjects, ","), formatRoleRef(o.RoleRef))
+	}
+	return drift
+}
+
+// folderEvents returns the most recent events recorded for the folder.
+func folderEvents(ctx context.Context, cl client.Reader, folder client.Object) ([]corev1.Event, error) {
+	events := &corev1.EventList{}
+	opts := []client.ListOption{client.MatchingFields{"involvedObject.uid": string(folder.GetUID())}}
+	if folder.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(folder.GetNamespace()))
+	}
+	if err := cl.List(ctx, events, opts...); err != nil {
+		return nil, err
+	}
+
+	items := events.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return eventTime(items[i]).Before(eventTime(items[j]))
+	})
+	if len(items) > describeEventLimit {
+		items = items[len(items)-describeEventLimit:]
+	}
+	return items, nil
+}
+
+func eventTime(event corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+func reversed(list []string) []string {
+	list = slices.Clone(list)
+	slices.Reverse(list)
+	return list
+}
+
+func formatOwners(owners []rbacv1.Subject) []string {
+	formatted := []string{}
+	for _, owner := range owners {
+		formatted = append(formatted, formatSubject(owner))
+	}
+	return formatted
+}
+
+// formatDescribeTags returns the tags of a folder as key=value, marking the
+// ones that are propagated as labels onto the members of the folder.
+func formatDescribeTags(metadata v1alpha1.FolderMetadata) []string {
+	formatted := formatTags(metadata.Tags)
+	for i, key := range slices.Sorted(maps.Keys(metadata.Tags)) {
+		if slices.Contains(metadata.PropagatedTags, key) {
+			formatted[i] += " (propagated)"
+		}
+	}
+	return formatted
+}
+
+func printDescription(w io.Writer, d *folderDescription, now time.Time) {
+	fmt.Fprintf(w, "Name:  %s\n", d.name)
+	fmt.Fprintf(w, "Kind:  %s\n", d.kind)
+	fmt.Fprintf(w, "UID:   %s\n", d.uid)
+	if d.indexed {
+		fmt.Fprintf(w, "Path:  /%s\n", strings.Join(d.path, "/"))
+	} else {
+		fmt.Fprintf(w, "Path:  <not in the folder index>\n")
+	}
+
+	printSection := func(title string, lines []string) {
+		if len(lines) == 0 {
+			fmt.Fprintf(w, "%s:  <none>\n", title)
+			return
+		}
+		fmt.Fprintf(w, "%s:\n", title)
+		for _, line := range lines {
+			fmt.Fprintf(w, "  %s\n", strings.TrimPrefix(line, "  "))
+		}
+	}
+	if d.metadata.DisplayName != "" {
+		fmt.Fprintf(w, "Display Name:  %s\n", d.metadata.DisplayName)
+	}
+	if d.metadata.Description != "" {
+		fmt.Fprintf(w, "Description:  %s\n", d.metadata.Description)
+	}
+	printSection("Owners", formatOwners(d.metadata.Owners))
+	printSection("Tags", formatDescribeTags(d.metadata))
+	printSection("Members", d.members)
+	printSection("Inherited Members", d.inherited)
+	printSection("Permissions", d.permissions)
+	printSection("Inherited Permissions", d.inheritedPermissions)
+
+	if len(d.rbac) == 0 {
+		fmt.Fprintln(w, "RBAC:  <none>")
+	} else {
+		fmt.Fprintln(w, "RBAC:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  STATE\tKIND\tNAMESPACE\tNAME\tSUMMARY")
+		for _, drift := range d.rbac {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", drift.state, drift.kind, drift.namespace, drift.name, drift.summary)
+		}
+		tw.Flush()
+	}
+
+	switch {
+	case d.eventsErr != nil:
+		fmt.Fprintf(w, "Events:  <unable to list events: %v>\n", d.eventsErr)
+	case len(d.events) == 0:
+		fmt.Fprintln(w, "Events:  <none>")
+	default:
+		fmt.Fprintln(w, "Events:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  TYPE\tREASON\tAGE\tFROM\tMESSAGE")
+		for _, event := range d.events {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n",
+				event.Type,
+				event.Reason,
+				duration.HumanDuration(now.Sub(eventTime(event))),
+				event.Source.Component,
+				strings.TrimSpace(event.Message))
+		}
+		tw.Flush()
+	}
+}
+
+// namespacedFolderKeyCompletions returns the namespace/name of every
+// NamespacedFolder in the index.
+func namespacedFolderKeyCompletions(ctx context.Context, cl client.Reader) []string {
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return nil
+	}
+	keys := []string{}
+	for key := range root.Spec.NamespacedFolderEntries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func completeDescribeArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return []string{"clusterfolder", "namespacedfolder"}, cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		switch describeKinds[args[0]] {
+		case "ClusterFolder":
+			return completeWith(func(ctx context.Context, cl client.Reader) []string {
+				return folderCompletions(ctx, cl, "")
+			})
+		case "NamespacedFolder":
+			return completeWith(namespacedFolderKeyCompletions)
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func newDescribeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe (clusterfolder NAME | namespacedfolder NAMESPACE/NAME)",
+		Short: "Show the details of a single folder",
+		Long: `Show the details of a single folder.
+
+The path from the top of the folder tree, the display name, description,
+owners and tags of the folder, the folders, namespaces and VMs the folder
+holds directly and through its child folders, and the permissions it declares
+and inherits from its ancestors are shown.
+
+The Roles and RoleBindings carrying the folder's ownership label are compared
+with those the folder controllers would generate. Each is InSync, Modified,
+Missing when the controller has not created it yet, or Unexpected when the
+controller would delete it. The most recent events for the folder follow.`,
+		Example: `  kubectl folder describe clusterfolder operations
+  kubectl folder describe namespacedfolder prod-web-apps/prod-web-app-a`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeDescribeArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			kind, ok := describeKinds[args[0]]
+			if !ok {
+				return fmt.Errorf("unsupported kind [%s], expected clusterfolder or namespacedfolder", args[0])
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			var d *folderDescription
+			if kind == "ClusterFolder" {
+				d, err = describeClusterFolder(ctx, cl, args[1])
+			} else {
+				d, err = describeNamespacedFolder(ctx, cl, args[1])
+			}
+			if err != nil {
+				return err
+			}
+
+			printDescription(os.Stdout, d, time.Now())
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,136 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+)
+
+var _ = Describe("describe", func() {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var objs []client.Object
+
+	BeforeEach(func() {
+		_, objs = folderFixtureObjects()
+	})
+
+	newClient := func() client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(objs...).
+			WithIndex(&corev1.Event{}, "involvedObject.uid", func(obj client.Object) []string {
+				return []string{string(obj.(*corev1.Event).InvolvedObject.UID)}
+			}).
+			Build()
+	}
+
+	states := func(d *folderDescription) []string {
+		found := []string{}
+		for _, drift := range d.rbac {
+			found = append(found, drift.state+" "+drift.kind)
+		}
+		return found
+	}
+
+	It("should compare the RoleBindings of a ClusterFolder with the generated ones", func() {
+		operations := objs[4].(*v1alpha1.ClusterFolder)
+		roleBindings, err := controller.ClusterFolderRoleBindings(operations, "prod-web-apps", true)
+		Expect(err).NotTo(HaveOccurred())
+
+		stale := roleBindings[0].DeepCopy()
+		stale.Name = "stale"
+		objs = append(objs, &roleBindings[0], stale,
+			&corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "operations.1"},
+				InvolvedObject: corev1.ObjectReference{Kind: "ClusterFolder", Name: "operations", UID: operations.UID},
+				Type:           corev1.EventTypeWarning,
+				Reason:         "ReconcileFailed",
+				Message:        "failed to create role binding",
+				Source:         corev1.EventSource{Component: "folder-controller"},
+				LastTimestamp:  metav1.NewTime(now.Add(-5 * time.Minute)),
+			})
+
+		d, err := describeClusterFolder(ctx, newClient(), "operations")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.path).To(Equal([]string{"operations"}))
+		Expect(d.members).To(Equal([]string{"ClusterFolder/production"}))
+		Expect(d.inherited).To(Equal([]string{"Namespace/prod-web-apps (from ClusterFolder/production)"}))
+		Expect(states(d)).To(ConsistOf("InSync RoleBinding", "Unexpected RoleBinding"))
+		Expect(d.events).To(HaveLen(1))
+
+		var out bytes.Buffer
+		printDescription(&out, d, now)
+		Expect(out.String()).To(ContainSubstring("Path:  /operations\n"))
+		Expect(out.String()).To(ContainSubstring("Inherited Permissions:  <none>"))
+		Expect(out.String()).To(ContainSubstring("Owners:  <none>\n"))
+		Expect(out.String()).NotTo(ContainSubstring("Display Name:"))
+		Expect(out.String()).To(MatchRegexp(`Warning\s+ReconcileFailed\s+5m\s+folder-controller\s+failed to create role binding`))
+	})
+
+	It("should show the ancestry and drift of a NamespacedFolder", func() {
+		appA := objs[5].(*v1alpha1.NamespacedFolder)
+		grants, err := controller.NamespacedFolderGrants(ctx, newClient(), appA, []string{"web-app-a"}, []string{"web-app-a"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(grants).To(HaveLen(1))
+
+		role := grants[0].Role.DeepCopy()
+		role.Rules = []rbacv1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"kubevirt.io"}, Resources: []string{"*"}}}
+		objs = append(objs, role)
+
+		d, err := describeNamespacedFolder(ctx, newClient(), "prod-web-apps/prod-web-app-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.path).To(Equal([]string{"operations", "production", "prod-web-apps", "prod-web-app-a"}))
+		Expect(d.members).To(Equal([]string{"VM/prod-web-apps/web-app-a"}))
+		Expect(d.permissions).To(Equal([]string{"  Group/dev-team-a: ClusterRole/view"}))
+		Expect(d.inheritedPermissions).To(Equal([]string{"  Group/operation-team: ClusterRole/admin (from ClusterFolder/operations)"}))
+		Expect(states(d)).To(Equal([]string{"Modified Role", "Missing RoleBinding"}))
+		Expect(d.events).To(BeEmpty())
+	})
+
+	It("should show the metadata of a folder", func() {
+		appB := objs[6].(*v1alpha1.NamespacedFolder)
+		appB.Spec.FolderMetadata = v1alpha1.FolderMetadata{
+			DisplayName:    "Web App B",
+			Description:    "Frontends of web app B",
+			Owners:         []rbacv1.Subject{{Kind: "Group", Name: "dev-team-b"}},
+			Tags:           map[string]string{"env": "production", "cost-center": "cc-42"},
+			PropagatedTags: []string{"cost-center"},
+		}
+
+		d, err := describeNamespacedFolder(ctx, newClient(), "prod-web-apps/prod-web-app-b")
+		Expect(err).NotTo(HaveOccurred())
+
+		var out bytes.Buffer
+		printDescription(&out, d, now)
+		Expect(out.String()).To(ContainSubstring(`Display Name:  Web App B
+Description:  Frontends of web app B
+Owners:
+  Group/dev-team-b
+Tags:
+  cost-center=cc-42 (propagated)
+  env=production
+Members:
+`))
+	})
+
+	It("should fail for folders that do not exist", func() {
+		_, err := describeNamespacedFolder(ctx, newClient(), "prod-web-apps/missing")
+		Expect(err).To(HaveOccurred())
+		_, err = describeNamespacedFolder(ctx, newClient(), "missing")
+		Expect(err).To(MatchError("invalid namespaced folder [missing], expected the format namespace/name"))
+	})
+})
@@ -0,0 +1,197 @@
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// stripClusterFields removes everything from an object's metadata that is
+// specific to the cluster it was read from, so it can be created elsewhere.
+func stripClusterFields(obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, scheme.Scheme)
+	if err != nil {
+		return err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	obj.SetOwnerReferences(nil)
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, corev1.LastAppliedConfigAnnotation)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	return nil
+}
+
+// exportFolders returns the root FolderIndex followed by every ClusterFolder,
+// NamespacedFolder and FolderMembership, stripped of their UIDs, status and
+// other cluster specific metadata. Members the index flags as deleted are
+// left out.
+func exportFolders(ctx context.Context, cl client.Reader) ([]client.Object, error) {
+	objs := []client.Object{}
+
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	folderindex.RemoveDeletedMembers(root)
+	root.Status = v1alpha1.FolderIndexStatus{}
+	objs = append(objs, root)
+
+	clusterFolders := &v1alpha1.ClusterFolderList{}
+	if err := cl.List(ctx, clusterFolders); err != nil {
+		return nil, fmt.Errorf("failed to list cluster folders: %v", err)
+	}
+	sort.Slice(clusterFolders.Items, func(i, j int) bool {
+		return clusterFolders.Items[i].Name < clusterFolders.Items[j].Name
+	})
+	for i := range clusterFolders.Items {
+		folder := &clusterFolders.Items[i]
+		folder.Status = v1alpha1.ClusterFolderStatus{}
+		objs = append(objs, folder)
+	}
+
+	namespacedFolders := &v1alpha1.NamespacedFolderList{}
+	if err := cl.List(ctx, namespacedFolders); err != nil {
+		return nil, fmt.Errorf("failed to list namespaced folders: %v", err)
+	}
+	sort.Slice(namespacedFolders.Items, func(i, j int) bool {
+		a, b := namespacedFolders.Items[i], namespacedFolders.Items[j]
+		return folderindex.NamespacedFolderKey(a.Namespace, a.Name) < folderindex.NamespacedFolderKey(b.Namespace, b.Name)
+	})
+	for i := range namespacedFolders.Items {
+		folder := &namespacedFolders.Items[i]
+		folder.Status = v1alpha1.NamespacedFolderStatus{}
+		objs = append(objs, folder)
+	}
+
+	memberships, err := listFolderMemberships(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		a, b := memberships[i], memberships[j]
+		return folderindex.NamespacedFolderKey(a.Namespace, a.Name) < folderindex.NamespacedFolderKey(b.Namespace, b.Name)
+	})
+	for i := range memberships {
+		objs = append(objs, &memberships[i])
+	}
+
+	for _, obj := range objs {
+		if err := stripClusterFields(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	return objs, nil
+}
+
+// writeManifestList writes the objects as a single v1 List document, which
+// readManifests accepts again.
+func writeManifestList(w io.Writer, objs []client.Object, output string) error {
+	list := &corev1.List{}
+	list.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("List"))
+	for _, obj := range objs {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		list.Items = append(list.Items, runtime.RawExtension{Raw: raw})
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if output == "yaml" {
+		data, err = yaml.JSONToYAML(data)
+		if err != nil {
+			return err
+		}
+	} else {
+		data = append(data, '\n')
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func newExportCmd() *cobra.Command {
+	var filename string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the folder hierarchy to a single portable document",
+		Long: `Write the folder hierarchy to a single portable document.
+
+The root FolderIndex and every ClusterFolder, NamespacedFolder and
+FolderMembership are written as one v1 List. UIDs, status and other cluster specific metadata are removed,
+so the document can be restored on another cluster with import.`,
+		Example: `  kubectl folder export -f folders.yaml
+  kubectl folder export -o json > folders.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			if output != "yaml" && output != "json" {
+				return fmt.Errorf("unsupported output format [%s], expected yaml or json", output)
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			objs, err := exportFolders(ctx, cl)
+			if err != nil {
+				return err
+			}
+
+			w := io.Writer(os.Stdout)
+			if filename != "" {
+				f, err := os.Create(filename)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			if err := writeManifestList(w, objs, output); err != nil {
+				return fmt.Errorf("failed to write folders: %v", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "File to write to instead of stdout")
+	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "Output format, yaml or json")
+	_ = cmd.RegisterFlagCompletionFunc("output", completeFixed("yaml", "json"))
+
+	return cmd
+}
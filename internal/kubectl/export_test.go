@@ -0,0 +1,169 @@
+package kubectl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("export and import", func() {
+	ctx := context.Background()
+
+	var exported []client.Object
+	var target client.Client
+
+	BeforeEach(func() {
+		source, _ := newFolderFixture()
+
+		objs, err := exportFolders(ctx, source)
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(GinkgoT().TempDir(), "folders.yaml")
+		f, err := os.Create(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writeManifestList(f, objs, "yaml")).To(Succeed())
+		Expect(f.Close()).To(Succeed())
+
+		exported, err = readManifests(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		target = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod-web-apps"}},
+			&virtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-a"}},
+		).Build()
+	})
+
+	It("should export the index and folders without cluster specific metadata", func() {
+		keys := []string{}
+		for _, obj := range exported {
+			keys = append(keys, objectKey(obj))
+			Expect(obj.GetUID()).To(BeEmpty())
+			Expect(obj.GetResourceVersion()).To(BeEmpty())
+		}
+		Expect(keys).To(Equal([]string{
+			"FolderIndex//root",
+			"ClusterFolder//operations",
+			"NamespacedFolder/prod-web-apps/prod-web-app-a",
+			"NamespacedFolder/prod-web-apps/prod-web-app-b",
+		}))
+	})
+
+	It("should import idempotently and report missing VirtualMachines", func() {
+		result, err := importFolders(ctx, target, exported, importOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.warnings).To(ConsistOf(
+			"vm [prod-web-apps/web-app-b] in NamespacedFolder [prod-web-apps/prod-web-app-b] does not exist"))
+		Expect(result.actions).To(Equal([]string{
+			"ClusterFolder [operations] created",
+			"NamespacedFolder [prod-web-apps/prod-web-app-a] created",
+			"NamespacedFolder [prod-web-apps/prod-web-app-b] created",
+			"FolderIndex [root] created",
+		}))
+
+		root := &v1alpha1.FolderIndex{}
+		Expect(target.Get(ctx, client.ObjectKey{Name: "root"}, root)).To(Succeed())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-b"].VirtualMachines).To(ConsistOf("web-app-b"))
+
+		result, err = importFolders(ctx, target, exported, importOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		for _, action := range result.actions {
+			Expect(action).To(HaveSuffix(" unchanged"))
+		}
+	})
+
+	It("should prune missing namespaces and VirtualMachines when requested", func() {
+		target = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+		result, err := importFolders(ctx, target, exported, importOptions{pruneMissing: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.warnings).To(ConsistOf(
+			"namespace [prod-web-apps] in ClusterFolder [production] does not exist",
+			"namespace [prod-web-apps] of NamespacedFolder [prod-web-apps/prod-web-app-a] does not exist",
+			"namespace [prod-web-apps] of NamespacedFolder [prod-web-apps/prod-web-app-b] does not exist",
+		))
+		Expect(result.actions).To(ContainElements(
+			"NamespacedFolder [prod-web-apps/prod-web-app-a] skipped",
+			"NamespacedFolder [prod-web-apps/prod-web-app-b] skipped",
+		))
+
+		root := &v1alpha1.FolderIndex{}
+		Expect(target.Get(ctx, client.ObjectKey{Name: "root"}, root)).To(Succeed())
+		Expect(root.Spec.ClusterFolderEntries["production"].Namespaces).To(BeEmpty())
+		Expect(root.Spec.NamespacedFolderEntries).To(BeEmpty())
+	})
+
+	It("should update folders whose spec differs", func() {
+		_, err := importFolders(ctx, target, exported, importOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		folder := &v1alpha1.ClusterFolder{}
+		Expect(target.Get(ctx, client.ObjectKey{Name: "operations"}, folder)).To(Succeed())
+		folder.Spec.FolderPermissions = nil
+		Expect(target.Update(ctx, folder)).To(Succeed())
+
+		result, err := importFolders(ctx, target, exported, importOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.actions).To(ContainElement("ClusterFolder [operations] updated"))
+	})
+
+	It("should export and import FolderMemberships as they are", func() {
+		_, objs := folderFixtureObjects()
+		membership := &v1alpha1.FolderMembership{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "prod-web-apps",
+				Name:      "web-app-c",
+				UID:       "membership-uid",
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "kubevirt.io/v1",
+					Kind:       "VirtualMachine",
+					Name:       "web-app-c",
+					UID:        "vm-uid",
+				}},
+			},
+			Spec: v1alpha1.FolderMembershipSpec{Folder: "prod-web-app-a"},
+		}
+		source := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(append(objs, membership)...).Build()
+
+		exported, err := exportFolders(ctx, source)
+		Expect(err).NotTo(HaveOccurred())
+		root := exported[0].(*v1alpha1.FolderIndex)
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].VirtualMachines).To(ConsistOf("web-app-a"))
+		Expect(objectKey(exported[len(exported)-1])).To(Equal("FolderMembership/prod-web-apps/web-app-c"))
+		Expect(exported[len(exported)-1].GetOwnerReferences()).To(BeEmpty())
+
+		result, err := importFolders(ctx, target, exported, importOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.warnings).To(ContainElement("vm [prod-web-apps/web-app-c] filed by a FolderMembership does not exist"))
+		Expect(result.actions).To(HaveLen(5))
+		Expect(result.actions[4]).To(Equal("FolderMembership [prod-web-apps/web-app-c] created"))
+
+		imported := &v1alpha1.FolderMembership{}
+		Expect(target.Get(ctx, client.ObjectKeyFromObject(membership), imported)).To(Succeed())
+		Expect(imported.Spec.Folder).To(Equal("prod-web-app-a"))
+		Expect(target.Get(ctx, client.ObjectKey{Name: "root"}, root)).To(Succeed())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].VirtualMachines).To(ConsistOf("web-app-a"))
+
+		By("leaving out memberships of missing VMs with --prune-missing")
+		Expect(target.Delete(ctx, imported)).To(Succeed())
+		result, err = importFolders(ctx, target, exported, importOptions{pruneMissing: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.actions).NotTo(ContainElement(ContainSubstring("FolderMembership")))
+	})
+
+	It("should reject objects other than folders", func() {
+		_, err := importFolders(ctx, target, append(exported,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}), importOptions{})
+		Expect(err).To(MatchError(ContainSubstring("unsupported object [Namespace//other]")))
+	})
+})
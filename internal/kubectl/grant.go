@@ -0,0 +1,399 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// permissionsFieldManager is the field manager grant and revoke apply folder
+// permissions as.
+const permissionsFieldManager = "kubectl-folder"
+
+// permissionFlags are the flags grant and revoke name a subject and a role
+// with.
+type permissionFlags struct {
+	user           string
+	group          string
+	serviceAccount string
+	clusterRole    string
+	role           string
+}
+
+func (f *permissionFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.user, "subject-user", "", "Name of the user")
+	cmd.Flags().StringVar(&f.group, "subject-group", "", "Name of the group")
+	cmd.Flags().StringVar(&f.serviceAccount, "subject-serviceaccount", "", "ServiceAccount in the format namespace/name")
+	cmd.Flags().StringVar(&f.clusterRole, "clusterrole", "", "Name of the ClusterRole")
+	cmd.Flags().StringVar(&f.role, "role", "", "Name of the Role")
+	_ = cmd.RegisterFlagCompletionFunc("subject-serviceaccount", cobra.NoFileCompletions)
+	_ = cmd.RegisterFlagCompletionFunc("clusterrole", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWith(clusterRoleCompletions)
+	})
+}
+
+// subject returns the subject named by exactly one of --subject-user,
+// --subject-group and --subject-serviceaccount.
+func (f *permissionFlags) subject() (rbacv1.Subject, error) {
+	subjects := []rbacv1.Subject{}
+	if f.user != "" {
+		subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: f.user})
+	}
+	if f.group != "" {
+		subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: f.group})
+	}
+	if f.serviceAccount != "" {
+		namespace, name, err := folderindex.SplitNamespacedFolderKey(f.serviceAccount)
+		if err != nil {
+			return rbacv1.Subject{}, fmt.Errorf("invalid serviceaccount [%s], expected the format namespace/name", f.serviceAccount)
+		}
+		subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name})
+	}
+	if len(subjects) != 1 {
+		return rbacv1.Subject{}, fmt.Errorf("exactly one of --subject-user, --subject-group or --subject-serviceaccount is required")
+	}
+	return subjects[0], nil
+}
+
+// roleRef returns the role named by --clusterrole or --role. When optional
+// is set and neither is given, ok is false.
+func (f *permissionFlags) roleRef(optional bool) (roleRef rbacv1.RoleRef, ok bool, err error) {
+	switch {
+	case f.clusterRole != "" && f.role != "":
+		return roleRef, false, fmt.Errorf("--clusterrole and --role are mutually exclusive")
+	case f.clusterRole != "":
+		return rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: f.clusterRole}, true, nil
+	case f.role != "":
+		return rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: f.role}, true, nil
+	case optional:
+		return roleRef, false, nil
+	}
+	return roleRef, false, fmt.Errorf("one of --clusterrole or --role is required")
+}
+
+func sameSubject(a rbacv1.Subject, b rbacv1.Subject) bool {
+	return a.Kind == b.Kind && a.Name == b.Name && a.Namespace == b.Namespace
+}
+
+func sameRoleRef(a rbacv1.RoleRef, b rbacv1.RoleRef) bool {
+	return a.Kind == b.Kind && a.Name == b.Name
+}
+
+// grantPermission returns permissions with roleRef granted to subject. The
+// roleRef is added to the existing entry of the subject, if there is one,
+// rather than to a new entry. changed is false when the subject already has
+// the roleRef.
+func grantPermission(permissions []v1alpha1.FolderPermission, subject rbacv1.Subject, roleRef rbacv1.RoleRef) (result []v1alpha1.FolderPermission, changed bool) {
+	for _, fp := range permissions {
+		result = append(result, *fp.DeepCopy())
+	}
+
+	for i := range result {
+		if !sameSubject(result[i].Subject, subject) {
+			continue
+		}
+		for _, rr := range result[i].RoleRefs {
+			if sameRoleRef(rr, roleRef) {
+				return result, false
+			}
+		}
+		result[i].RoleRefs = append(result[i].RoleRefs, roleRef)
+		return result, true
+	}
+
+	return append(result, v1alpha1.FolderPermission{Subject: subject, RoleRefs: []rbacv1.RoleRef{roleRef}}), true
+}
+
+// revokePermission returns permissions without roleRef for subject, or
+// without any role for subject when roleRef is nil. An entry left without
+// roles is removed. changed is false when there was nothing to revoke.
+func revokePermission(permissions []v1alpha1.FolderPermission, subject rbacv1.Subject, roleRef *rbacv1.RoleRef) (result []v1alpha1.FolderPermission, changed bool) {
+	result = []v1alpha1.FolderPermission{}
+
+	for _, fp := range permissions {
+		if !sameSubject(fp.Subject, subject) {
+			result = append(result, *fp.DeepCopy())
+			continue
+		}
+
+		roleRefs := []rbacv1.RoleRef{}
+		for _, rr := range fp.RoleRefs {
+			if roleRef != nil && !sameRoleRef(rr, *roleRef) {
+				roleRefs = append(roleRefs, rr)
+			}
+		}
+		if len(roleRefs) != len(fp.RoleRefs) {
+			changed = true
+		}
+		if len(roleRefs) != 0 {
+			result = append(result, v1alpha1.FolderPermission{Subject: fp.Subject, RoleRefs: roleRefs})
+		} else if len(fp.RoleRefs) == 0 {
+			// an entry that already had no roles is dropped as well
+			changed = true
+		}
+	}
+
+	return result, changed
+}
+
+// getPermissionFolder returns the ClusterFolder named name, or the
+// NamespacedFolder when namespace is set, along with its permissions.
+func getPermissionFolder(ctx context.Context, cl client.Reader, namespace string, name string) (client.Object, []v1alpha1.FolderPermission, error) {
+	if namespace == "" {
+		folder := &v1alpha1.ClusterFolder{}
+		if err := cl.Get(ctx, client.ObjectKey{Name: name}, folder); err != nil {
+			return nil, nil, fmt.Errorf("failed to get cluster folder [%s]: %v", name, err)
+		}
+		return folder, folder.Spec.FolderPermissions, nil
+	}
+
+	key := folderindex.NamespacedFolderKey(namespace, name)
+	folder := &v1alpha1.NamespacedFolder{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, folder); err != nil {
+		return nil, nil, fmt.Errorf("failed to get namespaced folder [%s]: %v", key, err)
+	}
+	return folder, folder.Spec.FolderPermissions, nil
+}
+
+// permissionsApplyObject returns the object grant and revoke apply to set
+// the permissions of folder. Only the permissions are part of it, so other
+// fields of the folder keep their managers. The permissions are set even
+// when empty, so revoking the last permission clears fields that were
+// applied by other managers. The resourceVersion makes the apply fail
+// rather than overwrite permissions that changed since the folder was read.
+func permissionsApplyObject(folder client.Object, permissions []v1alpha1.FolderPermission) (*unstructured.Unstructured, error) {
+	var kind string
+	switch folder.(type) {
+	case *v1alpha1.ClusterFolder:
+		kind = "ClusterFolder"
+	case *v1alpha1.NamespacedFolder:
+		kind = "NamespacedFolder"
+	default:
+		return nil, fmt.Errorf("unsupported folder type %T", folder)
+	}
+
+	encoded := []interface{}{}
+	for _, fp := range permissions {
+		fpMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&fp)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, fpMap)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(v1alpha1.GroupVersion.WithKind(kind))
+	obj.SetName(folder.GetName())
+	obj.SetNamespace(folder.GetNamespace())
+	obj.SetResourceVersion(folder.GetResourceVersion())
+	if err := unstructured.SetNestedSlice(obj.Object, encoded, "spec", "folderPermissions"); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// applyPermissions sets the permissions of folder with server-side apply.
+func applyPermissions(ctx context.Context, cl client.Client, folder client.Object, permissions []v1alpha1.FolderPermission) error {
+	obj, err := permissionsApplyObject(folder, permissions)
+	if err != nil {
+		return err
+	}
+	if err := cl.Patch(ctx, obj, client.Apply, client.FieldOwner(permissionsFieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to apply folder permissions: %v", err)
+	}
+	return nil
+}
+
+// roleRefWarning returns why roleRef has no effect on folder, or "" when it
+// grants access. A ClusterRole that does not exist grants nothing, and a
+// NamespacedFolder only generates Roles from the KubeVirt rules of a role.
+// Roles of a ClusterFolder are bound in each namespace of the folder, so
+// they are not checked.
+func roleRefWarning(ctx context.Context, cl client.Reader, folder client.Object, roleRef rbacv1.RoleRef) (string, error) {
+	_, namespaced := folder.(*v1alpha1.NamespacedFolder)
+	if !namespaced && roleRef.Kind != "ClusterRole" {
+		return "", nil
+	}
+
+	var obj client.Object
+	var rules func() []rbacv1.PolicyRule
+	if roleRef.Kind == "ClusterRole" {
+		role := &rbacv1.ClusterRole{}
+		obj, rules = role, func() []rbacv1.PolicyRule { return role.Rules }
+	} else {
+		role := &rbacv1.Role{}
+		obj, rules = role, func() []rbacv1.PolicyRule { return role.Rules }
+	}
+
+	key := client.ObjectKey{Name: roleRef.Name}
+	if roleRef.Kind == "Role" {
+		key.Namespace = folder.GetNamespace()
+	}
+	if err := cl.Get(ctx, key, obj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to get %s: %v", formatRoleRef(roleRef), err)
+		}
+		if key.Namespace != "" {
+			return fmt.Sprintf("%s does not exist in namespace [%s]", formatRoleRef(roleRef), key.Namespace), nil
+		}
+		return fmt.Sprintf("%s does not exist", formatRoleRef(roleRef)), nil
+	}
+	if namespaced && len(controller.FilterKubeVirtRules(rules(), []string{"vm"})) == 0 {
+		return fmt.Sprintf("%s has no KubeVirt rules, so the NamespacedFolder grants no access with it", formatRoleRef(roleRef)), nil
+	}
+	return "", nil
+}
+
+func folderDisplayName(folder client.Object) string {
+	if folder.GetNamespace() != "" {
+		return fmt.Sprintf("NamespacedFolder [%s]", folderindex.NamespacedFolderKey(folder.GetNamespace(), folder.GetName()))
+	}
+	return fmt.Sprintf("ClusterFolder [%s]", folder.GetName())
+}
+
+func printWarning(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintf(w, "Warning: "+format+"\n", args...)
+}
+
+func newGrantCmd() *cobra.Command {
+	flags := &permissionFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "grant FOLDER (--subject-user NAME | --subject-group NAME | --subject-serviceaccount NS/NAME) (--clusterrole NAME | --role NAME)",
+		Short: "Grant a subject a role on a folder",
+		Long: `Grant a subject a role on a folder.
+
+Without --namespace FOLDER is a ClusterFolder, with --namespace it is a
+NamespacedFolder in that namespace. The role is added to the folder
+permissions with server-side apply. When the subject already has permissions
+on the folder the role is added to them rather than to a new entry.
+
+A warning is printed when the role has no effect: a ClusterRole that does not
+exist, or, for NamespacedFolders, a role without KubeVirt rules.`,
+		Example: `  kubectl folder grant operations --subject-group operation-team --clusterrole admin
+  kubectl folder grant -n prod-web-apps prod-web-app-a --subject-group dev-team-a --clusterrole edit`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFolderArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			subject, err := flags.subject()
+			if err != nil {
+				return err
+			}
+			roleRef, _, err := flags.roleRef(false)
+			if err != nil {
+				return err
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			folder, permissions, err := getPermissionFolder(ctx, cl, namespaceFlag(cmd), args[0])
+			if err != nil {
+				return err
+			}
+
+			warning, err := roleRefWarning(ctx, cl, folder, roleRef)
+			if err != nil {
+				return err
+			}
+			if warning != "" {
+				printWarning(os.Stderr, "%s", warning)
+			}
+
+			permissions, changed := grantPermission(permissions, subject, roleRef)
+			if !changed {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s already grants %s to %s\n", folderDisplayName(folder), formatRoleRef(roleRef), formatSubject(subject))
+				return nil
+			}
+			if err := applyPermissions(ctx, cl, folder, permissions); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s granted to %s on %s\n", formatRoleRef(roleRef), formatSubject(subject), folderDisplayName(folder))
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+func newRevokeCmd() *cobra.Command {
+	flags := &permissionFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "revoke FOLDER (--subject-user NAME | --subject-group NAME | --subject-serviceaccount NS/NAME) [--clusterrole NAME | --role NAME]",
+		Short: "Revoke a role of a subject on a folder",
+		Long: `Revoke a role of a subject on a folder.
+
+Without --namespace FOLDER is a ClusterFolder, with --namespace it is a
+NamespacedFolder in that namespace. The role is removed from the folder
+permissions with server-side apply. Without --clusterrole or --role every
+role of the subject is revoked. A subject left without roles is removed from
+the folder permissions.`,
+		Example: `  kubectl folder revoke operations --subject-group operation-team --clusterrole admin
+  kubectl folder revoke -n prod-web-apps prod-web-app-a --subject-group dev-team-a`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFolderArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			subject, err := flags.subject()
+			if err != nil {
+				return err
+			}
+			roleRef, hasRoleRef, err := flags.roleRef(true)
+			if err != nil {
+				return err
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			folder, permissions, err := getPermissionFolder(ctx, cl, namespaceFlag(cmd), args[0])
+			if err != nil {
+				return err
+			}
+
+			revoked := "all roles"
+			var revokedRoleRef *rbacv1.RoleRef
+			if hasRoleRef {
+				revoked = formatRoleRef(roleRef)
+				revokedRoleRef = &roleRef
+			}
+
+			permissions, changed := revokePermission(permissions, subject, revokedRoleRef)
+			if !changed {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s does not grant %s to %s\n", folderDisplayName(folder), revoked, formatSubject(subject))
+				return nil
+			}
+			if err := applyPermissions(ctx, cl, folder, permissions); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s revoked from %s on %s\n", revoked, formatSubject(subject), folderDisplayName(folder))
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
@@ -0,0 +1,166 @@
+package kubectl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("grant and revoke", func() {
+	ctx := context.Background()
+
+	devTeamA := rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: "dev-team-a"}
+	devTeamB := rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: "dev-team-b"}
+	view := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "view"}
+	edit := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "edit"}
+
+	permissions := []v1alpha1.FolderPermission{
+		{Subject: devTeamA, RoleRefs: []rbacv1.RoleRef{view}},
+	}
+
+	It("should merge a role into the existing entry of a subject", func() {
+		result, changed := grantPermission(permissions, devTeamA, edit)
+		Expect(changed).To(BeTrue())
+		Expect(result).To(Equal([]v1alpha1.FolderPermission{
+			{Subject: devTeamA, RoleRefs: []rbacv1.RoleRef{view, edit}},
+		}))
+		// the original permissions are left alone
+		Expect(permissions[0].RoleRefs).To(HaveLen(1))
+	})
+
+	It("should add an entry for a new subject", func() {
+		result, changed := grantPermission(permissions, devTeamB, edit)
+		Expect(changed).To(BeTrue())
+		Expect(result).To(Equal([]v1alpha1.FolderPermission{
+			{Subject: devTeamA, RoleRefs: []rbacv1.RoleRef{view}},
+			{Subject: devTeamB, RoleRefs: []rbacv1.RoleRef{edit}},
+		}))
+	})
+
+	It("should not duplicate a role the subject already has", func() {
+		result, changed := grantPermission(permissions, devTeamA, view)
+		Expect(changed).To(BeFalse())
+		Expect(result).To(Equal(permissions))
+	})
+
+	It("should revoke a single role and drop entries left without roles", func() {
+		both := []v1alpha1.FolderPermission{
+			{Subject: devTeamA, RoleRefs: []rbacv1.RoleRef{view, edit}},
+			{Subject: devTeamB, RoleRefs: []rbacv1.RoleRef{edit}},
+		}
+
+		result, changed := revokePermission(both, devTeamA, &edit)
+		Expect(changed).To(BeTrue())
+		Expect(result).To(Equal([]v1alpha1.FolderPermission{
+			{Subject: devTeamA, RoleRefs: []rbacv1.RoleRef{view}},
+			{Subject: devTeamB, RoleRefs: []rbacv1.RoleRef{edit}},
+		}))
+
+		result, changed = revokePermission(both, devTeamB, &edit)
+		Expect(changed).To(BeTrue())
+		Expect(result).To(Equal([]v1alpha1.FolderPermission{
+			{Subject: devTeamA, RoleRefs: []rbacv1.RoleRef{view, edit}},
+		}))
+
+		result, changed = revokePermission(both, devTeamA, nil)
+		Expect(changed).To(BeTrue())
+		Expect(result).To(Equal([]v1alpha1.FolderPermission{
+			{Subject: devTeamB, RoleRefs: []rbacv1.RoleRef{edit}},
+		}))
+
+		_, changed = revokePermission(both, devTeamB, &view)
+		Expect(changed).To(BeFalse())
+	})
+
+	It("should apply only the permissions, including an empty list", func() {
+		folder := &v1alpha1.NamespacedFolder{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "prod-web-apps", Name: "prod-web-app-a", ResourceVersion: "42",
+		}}
+
+		obj, err := permissionsApplyObject(folder, []v1alpha1.FolderPermission{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(obj.Object).To(Equal(map[string]interface{}{
+			"apiVersion": v1alpha1.GroupVersion.String(),
+			"kind":       "NamespacedFolder",
+			"metadata": map[string]interface{}{
+				"name":            "prod-web-app-a",
+				"namespace":       "prod-web-apps",
+				"resourceVersion": "42",
+			},
+			"spec": map[string]interface{}{
+				"folderPermissions": []interface{}{},
+			},
+		}))
+
+		obj, err = permissionsApplyObject(folder, permissions)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(obj.Object["spec"]).To(Equal(map[string]interface{}{
+			"folderPermissions": []interface{}{
+				map[string]interface{}{
+					"subject": map[string]interface{}{
+						"kind": "Group", "apiGroup": "rbac.authorization.k8s.io", "name": "dev-team-a",
+					},
+					"roleRefs": []interface{}{
+						map[string]interface{}{
+							"kind": "ClusterRole", "apiGroup": "rbac.authorization.k8s.io", "name": "view",
+						},
+					},
+				},
+			},
+		}))
+	})
+
+	It("should warn about roles that have no effect", func() {
+		_, objs := folderFixtureObjects()
+		objs = append(objs,
+			&rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: "configmap-reader"},
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"},
+				}},
+			},
+		)
+		cl := newStateClient(objs)
+
+		clusterFolder := objs[4].(client.Object)
+		namespacedFolder := objs[5].(client.Object)
+
+		warning, err := roleRefWarning(ctx, cl, namespacedFolder, view)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warning).To(BeEmpty())
+
+		warning, err = roleRefWarning(ctx, cl, namespacedFolder, rbacv1.RoleRef{Kind: "ClusterRole", Name: "configmap-reader"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warning).To(Equal("ClusterRole/configmap-reader has no KubeVirt rules, so the NamespacedFolder grants no access with it"))
+
+		// ClusterFolders bind any role in their namespaces
+		warning, err = roleRefWarning(ctx, cl, clusterFolder, rbacv1.RoleRef{Kind: "ClusterRole", Name: "configmap-reader"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warning).To(BeEmpty())
+
+		warning, err = roleRefWarning(ctx, cl, clusterFolder, edit)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warning).To(Equal("ClusterRole/edit does not exist"))
+
+		warning, err = roleRefWarning(ctx, cl, namespacedFolder, rbacv1.RoleRef{Kind: "Role", Name: "vm-operator"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warning).To(Equal("Role/vm-operator does not exist in namespace [prod-web-apps]"))
+	})
+	It("should not shadow the kubectl flags with subject flags", func() {
+		for _, cmd := range rootCmd.Commands() {
+			cmd.LocalNonPersistentFlags().VisitAll(func(flag *pflag.Flag) {
+				Expect(rootCmd.PersistentFlags().Lookup(flag.Name)).To(BeNil(),
+					"flag --%s of %s shadows the kubectl flag", flag.Name, cmd.Name())
+			})
+		}
+		Expect(newGrantCmd().Flags().Lookup("subject-user")).NotTo(BeNil())
+		Expect(newAccessCmd().Flags().Lookup("subject-group")).NotTo(BeNil())
+	})
+})
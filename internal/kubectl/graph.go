@@ -0,0 +1,203 @@
+package kubectl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+const (
+	graphClusterFolder    = "ClusterFolder"
+	graphNamespace        = "Namespace"
+	graphNamespacedFolder = "NamespacedFolder"
+	graphVM               = "VM"
+	graphSubject          = "Subject"
+)
+
+type graphNode struct {
+	id    string
+	kind  string
+	label string
+}
+
+// graphEdge is either a containment edge from a parent to its child, or,
+// when permission is set, an edge from a subject to the folder granting it
+// the roles in label.
+type graphEdge struct {
+	from       string
+	to         string
+	label      string
+	permission bool
+}
+
+// treeGraph is the tree model flattened into nodes and edges, in the same
+// order the text tree prints them.
+type treeGraph struct {
+	nodes []graphNode
+	edges []graphEdge
+	ids   map[string]string
+}
+
+// node returns the id of the node, adding it on first use.
+func (g *treeGraph) node(kind string, name string) string {
+	key := kind + "/" + name
+	if id, ok := g.ids[key]; ok {
+		return id
+	}
+	id := fmt.Sprintf("n%d", len(g.nodes))
+	g.ids[key] = id
+	g.nodes = append(g.nodes, graphNode{id: id, kind: kind, label: name})
+	return id
+}
+
+func (g *treeGraph) addPermissions(folderID string, permissions []v1alpha1.FolderPermission) {
+	subjects := map[string]rbacv1.Subject{}
+	roles := map[string][]string{}
+	for _, fp := range permissions {
+		key := formatSubject(fp.Subject)
+		subjects[key] = fp.Subject
+		for _, roleRef := range fp.RoleRefs {
+			roles[key] = append(roles[key], formatRoleRef(roleRef))
+		}
+	}
+
+	keys := []string{}
+	for key := range subjects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		subjectID := g.node(graphSubject, key)
+		g.edges = append(g.edges, graphEdge{from: subjectID, to: folderID, label: strings.Join(roles[key], ", "), permission: true})
+	}
+}
+
+func (g *treeGraph) addClusterFolder(data *printTreeData, name string) string {
+	id := g.node(graphClusterFolder, name)
+	if permissions, ok := data.clusterFolderPermissions[name]; ok {
+		g.addPermissions(id, permissions)
+	}
+
+	node, ok := data.root.Spec.ClusterFolderEntries[name]
+	if !ok {
+		return id
+	}
+	for _, ns := range node.Namespaces {
+		if _, exists := data.namespaceMap[ns]; !exists {
+			continue
+		}
+		g.edges = append(g.edges, graphEdge{from: id, to: g.addNamespace(data, ns)})
+	}
+	for _, child := range node.ChildFolders {
+		g.edges = append(g.edges, graphEdge{from: id, to: g.addClusterFolder(data, child)})
+	}
+	return id
+}
+
+func (g *treeGraph) addNamespace(data *printTreeData, namespace string) string {
+	id := g.node(graphNamespace, namespace)
+	for _, child := range data.namespaceRootFolders(namespace) {
+		g.edges = append(g.edges, graphEdge{from: id, to: g.addNamespacedFolder(data, child)})
+	}
+	for _, vm := range data.unfiledVMs(namespace) {
+		g.edges = append(g.edges, graphEdge{from: id, to: g.node(graphVM, namespace+"/"+vm)})
+	}
+	return id
+}
+
+func (g *treeGraph) addNamespacedFolder(data *printTreeData, key string) string {
+	id := g.node(graphNamespacedFolder, key)
+	if permissions, ok := data.namespacedFolderPermissions[key]; ok {
+		g.addPermissions(id, permissions)
+	}
+
+	node, ok := data.root.Spec.NamespacedFolderEntries[key]
+	if !ok {
+		return id
+	}
+	namespace := folderindex.NamespacedFolderNamespace(key)
+	for _, vm := range data.folderVMs(key) {
+		g.edges = append(g.edges, graphEdge{from: id, to: g.node(graphVM, namespace+"/"+vm)})
+	}
+	for _, child := range node.ChildFolders {
+		g.edges = append(g.edges, graphEdge{from: id, to: g.addNamespacedFolder(data, child)})
+	}
+	return id
+}
+
+// newTreeGraph walks the tree model the same way printTree does.
+func newTreeGraph(data *printTreeData) *treeGraph {
+	g := &treeGraph{ids: map[string]string{}}
+	for _, parent := range data.rootClusterFolders {
+		g.addClusterFolder(data, parent)
+	}
+	for _, ns := range data.rootNamespaces {
+		g.addNamespace(data, ns)
+	}
+	return g
+}
+
+var dotShapes = map[string]string{
+	graphClusterFolder:    "folder",
+	graphNamespace:        "box3d",
+	graphNamespacedFolder: "tab",
+	graphVM:               "ellipse",
+	graphSubject:          "hexagon",
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// writeDot renders the graph in the Graphviz DOT language.
+func writeDot(w io.Writer, g *treeGraph) {
+	fmt.Fprintln(w, "digraph folders {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	for _, node := range g.nodes {
+		fmt.Fprintf(w, "  %s [label=%s, shape=%s];\n", node.id, dotQuote(node.kind+": "+node.label), dotShapes[node.kind])
+	}
+	for _, edge := range g.edges {
+		if edge.permission {
+			fmt.Fprintf(w, "  %s -> %s [label=%s, style=dashed];\n", edge.from, edge.to, dotQuote(edge.label))
+		} else {
+			fmt.Fprintf(w, "  %s -> %s;\n", edge.from, edge.to)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// mermaidShapes are the opening and closing brackets of each node kind.
+var mermaidShapes = map[string][2]string{
+	graphClusterFolder:    {"[", "]"},
+	graphNamespace:        {"[[", "]]"},
+	graphNamespacedFolder: {"(", ")"},
+	graphVM:               {"([", "])"},
+	graphSubject:          {"{{", "}}"},
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
+
+// writeMermaid renders the graph as a Mermaid flowchart.
+func writeMermaid(w io.Writer, g *treeGraph) {
+	fmt.Fprintln(w, "flowchart LR")
+	for _, node := range g.nodes {
+		shape := mermaidShapes[node.kind]
+		fmt.Fprintf(w, "  %s%s%s%s\n", node.id, shape[0], mermaidQuote(node.kind+": "+node.label), shape[1])
+	}
+	for _, edge := range g.edges {
+		if edge.permission {
+			fmt.Fprintf(w, "  %s -.->|%s| %s\n", edge.from, mermaidQuote(edge.label), edge.to)
+		} else {
+			fmt.Fprintf(w, "  %s --> %s\n", edge.from, edge.to)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// importResult records what an import did to each object, and the
+// namespaces and VirtualMachines referenced by the import that do not exist
+// on the target cluster.
+type importResult struct {
+	actions  []string
+	warnings []string
+}
+
+// importOptions controls how references to missing namespaces and
+// VirtualMachines are handled.
+type importOptions struct {
+	// pruneMissing removes missing namespaces and VirtualMachines from the
+	// imported index instead of only reporting them.
+	pruneMissing bool
+}
+
+// splitImportObjects sorts an exported document into the root FolderIndex,
+// its folders and the FolderMemberships, rejecting anything else.
+func splitImportObjects(objs []client.Object) (*v1alpha1.FolderIndex, []*v1alpha1.ClusterFolder, []*v1alpha1.NamespacedFolder, []*v1alpha1.FolderMembership, error) {
+	var root *v1alpha1.FolderIndex
+	clusterFolders := []*v1alpha1.ClusterFolder{}
+	namespacedFolders := []*v1alpha1.NamespacedFolder{}
+	memberships := []*v1alpha1.FolderMembership{}
+
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *v1alpha1.FolderIndex:
+			if o.Name != folderindex.RootName {
+				return nil, nil, nil, nil, fmt.Errorf("unexpected folder index [%s], only [%s] is supported", o.Name, folderindex.RootName)
+			}
+			if root != nil {
+				return nil, nil, nil, nil, fmt.Errorf("folder index [%s] is defined more than once", o.Name)
+			}
+			root = o
+		case *v1alpha1.ClusterFolder:
+			clusterFolders = append(clusterFolders, o)
+		case *v1alpha1.NamespacedFolder:
+			namespacedFolders = append(namespacedFolders, o)
+		case *v1alpha1.FolderMembership:
+			memberships = append(memberships, o)
+		default:
+			return nil, nil, nil, nil, fmt.Errorf("unsupported object [%s], only FolderIndex, ClusterFolder, NamespacedFolder and FolderMembership can be imported", objectKey(obj))
+		}
+	}
+
+	if root == nil {
+		return nil, nil, nil, nil, fmt.Errorf("no folder index [%s] found", folderindex.RootName)
+	}
+
+	return root, clusterFolders, namespacedFolders, memberships, nil
+}
+
+// existingVMs returns the namespace/name of every VirtualMachine in the
+// cluster. A cluster without KubeVirt installed has none.
+func existingVMs(ctx context.Context, cl client.Reader) (map[string]bool, error) {
+	vms := map[string]bool{}
+
+	vmList := &virtv1.VirtualMachineList{}
+	if err := cl.List(ctx, vmList); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return vms, nil
+		}
+		return nil, fmt.Errorf("failed to list virtual machines: %v", err)
+	}
+	for _, vm := range vmList.Items {
+		vms[folderindex.NamespacedFolderKey(vm.Namespace, vm.Name)] = true
+	}
+
+	return vms, nil
+}
+
+// checkImportedIndex reports the namespaces, namespaced folders and
+// VirtualMachines the index and the memberships reference that do not exist
+// on the cluster, and prunes them from the index when requested. It returns
+// the namespaces that are missing and the memberships to import.
+func checkImportedIndex(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, memberships []*v1alpha1.FolderMembership, opts importOptions, result *importResult) (map[string]bool, []*v1alpha1.FolderMembership, error) {
+	namespaceList := &corev1.NamespaceList{}
+	if err := cl.List(ctx, namespaceList); err != nil {
+		return nil, nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+	existingNamespaces := map[string]bool{}
+	for _, ns := range namespaceList.Items {
+		existingNamespaces[ns.Name] = true
+	}
+
+	vms, err := existingVMs(ctx, cl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	missingNamespaces := map[string]bool{}
+
+	folderNames := []string{}
+	for name := range root.Spec.ClusterFolderEntries {
+		folderNames = append(folderNames, name)
+	}
+	sort.Strings(folderNames)
+	for _, name := range folderNames {
+		entry := root.Spec.ClusterFolderEntries[name]
+		for _, ns := range entry.Namespaces {
+			if existingNamespaces[ns] {
+				continue
+			}
+			missingNamespaces[ns] = true
+			result.warnings = append(result.warnings, fmt.Sprintf("namespace [%s] in ClusterFolder [%s] does not exist", ns, name))
+			if opts.pruneMissing {
+				entry.Namespaces = folderindex.Remove(entry.Namespaces, ns)
+			}
+		}
+		root.Spec.ClusterFolderEntries[name] = entry
+	}
+
+	keys := []string{}
+	for key := range root.Spec.NamespacedFolderEntries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		ns, _, err := folderindex.SplitNamespacedFolderKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !existingNamespaces[ns] {
+			missingNamespaces[ns] = true
+			result.warnings = append(result.warnings, fmt.Sprintf("namespace [%s] of NamespacedFolder [%s] does not exist", ns, key))
+			if opts.pruneMissing {
+				delete(root.Spec.NamespacedFolderEntries, key)
+				for parentKey, parent := range root.Spec.NamespacedFolderEntries {
+					parent.ChildFolders = folderindex.Remove(parent.ChildFolders, key)
+					root.Spec.NamespacedFolderEntries[parentKey] = parent
+				}
+			}
+			continue
+		}
+
+		entry := root.Spec.NamespacedFolderEntries[key]
+		for _, vm := range entry.VirtualMachines {
+			if vms[folderindex.NamespacedFolderKey(ns, vm)] {
+				continue
+			}
+			result.warnings = append(result.warnings, fmt.Sprintf("vm [%s/%s] in NamespacedFolder [%s] does not exist", ns, vm, key))
+			if opts.pruneMissing {
+				entry.VirtualMachines = folderindex.Remove(entry.VirtualMachines, vm)
+			}
+		}
+		root.Spec.NamespacedFolderEntries[key] = entry
+	}
+
+	imported := []*v1alpha1.FolderMembership{}
+	for _, membership := range memberships {
+		key := folderindex.NamespacedFolderKey(membership.Namespace, membership.Name)
+		if !existingNamespaces[membership.Namespace] {
+			missingNamespaces[membership.Namespace] = true
+			result.warnings = append(result.warnings, fmt.Sprintf("namespace [%s] of FolderMembership [%s] does not exist", membership.Namespace, key))
+		} else if !vms[key] {
+			result.warnings = append(result.warnings, fmt.Sprintf("vm [%s] filed by a FolderMembership does not exist", key))
+			if opts.pruneMissing {
+				continue
+			}
+		}
+		imported = append(imported, membership)
+	}
+
+	return missingNamespaces, imported, nil
+}
+
+// applyImportedObject creates the folder or index, or updates the spec of
+// the existing one when it differs.
+func applyImportedObject(ctx context.Context, cl client.Client, obj client.Object) (string, error) {
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+
+	existing := obj.DeepCopyObject().(client.Object)
+	err := cl.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if errors.IsNotFound(err) {
+		if err := cl.Create(ctx, obj); err != nil {
+			return "", err
+		}
+		return "created", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if equality.Semantic.DeepEqual(folderSpec(existing), folderSpec(obj)) {
+		return "unchanged", nil
+	}
+
+	// Only the spec is replaced, metadata such as labels added on the target
+	// cluster is preserved.
+	updated := existing.DeepCopyObject().(client.Object)
+	switch u := updated.(type) {
+	case *v1alpha1.FolderIndex:
+		u.Spec = obj.(*v1alpha1.FolderIndex).Spec
+	case *v1alpha1.ClusterFolder:
+		u.Spec = obj.(*v1alpha1.ClusterFolder).Spec
+	case *v1alpha1.NamespacedFolder:
+		u.Spec = obj.(*v1alpha1.NamespacedFolder).Spec
+	case *v1alpha1.FolderMembership:
+		u.Spec = obj.(*v1alpha1.FolderMembership).Spec
+	}
+	if err := cl.Update(ctx, updated); err != nil {
+		return "", err
+	}
+	return "updated", nil
+}
+
+func folderSpec(obj client.Object) any {
+	switch o := obj.(type) {
+	case *v1alpha1.FolderIndex:
+		return &o.Spec
+	case *v1alpha1.ClusterFolder:
+		return &o.Spec
+	case *v1alpha1.NamespacedFolder:
+		return &o.Spec
+	case *v1alpha1.FolderMembership:
+		return &o.Spec
+	}
+	return nil
+}
+
+// importFolders recreates an exported folder hierarchy. Running it again
+// with the same document changes nothing. Folders are applied before the
+// index so the controllers find every folder the index references, and
+// memberships after it, so the folders they name are in the index.
+func importFolders(ctx context.Context, cl client.Client, objs []client.Object, opts importOptions) (*importResult, error) {
+	result := &importResult{}
+
+	root, clusterFolders, namespacedFolders, memberships, err := splitImportObjects(objs)
+	if err != nil {
+		return result, err
+	}
+
+	root = root.DeepCopy()
+	if root.Spec.ClusterFolderEntries == nil {
+		root.Spec.ClusterFolderEntries = map[string]v1alpha1.ClusterFolderEntry{}
+	}
+	if root.Spec.NamespacedFolderEntries == nil {
+		root.Spec.NamespacedFolderEntries = map[string]v1alpha1.NamespacedFolderEntry{}
+	}
+
+	missingNamespaces, memberships, err := checkImportedIndex(ctx, cl, root, memberships, opts, result)
+	if err != nil {
+		return result, err
+	}
+
+	for _, folder := range clusterFolders {
+		action, err := applyImportedObject(ctx, cl, folder.DeepCopy())
+		if err != nil {
+			return result, fmt.Errorf("failed to import ClusterFolder [%s]: %v", folder.Name, err)
+		}
+		result.actions = append(result.actions, fmt.Sprintf("ClusterFolder [%s] %s", folder.Name, action))
+	}
+
+	for _, folder := range namespacedFolders {
+		key := folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)
+		if missingNamespaces[folder.Namespace] {
+			result.actions = append(result.actions, fmt.Sprintf("NamespacedFolder [%s] skipped", key))
+			continue
+		}
+		action, err := applyImportedObject(ctx, cl, folder.DeepCopy())
+		if err != nil {
+			return result, fmt.Errorf("failed to import NamespacedFolder [%s]: %v", key, err)
+		}
+		result.actions = append(result.actions, fmt.Sprintf("NamespacedFolder [%s] %s", key, action))
+	}
+
+	action, err := applyImportedObject(ctx, cl, root)
+	if err != nil {
+		return result, fmt.Errorf("failed to import folder index [%s]: %v", root.Name, err)
+	}
+	result.actions = append(result.actions, fmt.Sprintf("FolderIndex [%s] %s", root.Name, action))
+
+	for _, membership := range memberships {
+		key := folderindex.NamespacedFolderKey(membership.Namespace, membership.Name)
+		if missingNamespaces[membership.Namespace] {
+			result.actions = append(result.actions, fmt.Sprintf("FolderMembership [%s] skipped", key))
+			continue
+		}
+		action, err := applyImportedObject(ctx, cl, membership.DeepCopy())
+		if err != nil {
+			return result, fmt.Errorf("failed to import FolderMembership [%s]: %v", key, err)
+		}
+		result.actions = append(result.actions, fmt.Sprintf("FolderMembership [%s] %s", key, action))
+	}
+
+	return result, nil
+}
+
+func newImportCmd() *cobra.Command {
+	var filename string
+	var opts importOptions
+
+	cmd := &cobra.Command{
+		Use:   "import -f FILE",
+		Short: "Recreate a folder hierarchy written by export",
+		Long: `Recreate a folder hierarchy written by export.
+
+Folders that do not exist are created and folders whose spec differs are
+updated, so importing the same document again changes nothing. The root
+FolderIndex is applied last.
+
+Namespaces and VirtualMachines referenced by the document that do not exist
+on the cluster are reported. They are kept in the index, and their
+FolderMemberships are created, so they gain folder permissions once they are
+created, unless --prune-missing is given. NamespacedFolders and
+FolderMemberships in missing namespaces cannot be created and are skipped.`,
+		Example: `  kubectl folder export -f folders.yaml
+  kubectl folder import -f folders.yaml
+  kubectl folder import -f folders.yaml --prune-missing`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			objs, err := readManifests(filename)
+			if err != nil {
+				return err
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			result, err := importFolders(ctx, cl, objs, opts)
+			for _, warning := range result.warnings {
+				fmt.Fprintf(cmd.OutOrStdout(), "warning: %s\n", warning)
+			}
+			for _, action := range result.actions {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\n", action)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "File written by export, - reads from stdin")
+	cmd.Flags().BoolVar(&opts.pruneMissing, "prune-missing", false, "Remove namespaces and VirtualMachines that do not exist on the cluster from the index")
+	_ = cmd.MarkFlagRequired("filename")
+
+	return cmd
+}
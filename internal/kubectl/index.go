@@ -0,0 +1,160 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// newClient returns a client for the cluster selected by the standard
+// kubectl flags.
+func newClient() (client.Client, error) {
+	cfg, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{})
+}
+
+func getRootIndex(ctx context.Context, cl client.Reader) (*v1alpha1.FolderIndex, error) {
+	root := &v1alpha1.FolderIndex{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		return nil, fmt.Errorf("failed to find root folder index: %v", err)
+	}
+	if root.Spec.ClusterFolderEntries == nil {
+		root.Spec.ClusterFolderEntries = map[string]v1alpha1.ClusterFolderEntry{}
+	}
+	if root.Spec.NamespacedFolderEntries == nil {
+		root.Spec.NamespacedFolderEntries = map[string]v1alpha1.NamespacedFolderEntry{}
+	}
+	return root, nil
+}
+
+// patchRootIndex applies the difference between orig and modified to the root
+// FolderIndex. The patch carries the resourceVersion of orig so that it is
+// rejected if the index changed after it was read, rather than silently
+// overwriting someone else's edit.
+func patchRootIndex(ctx context.Context, cl client.Client, orig *v1alpha1.FolderIndex, modified *v1alpha1.FolderIndex) error {
+	patch := client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})
+	if err := cl.Patch(ctx, modified, patch); err != nil {
+		return fmt.Errorf("failed to update root folder index: %v", err)
+	}
+	return nil
+}
+
+// dryRunFolderView sends the update of the root FolderIndex from orig to
+// modified and the update of the changed memberships as dry runs. The webhooks
+// check every object without anything being persisted, so a change one of
+// them rejects, such as filing VMs past the limits of a folder, can fail
+// before any of the writes is made.
+func dryRunFolderView(ctx context.Context, cl client.Client, orig *v1alpha1.FolderIndex, modified *v1alpha1.FolderIndex, changed []v1alpha1.FolderMembership) error {
+	patch := client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})
+	if err := cl.Patch(ctx, modified.DeepCopy(), patch, client.DryRunAll); err != nil {
+		return fmt.Errorf("folder index update rejected: %v", err)
+	}
+	for i := range changed {
+		if err := cl.Update(ctx, changed[i].DeepCopy(), client.DryRunAll); err != nil {
+			return fmt.Errorf("folder membership [%s/%s] update rejected: %v", changed[i].Namespace, changed[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// listFolderMemberships returns the FolderMemberships in the cluster. A
+// cluster without the FolderMembership CRD has none.
+func listFolderMemberships(ctx context.Context, cl client.Reader) ([]v1alpha1.FolderMembership, error) {
+	list := &v1alpha1.FolderMembershipList{}
+	if err := cl.List(ctx, list); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list folder memberships: %v", err)
+	}
+	return list.Items, nil
+}
+
+// getFolderView returns the root FolderIndex without the members it flags as
+// deleted and with the VirtualMachines of the FolderMemberships filed into
+// it, which is the tree the controllers grant access by. It is for reading
+// only, updates go through updateRootIndex.
+func getFolderView(ctx context.Context, cl client.Reader) (*v1alpha1.FolderIndex, error) {
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	folderindex.RemoveDeletedMembers(root)
+	memberships, err := listFolderMemberships(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	folderindex.AddMemberships(root, memberships)
+	return root, nil
+}
+
+// changeFolderView files the memberships into a copy of root and applies
+// change to it. It returns the folder view before and after the change.
+func changeFolderView(root *v1alpha1.FolderIndex, memberships []v1alpha1.FolderMembership, change func(root *v1alpha1.FolderIndex) error) (*v1alpha1.FolderIndex, *v1alpha1.FolderIndex, error) {
+	before := root.DeepCopy()
+	folderindex.AddMemberships(before, memberships)
+	view := before.DeepCopy()
+	if err := change(view); err != nil {
+		return nil, nil, err
+	}
+	return before, view, nil
+}
+
+// splitFolderView takes the VirtualMachines of the memberships back out of a
+// folder view changed from before. It returns the root FolderIndex without
+// them, the memberships the view files into another folder, and the
+// memberships of VirtualMachines the change unfiled.
+func splitFolderView(before *v1alpha1.FolderIndex, view *v1alpha1.FolderIndex, memberships []v1alpha1.FolderMembership) (*v1alpha1.FolderIndex, []v1alpha1.FolderMembership, []v1alpha1.FolderMembership) {
+	root := view.DeepCopy()
+	changed := []v1alpha1.FolderMembership{}
+	deleted := []v1alpha1.FolderMembership{}
+
+	for _, membership := range memberships {
+		folderKey, exists := folderindex.VirtualMachineParent(root, membership.Namespace, membership.Name)
+		if !exists {
+			if _, filed := folderindex.VirtualMachineParent(before, membership.Namespace, membership.Name); filed {
+				deleted = append(deleted, membership)
+			}
+			continue
+		}
+
+		entry := root.Spec.NamespacedFolderEntries[folderKey]
+		entry.VirtualMachines = folderindex.Remove(entry.VirtualMachines, membership.Name)
+		root.Spec.NamespacedFolderEntries[folderKey] = entry
+
+		_, folder, err := folderindex.SplitNamespacedFolderKey(folderKey)
+		if err == nil && folder != membership.Spec.Folder {
+			updated := membership.DeepCopy()
+			updated.Spec.Folder = folder
+			changed = append(changed, *updated)
+		}
+	}
+	return root, changed, deleted
+}
+
+// updateMemberships writes the memberships a change moved and deletes the
+// ones it unfiled. Both carry the resourceVersion they were read at, so a
+// concurrent edit fails the update.
+func updateMemberships(ctx context.Context, cl client.Client, changed []v1alpha1.FolderMembership, deleted []v1alpha1.FolderMembership) error {
+	for i := range changed {
+		if err := cl.Update(ctx, &changed[i]); err != nil {
+			return fmt.Errorf("failed to update folder membership [%s/%s]: %v", changed[i].Namespace, changed[i].Name, err)
+		}
+	}
+	for i := range deleted {
+		resourceVersion := deleted[i].ResourceVersion
+		if err := cl.Delete(ctx, &deleted[i], client.Preconditions{ResourceVersion: &resourceVersion}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete folder membership [%s/%s]: %v", deleted[i].Namespace, deleted[i].Name, err)
+		}
+	}
+	return nil
+}
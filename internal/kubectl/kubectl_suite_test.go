@@ -0,0 +1,17 @@
+package kubectl
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// These tests use Ginkgo (BDD-style Go testing framework). Refer to
+// http://onsi.github.io/ginkgo/ to learn more about Ginkgo.
+
+func TestKubectl(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Kubectl Suite")
+}
@@ -0,0 +1,682 @@
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// Lint levels, named after the SARIF result levels.
+const (
+	lintWarning = "warning"
+	lintNote    = "note"
+)
+
+// lintRule is a single check lint performs.
+type lintRule struct {
+	id          string
+	level       string
+	description string
+}
+
+var lintRules = []lintRule{
+	{id: "index-entry-without-folder", level: lintWarning,
+		description: "The folder index references a folder that does not exist, so no permissions are applied for it"},
+	{id: "folder-not-indexed", level: lintWarning,
+		description: "A folder exists that the folder index does not reference, so its permissions apply to nothing"},
+	{id: "empty-folder", level: lintNote,
+		description: "A folder contains no folders, namespaces or VirtualMachines"},
+	{id: "missing-namespace", level: lintWarning,
+		description: "The folder index references a namespace that does not exist"},
+	{id: "missing-role", level: lintWarning,
+		description: "A folder permission references a Role or ClusterRole that does not exist"},
+	{id: "no-kubevirt-rules", level: lintWarning,
+		description: "A NamespacedFolder permission references a role without KubeVirt rules, so no Role is generated for it"},
+	{id: "redundant-permission", level: lintNote,
+		description: "A folder permission is already inherited from an ancestor folder"},
+	{id: "ineffective-block", level: lintWarning,
+		description: "A NamespacedFolder blocks inheritance, but ClusterFolder permissions still reach its VirtualMachines across the whole namespace"},
+	{id: "deep-tree", level: lintWarning,
+		description: "A folder is nested deeper than the maximum depth"},
+}
+
+func lintRuleFor(id string) lintRule {
+	for _, rule := range lintRules {
+		if rule.id == id {
+			return rule
+		}
+	}
+	panic(fmt.Sprintf("unknown lint rule %s", id))
+}
+
+// lintFinding is a problem lint found in the folder hierarchy. Object is
+// formatted like the folder of a grant, e.g. ClusterFolder/operations or
+// NamespacedFolder/prod-web-apps/prod-web-app-a.
+type lintFinding struct {
+	Rule    string `json:"rule"`
+	Level   string `json:"level"`
+	Object  string `json:"object"`
+	Message string `json:"message"`
+}
+
+type lintOptions struct {
+	maxDepth int
+	// offline skips the checks that need Namespaces and roles, which a file
+	// written by export does not contain.
+	offline bool
+}
+
+type linter struct {
+	ctx  context.Context
+	cl   client.Reader
+	opts lintOptions
+
+	root              *v1alpha1.FolderIndex
+	clusterFolders    map[string]*v1alpha1.ClusterFolder
+	namespacedFolders map[string]*v1alpha1.NamespacedFolder
+	namespaces        map[string]bool
+
+	findings []lintFinding
+}
+
+func (l *linter) report(rule string, object string, format string, args ...any) {
+	l.findings = append(l.findings, lintFinding{
+		Rule:    rule,
+		Level:   lintRuleFor(rule).level,
+		Object:  object,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// lintFolders reports the soft problems in the folder hierarchy that the
+// FolderIndex webhook accepts, sorted by object.
+func lintFolders(ctx context.Context, cl client.Reader, opts lintOptions) ([]lintFinding, error) {
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	// members flagged as deleted get nothing from their folders, lint the
+	// index the controllers apply
+	folderindex.RemoveDeletedMembers(root)
+
+	l := &linter{
+		ctx:               ctx,
+		cl:                cl,
+		opts:              opts,
+		root:              root,
+		clusterFolders:    map[string]*v1alpha1.ClusterFolder{},
+		namespacedFolders: map[string]*v1alpha1.NamespacedFolder{},
+		namespaces:        map[string]bool{},
+	}
+
+	clusterFolderList := &v1alpha1.ClusterFolderList{}
+	if err := cl.List(ctx, clusterFolderList); err != nil {
+		return nil, fmt.Errorf("failed to list cluster folders: %v", err)
+	}
+	for i := range clusterFolderList.Items {
+		folder := &clusterFolderList.Items[i]
+		l.clusterFolders[folder.Name] = folder
+	}
+
+	namespacedFolderList := &v1alpha1.NamespacedFolderList{}
+	if err := cl.List(ctx, namespacedFolderList); err != nil {
+		return nil, fmt.Errorf("failed to list namespaced folders: %v", err)
+	}
+	for i := range namespacedFolderList.Items {
+		folder := &namespacedFolderList.Items[i]
+		l.namespacedFolders[folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)] = folder
+	}
+
+	if !opts.offline {
+		namespaceList := &corev1.NamespaceList{}
+		if err := cl.List(ctx, namespaceList); err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %v", err)
+		}
+		for _, ns := range namespaceList.Items {
+			l.namespaces[ns.Name] = true
+		}
+	}
+
+	l.lintIndex()
+	if err := l.lintClusterFolders(); err != nil {
+		return nil, err
+	}
+	if err := l.lintNamespacedFolders(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Object < l.findings[j].Object
+	})
+	return l.findings, nil
+}
+
+// lintIndex checks the entries of the index against the folder objects and
+// namespaces, and the depth of the tree.
+func (l *linter) lintIndex() {
+	for _, folder := range slices.Sorted(maps.Keys(l.root.Spec.ClusterFolderEntries)) {
+		entry := l.root.Spec.ClusterFolderEntries[folder]
+		object := "ClusterFolder/" + folder
+
+		if _, exists := l.clusterFolders[folder]; !exists {
+			l.report("index-entry-without-folder", object, "ClusterFolder [%s] is in the folder index but does not exist", folder)
+		}
+		if len(entry.ChildFolders) == 0 && len(entry.Namespaces) == 0 {
+			l.report("empty-folder", object, "ClusterFolder [%s] contains no folders or namespaces", folder)
+		}
+		if !l.opts.offline {
+			for _, namespace := range entry.Namespaces {
+				if !l.namespaces[namespace] {
+					l.report("missing-namespace", object, "namespace [%s] in ClusterFolder [%s] does not exist", namespace, folder)
+				}
+			}
+		}
+
+		depth := len(folderindex.ClusterFolderAncestry(l.root, folder))
+		l.lintDepth(object, depth)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(l.root.Spec.NamespacedFolderEntries)) {
+		entry := l.root.Spec.NamespacedFolderEntries[key]
+		object := "NamespacedFolder/" + key
+
+		if _, exists := l.namespacedFolders[key]; !exists {
+			l.report("index-entry-without-folder", object, "NamespacedFolder [%s] is in the folder index but does not exist", key)
+		}
+		if len(entry.ChildFolders) == 0 && len(entry.VirtualMachines) == 0 {
+			l.report("empty-folder", object, "NamespacedFolder [%s] contains no folders or VirtualMachines", key)
+		}
+
+		namespace, _, err := folderindex.SplitNamespacedFolderKey(key)
+		if err != nil {
+			continue
+		}
+		if !l.opts.offline && !l.namespaces[namespace] {
+			l.report("missing-namespace", object, "namespace [%s] of NamespacedFolder [%s] does not exist", namespace, key)
+		}
+
+		depth := len(folderindex.NamespacedFolderAncestry(l.root, key))
+		if parent, ok := folderindex.NamespaceParent(l.root, namespace); ok {
+			depth += len(folderindex.ClusterFolderAncestry(l.root, parent))
+		}
+		l.lintDepth(object, depth)
+	}
+}
+
+// lintDepth reports a folder deeper than the maximum depth only when its
+// parent is not, so a deep branch is reported once rather than for every
+// folder below the limit.
+func (l *linter) lintDepth(object string, depth int) {
+	if l.opts.maxDepth > 0 && depth == l.opts.maxDepth+1 {
+		l.report("deep-tree", object, "%s is nested %d folders deep, more than the maximum of %d",
+			strings.SplitN(object, "/", 2)[1], depth, l.opts.maxDepth)
+	}
+}
+
+func (l *linter) lintClusterFolders() error {
+	for _, name := range slices.Sorted(maps.Keys(l.clusterFolders)) {
+		folder := l.clusterFolders[name]
+		object := "ClusterFolder/" + name
+
+		if _, exists := l.root.Spec.ClusterFolderEntries[name]; !exists {
+			l.report("folder-not-indexed", object, "ClusterFolder [%s] is not in the folder index", name)
+			continue
+		}
+
+		ancestors := folderindex.InheritedAncestry(folderindex.ClusterFolderAncestry(l.root, name), l.blockedClusterFolders())[1:]
+		namespaces := folderindex.GetAllNamespaces(l.root, name)
+		for _, fp := range folder.Spec.FolderPermissions {
+			for _, roleRef := range fp.RoleRefs {
+				if from, ok := l.inheritedFrom(fp.Subject, roleRef, ancestors, false, nil); ok {
+					l.report("redundant-permission", object, "%s is already granted %s by %s",
+						formatSubject(fp.Subject), formatRoleRef(roleRef), from)
+				}
+				if l.opts.offline {
+					continue
+				}
+
+				if roleRef.Kind != "Role" {
+					if _, err := l.lintRoleRef(object, "", roleRef); err != nil {
+						return err
+					}
+					continue
+				}
+				// ClusterFolders bind a Role in every namespace they hold
+				for _, namespace := range namespaces {
+					if !l.namespaces[namespace] {
+						continue
+					}
+					if _, err := l.lintRoleRef(object, namespace, roleRef); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (l *linter) lintNamespacedFolders() error {
+	for _, key := range slices.Sorted(maps.Keys(l.namespacedFolders)) {
+		folder := l.namespacedFolders[key]
+		object := "NamespacedFolder/" + key
+
+		if _, exists := l.root.Spec.NamespacedFolderEntries[key]; !exists {
+			l.report("folder-not-indexed", object, "NamespacedFolder [%s] is not in the folder index", key)
+			continue
+		}
+
+		ancestors := folderindex.InheritedAncestry(folderindex.NamespacedFolderAncestry(l.root, key), l.blockedNamespacedFolders())[1:]
+		clusterAncestors := []string{}
+		if parent, ok := folderindex.NamespaceParent(l.root, folder.Namespace); ok {
+			clusterAncestors = folderindex.InheritedAncestry(folderindex.ClusterFolderAncestry(l.root, parent), l.blockedClusterFolders())
+		}
+
+		if folder.Spec.Inheritance != nil && folder.Spec.Inheritance.Block {
+			l.lintBlock(object, key, clusterAncestors)
+		}
+
+		for _, fp := range folder.Spec.FolderPermissions {
+			for _, roleRef := range fp.RoleRefs {
+				if from, ok := l.inheritedFrom(fp.Subject, roleRef, clusterAncestors, true, ancestors); ok {
+					l.report("redundant-permission", object, "%s is already granted %s by %s",
+						formatSubject(fp.Subject), formatRoleRef(roleRef), from)
+				}
+				if l.opts.offline || !l.namespaces[folder.Namespace] {
+					continue
+				}
+
+				rules, err := l.lintRoleRef(object, folder.Namespace, roleRef)
+				if err != nil {
+					return err
+				}
+				if rules != nil && len(controller.FilterKubeVirtRules(rules, []string{"vm"})) == 0 {
+					l.report("no-kubevirt-rules", object, "%s has no KubeVirt rules, so no access is granted to %s by it",
+						formatRoleRef(roleRef), formatSubject(fp.Subject))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// lintBlock reports the ClusterFolders of clusterAncestors whose permissions
+// reach the namespace of the blocking NamespacedFolder key, as blocking cannot
+// take away access granted across the whole namespace. The first of
+// clusterAncestors holds the namespace and grants it all of its permissions.
+func (l *linter) lintBlock(object string, key string, clusterAncestors []string) {
+	namespace := folderindex.NamespacedFolderNamespace(key)
+	for i, name := range clusterAncestors {
+		folder, exists := l.clusterFolders[name]
+		if !exists {
+			continue
+		}
+		subjects := []string{}
+		for _, fp := range folderindex.Permissions(folder.Spec.FolderPermissions, i > 0) {
+			if subject := formatSubject(fp.Subject); !slices.Contains(subjects, subject) {
+				subjects = append(subjects, subject)
+			}
+		}
+		if len(subjects) != 0 {
+			l.report("ineffective-block", object, "NamespacedFolder [%s] blocks inheritance, but ClusterFolder [%s] still grants %s access to all of namespace [%s]",
+				key, name, strings.Join(subjects, ", "), namespace)
+		}
+	}
+}
+
+// lintRoleRef reports a roleRef that does not resolve to a role and returns
+// the rules of the role otherwise. Roles are looked up in namespace.
+func (l *linter) lintRoleRef(object string, namespace string, roleRef rbacv1.RoleRef) ([]rbacv1.PolicyRule, error) {
+	var obj client.Object
+	var key client.ObjectKey
+	switch roleRef.Kind {
+	case "ClusterRole":
+		obj = &rbacv1.ClusterRole{}
+		key = client.ObjectKey{Name: roleRef.Name}
+	case "Role":
+		obj = &rbacv1.Role{}
+		key = client.ObjectKey{Name: roleRef.Name, Namespace: namespace}
+	default:
+		l.report("missing-role", object, "roleRef [%s] is not a Role or ClusterRole", formatRoleRef(roleRef))
+		return nil, nil
+	}
+
+	if err := l.cl.Get(l.ctx, key, obj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get %s: %v", formatRoleRef(roleRef), err)
+		}
+		if namespace != "" {
+			l.report("missing-role", object, "%s does not exist in namespace [%s]", formatRoleRef(roleRef), namespace)
+		} else {
+			l.report("missing-role", object, "%s does not exist", formatRoleRef(roleRef))
+		}
+		return nil, nil
+	}
+
+	switch role := obj.(type) {
+	case *rbacv1.ClusterRole:
+		return role.Rules, nil
+	case *rbacv1.Role:
+		return role.Rules, nil
+	}
+	return nil, nil
+}
+
+// inheritedFrom returns the first ancestor folder that passes on to its
+// descendants a grant of the same roleRef to subject. holdsNamespace is set
+// when the first of clusterAncestors holds the namespace of a
+// NamespacedFolder, and grants it all of its permissions.
+func (l *linter) inheritedFrom(subject rbacv1.Subject, roleRef rbacv1.RoleRef, clusterAncestors []string, holdsNamespace bool, namespacedAncestors []string) (string, bool) {
+	same := func(permissions []v1alpha1.FolderPermission) bool {
+		for _, fp := range permissions {
+			if formatSubject(fp.Subject) != formatSubject(subject) {
+				continue
+			}
+			for _, rr := range fp.RoleRefs {
+				if rr.Kind == roleRef.Kind && rr.Name == roleRef.Name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, key := range namespacedAncestors {
+		if folder, exists := l.namespacedFolders[key]; exists && same(folderindex.Permissions(folder.Spec.FolderPermissions, true)) {
+			return "NamespacedFolder/" + key, true
+		}
+	}
+	for i, name := range clusterAncestors {
+		// the ClusterFolder holding the namespace grants it all of its
+		// permissions
+		inherited := i > 0 || !holdsNamespace
+		if folder, exists := l.clusterFolders[name]; exists && same(folderindex.Permissions(folder.Spec.FolderPermissions, inherited)) {
+			return "ClusterFolder/" + name, true
+		}
+	}
+	return "", false
+}
+
+// blockedClusterFolders returns the names of the ClusterFolders that block
+// the permissions of their ancestors.
+func (l *linter) blockedClusterFolders() map[string]bool {
+	blocked := map[string]bool{}
+	for name, folder := range l.clusterFolders {
+		if folder.Spec.Inheritance != nil && folder.Spec.Inheritance.Block {
+			blocked[name] = true
+		}
+	}
+	return blocked
+}
+
+// blockedNamespacedFolders returns the keys of the NamespacedFolders that
+// block the permissions of their ancestors.
+func (l *linter) blockedNamespacedFolders() map[string]bool {
+	blocked := map[string]bool{}
+	for key, folder := range l.namespacedFolders {
+		if folder.Spec.Inheritance != nil && folder.Spec.Inheritance.Block {
+			blocked[key] = true
+		}
+	}
+	return blocked
+}
+
+// lintOutputFormats are the values accepted by lint -o.
+var lintOutputFormats = []string{"text", "json", "sarif"}
+
+func printLintText(w io.Writer, findings []lintFinding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No problems found")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LEVEL\tRULE\tOBJECT\tMESSAGE")
+	for _, finding := range findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", finding.Level, finding.Rule, finding.Object, finding.Message)
+	}
+	tw.Flush()
+}
+
+func printLintJSON(w io.Writer, findings []lintFinding) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
+
+// The subset of SARIF 2.1.0 lint writes. Findings are reported against
+// logical locations, the folders, and against the manifest file when the
+// folders were read from one.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// printLintSARIF writes the findings as a SARIF log. file is the manifest
+// the folders were read from, if any.
+func printLintSARIF(w io.Writer, findings []lintFinding, file string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:  "kubectl-folder-lint",
+			Rules: []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	for _, rule := range lintRules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.id,
+			ShortDescription:     sarifMessage{Text: rule.description},
+			DefaultConfiguration: sarifConfiguration{Level: rule.level},
+		})
+	}
+
+	for _, finding := range findings {
+		location := sarifLocation{
+			LogicalLocations: []sarifLogicalLocation{{
+				Name:               strings.SplitN(finding.Object, "/", 2)[1],
+				FullyQualifiedName: finding.Object,
+				Kind:               "resource",
+			}},
+		}
+		if file != "" {
+			location.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: file},
+			}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    finding.Rule,
+			Level:     finding.Level,
+			Message:   sarifMessage{Text: finding.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// lintFailOnLevels are the values accepted by lint --fail-on.
+var lintFailOnLevels = []string{lintWarning, lintNote, "none"}
+
+// lintFailures returns the number of findings at or above the failOn level.
+func lintFailures(findings []lintFinding, failOn string) int {
+	failures := 0
+	for _, finding := range findings {
+		if failOn == lintNote || (failOn == lintWarning && finding.Level == lintWarning) {
+			failures++
+		}
+	}
+	return failures
+}
+
+func newLintCmd() *cobra.Command {
+	var output string
+	var fromFile string
+	var failOn string
+	var maxDepth int
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Report problems in the folder hierarchy",
+		Long: `Report problems in the folder hierarchy.
+
+The FolderIndex webhook rejects an index that is inconsistent. lint reports
+the problems it accepts:
+
+  index-entry-without-folder  an index entry whose folder does not exist
+  folder-not-indexed          a folder that is not in the index
+  empty-folder                a folder without folders, namespaces or VMs
+  missing-namespace           a namespace in the index that does not exist
+  missing-role                a permission referencing a missing role
+  no-kubevirt-rules           a NamespacedFolder permission whose role has no
+                              KubeVirt rules, which generates no Role
+  redundant-permission        a permission already granted by an ancestor
+  ineffective-block           a NamespacedFolder blocking inheritance whose
+                              namespace ClusterFolder permissions still reach
+  deep-tree                   a folder nested deeper than --max-depth
+
+-o json and -o sarif write machine readable results for CI. The command
+fails when a finding is at or above the --fail-on level.
+
+--from-file lints a file written by export instead of the cluster. As it holds
+no Namespaces or roles, the missing-namespace, missing-role and
+no-kubevirt-rules checks are skipped.`,
+		Example: `  kubectl folder lint
+  kubectl folder lint -o sarif --from-file folders.yaml > folders.sarif
+  kubectl folder lint --fail-on none --max-depth 4`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			if !slices.Contains(lintOutputFormats, output) {
+				return fmt.Errorf("unsupported output format [%s], expected one of %s", output, strings.Join(lintOutputFormats, ", "))
+			}
+			if !slices.Contains(lintFailOnLevels, failOn) {
+				return fmt.Errorf("unsupported --fail-on level [%s], expected one of %s", failOn, strings.Join(lintFailOnLevels, ", "))
+			}
+
+			var cl client.Reader
+			if fromFile != "" {
+				objs, err := readManifests(fromFile)
+				if err != nil {
+					return err
+				}
+				cl = newStateReader(objs)
+			} else {
+				var err error
+				cl, err = newClient()
+				if err != nil {
+					return fmt.Errorf("failed to create client: %v", err)
+				}
+			}
+
+			findings, err := lintFolders(ctx, cl, lintOptions{maxDepth: maxDepth, offline: fromFile != ""})
+			if err != nil {
+				return err
+			}
+
+			switch output {
+			case "json":
+				err = printLintJSON(os.Stdout, findings)
+			case "sarif":
+				err = printLintSARIF(os.Stdout, findings, fromFile)
+			default:
+				printLintText(os.Stdout, findings)
+			}
+			if err != nil {
+				return err
+			}
+
+			if failures := lintFailures(findings, failOn); failures != 0 {
+				return fmt.Errorf("%d lint findings at or above level [%s]", failures, failOn)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format, text, json or sarif")
+	_ = cmd.RegisterFlagCompletionFunc("output", completeFixed(lintOutputFormats...))
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "File written by export to lint instead of the cluster")
+	cmd.Flags().StringVar(&failOn, "fail-on", lintWarning, "Fail when a finding is at or above this level, warning, note or none")
+	_ = cmd.RegisterFlagCompletionFunc("fail-on", completeFixed(lintFailOnLevels...))
+	cmd.Flags().IntVar(&maxDepth, "max-depth", 6, "Maximum number of nested folders before deep-tree is reported, 0 disables the check")
+
+	return cmd
+}
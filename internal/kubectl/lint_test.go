@@ -0,0 +1,150 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("lint", func() {
+	ctx := context.Background()
+
+	rules := func(findings []lintFinding) []string {
+		found := []string{}
+		for _, finding := range findings {
+			found = append(found, finding.Rule+" "+finding.Object)
+		}
+		return found
+	}
+
+	It("should only report the missing production ClusterFolder of the fixture", func() {
+		cl, _ := newFolderFixture()
+
+		findings, err := lintFolders(ctx, cl, lintOptions{maxDepth: 6})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rules(findings)).To(ConsistOf("index-entry-without-folder ClusterFolder/production"))
+	})
+
+	It("should report soft problems in the hierarchy", func() {
+		root, objs := folderFixtureObjects()
+		root.Spec.ClusterFolderEntries["production"] = v1alpha1.ClusterFolderEntry{Namespaces: []string{"prod-web-apps", "gone"}}
+		root.Spec.ClusterFolderEntries["empty"] = v1alpha1.ClusterFolderEntry{}
+
+		appA := objs[5].(*v1alpha1.NamespacedFolder)
+		appA.Spec.FolderPermissions = append(appA.Spec.FolderPermissions,
+			v1alpha1.FolderPermission{
+				Subject:  rbacv1.Subject{Kind: "Group", Name: "operation-team"},
+				RoleRefs: []rbacv1.RoleRef{{Kind: "ClusterRole", Name: "admin"}},
+			},
+			v1alpha1.FolderPermission{
+				Subject:  rbacv1.Subject{Kind: "User", Name: "steve"},
+				RoleRefs: []rbacv1.RoleRef{{Kind: "ClusterRole", Name: "missing"}, {Kind: "ClusterRole", Name: "pod-reader"}},
+			})
+
+		objs = append(objs,
+			&rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-reader"},
+				Rules: []rbacv1.PolicyRule{{
+					Verbs:     []string{"get"},
+					APIGroups: []string{""},
+					Resources: []string{"pods"},
+				}},
+			},
+			&v1alpha1.ClusterFolder{ObjectMeta: metav1.ObjectMeta{Name: "production"}},
+			&v1alpha1.NamespacedFolder{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "unindexed"}},
+		)
+		cl := newStateReader(objs)
+
+		findings, err := lintFolders(ctx, cl, lintOptions{maxDepth: 2})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rules(findings)).To(ConsistOf(
+			"index-entry-without-folder ClusterFolder/empty",
+			"empty-folder ClusterFolder/empty",
+			"missing-namespace ClusterFolder/production",
+			"folder-not-indexed NamespacedFolder/prod-web-apps/unindexed",
+			"redundant-permission NamespacedFolder/prod-web-apps/prod-web-app-a",
+			"missing-role NamespacedFolder/prod-web-apps/prod-web-app-a",
+			"no-kubevirt-rules NamespacedFolder/prod-web-apps/prod-web-app-a",
+			"deep-tree NamespacedFolder/prod-web-apps/prod-web-app-a",
+			"deep-tree NamespacedFolder/prod-web-apps/prod-web-app-b",
+		))
+		Expect(findings).To(ContainElement(lintFinding{
+			Rule:    "redundant-permission",
+			Level:   lintNote,
+			Object:  "NamespacedFolder/prod-web-apps/prod-web-app-a",
+			Message: "Group/operation-team is already granted ClusterRole/admin by ClusterFolder/operations",
+		}))
+
+		Expect(lintFailures(findings, lintWarning)).To(Equal(7))
+		Expect(lintFailures(findings, lintNote)).To(Equal(9))
+		Expect(lintFailures(findings, "none")).To(BeZero())
+	})
+
+	It("should warn about blocking folders that ClusterFolder permissions still reach", func() {
+		_, objs := folderFixtureObjects()
+		operations := objs[4].(*v1alpha1.ClusterFolder)
+		appA := objs[5].(*v1alpha1.NamespacedFolder)
+		appA.Spec.Inheritance = &v1alpha1.FolderInheritance{Block: true}
+
+		findings, err := lintFolders(ctx, newStateReader(objs), lintOptions{maxDepth: 6})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(findings).To(ContainElement(lintFinding{
+			Rule:    "ineffective-block",
+			Level:   lintWarning,
+			Object:  "NamespacedFolder/prod-web-apps/prod-web-app-a",
+			Message: "NamespacedFolder [prod-web-apps/prod-web-app-a] blocks inheritance, but ClusterFolder [operations] still grants Group/operation-team access to all of namespace [prod-web-apps]",
+		}))
+
+		By("not warning once the ClusterFolder permissions stop above the namespace")
+		operations.Spec.FolderPermissions[0].ExcludeFromChildren = true
+		findings, err = lintFolders(ctx, newStateReader(objs), lintOptions{maxDepth: 6})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rules(findings)).NotTo(ContainElement("ineffective-block NamespacedFolder/prod-web-apps/prod-web-app-a"))
+	})
+
+	It("should skip the cluster checks offline", func() {
+		root, objs := folderFixtureObjects()
+		root.Spec.ClusterFolderEntries["production"] = v1alpha1.ClusterFolderEntry{Namespaces: []string{"gone"}}
+		cl := newStateReader([]client.Object{root, objs[4], objs[5]})
+
+		findings, err := lintFolders(ctx, cl, lintOptions{offline: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rules(findings)).To(ConsistOf(
+			"index-entry-without-folder ClusterFolder/production",
+			"index-entry-without-folder NamespacedFolder/prod-web-apps/prod-web-app-b",
+		))
+	})
+
+	It("should write SARIF results for every finding", func() {
+		findings := []lintFinding{{
+			Rule:    "empty-folder",
+			Level:   lintNote,
+			Object:  "ClusterFolder/empty",
+			Message: "ClusterFolder [empty] contains no folders or namespaces",
+		}}
+
+		var out bytes.Buffer
+		Expect(printLintSARIF(&out, findings, "folders.yaml")).To(Succeed())
+
+		log := sarifLog{}
+		Expect(json.Unmarshal(out.Bytes(), &log)).To(Succeed())
+		Expect(log.Version).To(Equal("2.1.0"))
+		Expect(log.Runs).To(HaveLen(1))
+		Expect(log.Runs[0].Tool.Driver.Rules).To(HaveLen(len(lintRules)))
+		Expect(log.Runs[0].Results).To(HaveLen(1))
+
+		result := log.Runs[0].Results[0]
+		Expect(result.RuleID).To(Equal("empty-folder"))
+		Expect(result.Level).To(Equal("note"))
+		Expect(result.Locations[0].PhysicalLocation.ArtifactLocation.URI).To(Equal("folders.yaml"))
+		Expect(result.Locations[0].LogicalLocations[0].FullyQualifiedName).To(Equal("ClusterFolder/empty"))
+	})
+})
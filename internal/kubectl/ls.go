@@ -0,0 +1,334 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// listPath returns the entry a path names and the entries below it. Besides
+// the index, the namespaces of the cluster that are in no ClusterFolder are
+// listed at the top, and the VirtualMachines of a namespace that are in no
+// NamespacedFolder are listed in the namespace, the same way tree shows them.
+// Listing a VirtualMachine returns the VirtualMachine itself.
+func listPath(ctx context.Context, cl client.Reader, p string) (folderindex.PathEntry, []folderindex.PathEntry, error) {
+	root, err := getFolderView(ctx, cl)
+	if err != nil {
+		return folderindex.PathEntry{}, nil, err
+	}
+
+	entry, err := resolveLivePath(ctx, cl, root, p)
+	if err != nil {
+		return entry, nil, err
+	}
+	if entry.Kind == folderindex.KindVirtualMachine {
+		return entry, []folderindex.PathEntry{entry}, nil
+	}
+
+	children := folderindex.PathChildren(root, entry)
+
+	switch entry.Kind {
+	case folderindex.KindTop:
+		namespaces, err := existingNamespaces(ctx, cl)
+		if err != nil {
+			return entry, nil, err
+		}
+		unfiled := []string{}
+		for namespace := range namespaces {
+			if _, exists := folderindex.NamespaceParent(root, namespace); exists {
+				continue
+			}
+			if !containsEntry(children, folderindex.KindNamespace, namespace) {
+				unfiled = append(unfiled, namespace)
+			}
+		}
+		sort.Strings(unfiled)
+		for _, namespace := range unfiled {
+			children = append(children, folderindex.PathEntry{Kind: folderindex.KindNamespace, Name: namespace})
+		}
+		sort.SliceStable(children, func(i, j int) bool {
+			if children[i].Kind != children[j].Kind {
+				return children[i].Kind == folderindex.KindClusterFolder
+			}
+			return children[i].Name < children[j].Name
+		})
+	case folderindex.KindNamespace:
+		vms, err := existingVMs(ctx, cl)
+		if err != nil {
+			return entry, nil, err
+		}
+		unfiled := []string{}
+		for key := range vms {
+			namespace, vm, _ := folderindex.SplitNamespacedFolderKey(key)
+			if namespace != entry.Name {
+				continue
+			}
+			if _, exists := folderindex.VirtualMachineParent(root, namespace, vm); !exists {
+				unfiled = append(unfiled, key)
+			}
+		}
+		sort.Strings(unfiled)
+		for _, key := range unfiled {
+			children = append(children, folderindex.PathEntry{Kind: folderindex.KindVirtualMachine, Name: key})
+		}
+	}
+
+	return entry, children, nil
+}
+
+// resolveLivePath resolves a path in the index, falling back to the
+// namespaces and VirtualMachines of the cluster that the index does not hold:
+// a namespace in no ClusterFolder at the top, and a VirtualMachine in no
+// NamespacedFolder directly below its namespace.
+func resolveLivePath(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, p string) (folderindex.PathEntry, error) {
+	entry, err := folderindex.ResolvePath(root, p)
+	if err == nil {
+		return entry, nil
+	}
+
+	segments := folderindex.SplitPath(p)
+	if len(segments) == 0 {
+		return entry, err
+	}
+	parent, parentErr := resolveLivePath(ctx, cl, root, strings.Join(segments[:len(segments)-1], "/"))
+	if parentErr != nil {
+		return entry, parentErr
+	}
+	name := segments[len(segments)-1]
+
+	switch parent.Kind {
+	case folderindex.KindTop:
+		namespaces, nsErr := existingNamespaces(ctx, cl)
+		if nsErr != nil {
+			return entry, nsErr
+		}
+		if _, exists := folderindex.NamespaceParent(root, name); !namespaces[name] || exists {
+			return entry, err
+		}
+		return folderindex.PathEntry{Kind: folderindex.KindNamespace, Name: name}, nil
+	case folderindex.KindNamespace:
+		vms, vmErr := existingVMs(ctx, cl)
+		if vmErr != nil {
+			return entry, vmErr
+		}
+		key := folderindex.NamespacedFolderKey(parent.Name, name)
+		if !vms[key] {
+			return entry, err
+		}
+		return folderindex.PathEntry{Kind: folderindex.KindVirtualMachine, Name: key}, nil
+	}
+	return entry, err
+}
+
+func containsEntry(entries []folderindex.PathEntry, kind string, name string) bool {
+	for _, entry := range entries {
+		if entry.Kind == kind && entry.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lsName returns the name ls prints for an entry. Entries that can hold
+// other entries end in a slash.
+func lsName(entry folderindex.PathEntry) string {
+	if entry.Kind == folderindex.KindVirtualMachine {
+		return entry.Base()
+	}
+	return entry.Base() + "/"
+}
+
+func printLs(w io.Writer, entries []folderindex.PathEntry, long bool) {
+	if !long {
+		for _, entry := range entries {
+			fmt.Fprintln(w, lsName(entry))
+		}
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAME")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\n", entry.Kind, entry.Name)
+	}
+	tw.Flush()
+}
+
+// completePath completes a folder path one segment at a time.
+func completePath(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	dir := toComplete[:strings.LastIndex(toComplete, "/")+1]
+	cl, err := newClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	entry, children, err := listPath(context.Background(), cl, dir)
+	if err != nil || entry.Kind == folderindex.KindVirtualMachine {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := []string{}
+	for _, child := range children {
+		completions = append(completions, dir+lsName(child))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+func newLsCmd() *cobra.Command {
+	var long bool
+
+	cmd := &cobra.Command{
+		Use:   "ls [PATH]",
+		Short: "List the contents of a folder path",
+		Long: `List the contents of a folder path.
+
+The folder hierarchy is addressed like a filesystem. ClusterFolders hold
+ClusterFolders and namespaces, namespaces hold NamespacedFolders and the VMs
+that are in no NamespacedFolder, and NamespacedFolders hold NamespacedFolders
+and VMs. PATH defaults to /, which holds the top level ClusterFolders and the
+namespaces that are in no ClusterFolder.
+
+Names that can hold other entries are printed with a trailing slash. -l prints
+the kind and full name of every entry instead.`,
+		Example: `  kubectl folder ls /infra-admins/operations/production
+  kubectl folder ls -l /infra-admins/operations/production/prod-web-apps/prod-web-app-a`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completePath,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			p := "/"
+			if len(args) == 1 {
+				p = args[0]
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			_, children, err := listPath(ctx, cl, p)
+			if err != nil {
+				return err
+			}
+			printLs(cmd.OutOrStdout(), children, long)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&long, "long", "l", false, "Print the kind and full name of every entry")
+
+	return cmd
+}
+
+// pathKinds maps the accepted spellings of the kind argument of path to the
+// kind of entry.
+var pathKinds = map[string]string{
+	"vm":                folderindex.KindVirtualMachine,
+	"vms":               folderindex.KindVirtualMachine,
+	"virtualmachine":    folderindex.KindVirtualMachine,
+	"virtualmachines":   folderindex.KindVirtualMachine,
+	"namespace":         folderindex.KindNamespace,
+	"namespaces":        folderindex.KindNamespace,
+	"ns":                folderindex.KindNamespace,
+	"clusterfolder":     folderindex.KindClusterFolder,
+	"clusterfolders":    folderindex.KindClusterFolder,
+	"namespacedfolder":  folderindex.KindNamespacedFolder,
+	"namespacedfolders": folderindex.KindNamespacedFolder,
+}
+
+// entryPath returns the path of the named entry, checking that folders are
+// in the index.
+func entryPath(root *v1alpha1.FolderIndex, kind string, name string) (string, error) {
+	switch kind {
+	case folderindex.KindClusterFolder:
+		if _, exists := root.Spec.ClusterFolderEntries[name]; !exists {
+			return "", fmt.Errorf("cluster folder [%s] does not exist in the folder index", name)
+		}
+	case folderindex.KindNamespacedFolder:
+		if _, exists := root.Spec.NamespacedFolderEntries[name]; !exists {
+			return "", fmt.Errorf("namespaced folder [%s] does not exist in the folder index", name)
+		}
+	case folderindex.KindVirtualMachine:
+		if _, _, err := folderindex.SplitNamespacedFolderKey(name); err != nil {
+			return "", fmt.Errorf("invalid vm [%s], expected the format namespace/name", name)
+		}
+	}
+	return folderindex.EntryPath(root, folderindex.PathEntry{Kind: kind, Name: name}), nil
+}
+
+func completePathArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return []string{"vm", "namespace", "clusterfolder", "namespacedfolder"}, cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		switch pathKinds[args[0]] {
+		case folderindex.KindVirtualMachine:
+			return completeWith(vmCompletions)
+		case folderindex.KindNamespace:
+			return completeWith(namespaceCompletions)
+		case folderindex.KindClusterFolder:
+			return completeWith(func(ctx context.Context, cl client.Reader) []string {
+				return folderCompletions(ctx, cl, "")
+			})
+		case folderindex.KindNamespacedFolder:
+			return completeWith(namespacedFolderKeyCompletions)
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func newPathCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "path (vm | namespace | clusterfolder | namespacedfolder) NAME",
+		Short: "Print the folder path of a VM, namespace or folder",
+		Long: `Print the folder path of a VM, namespace or folder.
+
+VMs and NamespacedFolders are named namespace/name. The path printed is the
+one ls accepts. A VM that is in no NamespacedFolder is placed directly in its
+namespace.`,
+		Example: `  kubectl folder path vm prod-web-apps/web-app-a
+  kubectl folder ls $(dirname $(kubectl folder path vm prod-web-apps/web-app-a))`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completePathArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			kind, ok := pathKinds[args[0]]
+			if !ok {
+				return fmt.Errorf("unsupported kind [%s], expected vm, namespace, clusterfolder or namespacedfolder", args[0])
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			root, err := getFolderView(ctx, cl)
+			if err != nil {
+				return err
+			}
+
+			p, err := entryPath(root, kind, args[1])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), p)
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,89 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+var _ = Describe("ls and path", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+
+	BeforeEach(func() {
+		_, objs := folderFixtureObjects()
+		objs = append(objs,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+			&virtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "dev", Name: "scratch"}},
+			&virtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-a"}},
+			&virtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "unfiled"}},
+		)
+		cl = newStateClient(objs)
+	})
+
+	ls := func(p string, long bool) string {
+		_, children, err := listPath(ctx, cl, p)
+		Expect(err).NotTo(HaveOccurred())
+		var out bytes.Buffer
+		printLs(&out, children, long)
+		return out.String()
+	}
+
+	It("should list the top of the hierarchy with namespaces in no folder", func() {
+		Expect(ls("/", false)).To(Equal("operations/\ndev/\n"))
+	})
+
+	It("should list namespaces in no folder and their VMs", func() {
+		Expect(ls("/dev", false)).To(Equal("scratch\n"))
+		Expect(ls("/dev/scratch", true)).To(Equal("KIND            NAME\nVirtualMachine  dev/scratch\n"))
+
+		root, err := getFolderView(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		p, err := entryPath(root, folderindex.KindVirtualMachine, "dev/scratch")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p).To(Equal("/dev/scratch"))
+		Expect(ls(p, false)).To(Equal("scratch\n"))
+	})
+
+	It("should list through the cluster and namespaced layers", func() {
+		Expect(ls("/operations", false)).To(Equal("production/\n"))
+		Expect(ls("/operations/production/prod-web-apps", false)).To(Equal("prod-web-app-a/\nprod-web-app-b/\nunfiled\n"))
+		Expect(ls("/operations/production/prod-web-apps/prod-web-app-a", true)).To(Equal(
+			"KIND            NAME\nVirtualMachine  prod-web-apps/web-app-a\n"))
+		Expect(ls("/operations/production/prod-web-apps/unfiled", false)).To(Equal("unfiled\n"))
+	})
+
+	It("should fail for paths that do not exist", func() {
+		_, _, err := listPath(ctx, cl, "/operations/staging")
+		Expect(err).To(MatchError("path [/operations/staging] does not exist in the folder index"))
+		_, _, err = listPath(ctx, cl, "/prod-web-apps")
+		Expect(err).To(MatchError("path [/prod-web-apps] does not exist in the folder index"))
+		_, _, err = listPath(ctx, cl, "/missing/scratch")
+		Expect(err).To(MatchError("path [/missing] does not exist in the folder index"))
+		_, _, err = listPath(ctx, cl, "/operations/production/prod-web-apps/missing")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should print the path of VMs and folders", func() {
+		root, err := getRootIndex(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(entryPath(root, folderindex.KindVirtualMachine, "prod-web-apps/web-app-b")).To(
+			Equal("/operations/production/prod-web-apps/prod-web-app-b/web-app-b"))
+		Expect(entryPath(root, folderindex.KindNamespace, "dev")).To(Equal("/dev"))
+
+		_, err = entryPath(root, folderindex.KindVirtualMachine, "web-app-b")
+		Expect(err).To(MatchError("invalid vm [web-app-b], expected the format namespace/name"))
+		_, err = entryPath(root, folderindex.KindClusterFolder, "staging")
+		Expect(err).To(MatchError("cluster folder [staging] does not exist in the folder index"))
+	})
+})
@@ -0,0 +1,83 @@
+package kubectl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// readManifests decodes every object in a multi-document YAML or JSON file.
+// Objects wrapped in a v1 List are returned individually. A path of "-"
+// reads from stdin.
+func readManifests(path string) ([]client.Object, error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	decoder := serializer.NewCodecFactory(scheme.Scheme).UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+
+	objs := []client.Object{}
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		decoded, err := decodeManifest(decoder, doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+		}
+		objs = append(objs, decoded...)
+	}
+
+	return objs, nil
+}
+
+func decodeManifest(decoder runtime.Decoder, doc []byte) ([]client.Object, error) {
+	obj, _, err := decoder.Decode(doc, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if list, ok := obj.(*corev1.List); ok {
+		objs := []client.Object{}
+		for _, item := range list.Items {
+			decoded, err := decodeManifest(decoder, item.Raw)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, decoded...)
+		}
+		return objs, nil
+	}
+
+	clientObj, ok := obj.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("unsupported object %T", obj)
+	}
+	return []client.Object{clientObj}, nil
+}
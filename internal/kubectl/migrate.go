@@ -0,0 +1,142 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// indexMemberships returns a FolderMembership for every VirtualMachine the
+// index files, limited to namespace when it is set, sorted by namespace and
+// name.
+func indexMemberships(root *v1alpha1.FolderIndex, namespace string) []v1alpha1.FolderMembership {
+	memberships := []v1alpha1.FolderMembership{}
+	for key, entry := range root.Spec.NamespacedFolderEntries {
+		folderNamespace, folder, err := folderindex.SplitNamespacedFolderKey(key)
+		if err != nil || (namespace != "" && folderNamespace != namespace) {
+			continue
+		}
+		for _, vm := range entry.VirtualMachines {
+			memberships = append(memberships, v1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{Namespace: folderNamespace, Name: vm},
+				Spec:       v1alpha1.FolderMembershipSpec{Folder: folder},
+			})
+		}
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		a, b := memberships[i], memberships[j]
+		return folderindex.NamespacedFolderKey(a.Namespace, a.Name) < folderindex.NamespacedFolderKey(b.Namespace, b.Name)
+	})
+	return memberships
+}
+
+// migrateMemberships moves the VirtualMachines the index files into
+// FolderMemberships. The memberships are created before the VirtualMachines
+// are taken out of the index, so they never lose the access of their folder.
+// VirtualMachines that already have a membership keep it, since it takes
+// precedence over the index. The created memberships are returned, followed by
+// the ones that were skipped because a membership already existed.
+func migrateMemberships(ctx context.Context, cl client.Client, namespace string, dryRun bool) ([]v1alpha1.FolderMembership, []v1alpha1.FolderMembership, error) {
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing, err := listFolderMemberships(ctx, cl)
+	if err != nil {
+		return nil, nil, err
+	}
+	hasMembership := map[string]bool{}
+	for _, membership := range existing {
+		hasMembership[folderindex.NamespacedFolderKey(membership.Namespace, membership.Name)] = true
+	}
+
+	createOpts := []client.CreateOption{}
+	patchOpts := []client.PatchOption{}
+	if dryRun {
+		createOpts = append(createOpts, client.DryRunAll)
+		patchOpts = append(patchOpts, client.DryRunAll)
+	}
+
+	created := []v1alpha1.FolderMembership{}
+	skipped := []v1alpha1.FolderMembership{}
+	newRoot := root.DeepCopy()
+	for _, membership := range indexMemberships(root, namespace) {
+		if hasMembership[folderindex.NamespacedFolderKey(membership.Namespace, membership.Name)] {
+			skipped = append(skipped, membership)
+		} else if err := cl.Create(ctx, &membership, createOpts...); apierrors.IsAlreadyExists(err) {
+			skipped = append(skipped, membership)
+		} else if err != nil {
+			return created, skipped, fmt.Errorf("failed to create folder membership [%s/%s]: %v", membership.Namespace, membership.Name, err)
+		} else {
+			created = append(created, membership)
+		}
+
+		key := folderindex.NamespacedFolderKey(membership.Namespace, membership.Spec.Folder)
+		entry := newRoot.Spec.NamespacedFolderEntries[key]
+		entry.VirtualMachines = folderindex.Remove(entry.VirtualMachines, membership.Name)
+		newRoot.Spec.NamespacedFolderEntries[key] = entry
+	}
+
+	patch := client.MergeFromWithOptions(root, client.MergeFromWithOptimisticLock{})
+	if err := cl.Patch(ctx, newRoot, patch, patchOpts...); err != nil {
+		return created, skipped, fmt.Errorf("failed to update root folder index: %v", err)
+	}
+	return created, skipped, nil
+}
+
+func newMigrateMembershipsCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate-memberships",
+		Short: "Move the VMs of the folder index into FolderMemberships",
+		Long: `Move the VMs of the folder index into FolderMemberships.
+
+A FolderMembership files a single VM into a NamespacedFolder. Unlike the
+folder index, which is one object and bounded by the object size limit of the
+cluster, memberships scale with the number of VMs.
+
+A membership is created for every VM the index files, then the VMs are taken
+out of the index. VMs that already have a membership keep it and are reported
+as skipped. With --namespace only the VMs of that namespace are
+migrated, so large fleets can be migrated a namespace at a time. Running the
+command again finishes a migration that was interrupted.`,
+		Example: `  kubectl folder migrate-memberships -n prod-web-apps --dry-run
+  kubectl folder migrate-memberships`,
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			created, skipped, err := migrateMemberships(ctx, cl, namespaceFlag(cmd), dryRun)
+			verb := "created"
+			if dryRun {
+				verb = "would be created"
+			}
+			for _, membership := range created {
+				fmt.Fprintf(cmd.OutOrStdout(), "FolderMembership [%s/%s] %s in folder [%s]\n", membership.Namespace, membership.Name, verb, membership.Spec.Folder)
+			}
+			for _, membership := range skipped {
+				fmt.Fprintf(cmd.OutOrStdout(), "FolderMembership [%s/%s] skipped, it already exists\n", membership.Namespace, membership.Name)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the memberships that would be created without changing anything")
+
+	return cmd
+}
@@ -0,0 +1,122 @@
+package kubectl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+var _ = Describe("FolderMemberships", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+
+	membership := func(namespace string, vm string, folder string) *v1alpha1.FolderMembership {
+		return &v1alpha1.FolderMembership{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: vm},
+			Spec:       v1alpha1.FolderMembershipSpec{Folder: folder},
+		}
+	}
+
+	folderOf := func(namespace string, vm string) string {
+		view, err := getFolderView(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		folder, _ := folderindex.VirtualMachineParent(view, namespace, vm)
+		return folder
+	}
+
+	BeforeEach(func() {
+		root, objs := folderFixtureObjects()
+		root.Spec.NamespacedFolderEntries["staging-web-apps/staging-web-app-a"] = v1alpha1.NamespacedFolderEntry{
+			VirtualMachines: []string{"web-app-a"},
+		}
+		objs = append(objs, membership("prod-web-apps", "web-app-c", "prod-web-app-b"))
+		cl = newStateClient(objs)
+	})
+
+	It("should file the VMs of memberships into the folder view", func() {
+		Expect(folderOf("prod-web-apps", "web-app-c")).To(Equal("prod-web-apps/prod-web-app-b"))
+		Expect(folderOf("prod-web-apps", "web-app-a")).To(Equal("prod-web-apps/prod-web-app-a"))
+	})
+
+	It("should move the VMs of memberships by updating the membership", func() {
+		Expect(updateRootIndex(ctx, cl, func(root *v1alpha1.FolderIndex) error {
+			return moveVMInIndex(root, "prod-web-apps", "web-app-c", "prod-web-apps/prod-web-app-a")
+		})).To(Succeed())
+
+		updated := &v1alpha1.FolderMembership{}
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: "prod-web-apps", Name: "web-app-c"}, updated)).To(Succeed())
+		Expect(updated.Spec.Folder).To(Equal("prod-web-app-a"))
+
+		root, err := getRootIndex(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].VirtualMachines).To(Equal([]string{"web-app-a"}))
+
+		By("deleting the membership when the VM is unfiled")
+		Expect(updateRootIndex(ctx, cl, func(root *v1alpha1.FolderIndex) error {
+			return moveVMInIndex(root, "prod-web-apps", "web-app-c", "")
+		})).To(Succeed())
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: "prod-web-apps", Name: "web-app-c"}, updated)).NotTo(Succeed())
+		Expect(folderOf("prod-web-apps", "web-app-c")).To(BeEmpty())
+	})
+
+	It("should migrate the VMs of the index into memberships", func() {
+		created, skipped, err := migrateMemberships(ctx, cl, "prod-web-apps", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(skipped).To(BeEmpty())
+		Expect(created).To(HaveLen(2))
+		Expect(created[0].Name).To(Equal("web-app-a"))
+		Expect(created[0].Spec.Folder).To(Equal("prod-web-app-a"))
+		Expect(created[1].Name).To(Equal("web-app-b"))
+		Expect(created[1].Spec.Folder).To(Equal("prod-web-app-b"))
+
+		root, err := getRootIndex(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].VirtualMachines).To(BeEmpty())
+		Expect(root.Spec.NamespacedFolderEntries["staging-web-apps/staging-web-app-a"].VirtualMachines).To(Equal([]string{"web-app-a"}))
+
+		Expect(folderOf("prod-web-apps", "web-app-a")).To(Equal("prod-web-apps/prod-web-app-a"))
+		Expect(folderOf("prod-web-apps", "web-app-b")).To(Equal("prod-web-apps/prod-web-app-b"))
+		Expect(folderOf("prod-web-apps", "web-app-c")).To(Equal("prod-web-apps/prod-web-app-b"))
+
+		By("finishing an interrupted migration without creating memberships twice")
+		created, skipped, err = migrateMemberships(ctx, cl, "", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(skipped).To(BeEmpty())
+		Expect(created).To(HaveLen(1))
+		Expect(created[0].Namespace).To(Equal("staging-web-apps"))
+	})
+
+	It("should report VMs that already have a membership as skipped", func() {
+		Expect(cl.Create(ctx, &v1alpha1.FolderMembership{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "staging-web-apps", Name: "web-app-a"},
+			Spec:       v1alpha1.FolderMembershipSpec{Folder: "staging-web-app-a"},
+		})).To(Succeed())
+
+		created, skipped, err := migrateMemberships(ctx, cl, "staging-web-apps", false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(BeEmpty())
+		Expect(skipped).To(HaveLen(1))
+		Expect(skipped[0].Name).To(Equal("web-app-a"))
+
+		root, err := getRootIndex(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(root.Spec.NamespacedFolderEntries["staging-web-apps/staging-web-app-a"].VirtualMachines).To(BeEmpty())
+	})
+
+	It("should not change anything on a dry run", func() {
+		created, _, err := migrateMemberships(ctx, cl, "", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(HaveLen(3))
+
+		memberships, err := listFolderMemberships(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(memberships).To(HaveLen(1))
+	})
+})
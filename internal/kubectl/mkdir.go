@@ -0,0 +1,155 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+func mkdirClusterFolder(ctx context.Context, cl client.Client, name string, parent string) error {
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := root.Spec.ClusterFolderEntries[name]; exists {
+		return fmt.Errorf("cluster folder [%s] already exists in the folder index", name)
+	}
+	if parent != "" {
+		if _, exists := root.Spec.ClusterFolderEntries[parent]; !exists {
+			return fmt.Errorf("parent cluster folder [%s] does not exist in the folder index", parent)
+		}
+	}
+
+	folder := &v1alpha1.ClusterFolder{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	if err := cl.Create(ctx, folder); err != nil {
+		return fmt.Errorf("failed to create cluster folder [%s]: %v", name, err)
+	}
+
+	newRoot := root.DeepCopy()
+	newRoot.Spec.ClusterFolderEntries[name] = v1alpha1.ClusterFolderEntry{}
+	if parent != "" {
+		parentEntry := newRoot.Spec.ClusterFolderEntries[parent]
+		parentEntry.ChildFolders = append(parentEntry.ChildFolders, name)
+		newRoot.Spec.ClusterFolderEntries[parent] = parentEntry
+	}
+
+	if err := patchRootIndex(ctx, cl, root, newRoot); err != nil {
+		return rollbackFolderCreation(ctx, cl, folder, fmt.Sprintf("cluster folder [%s]", name), err)
+	}
+
+	return nil
+}
+
+func mkdirNamespacedFolder(ctx context.Context, cl client.Client, namespace string, name string, parent string) error {
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return err
+	}
+
+	key := folderindex.NamespacedFolderKey(namespace, name)
+	if _, exists := root.Spec.NamespacedFolderEntries[key]; exists {
+		return fmt.Errorf("namespaced folder [%s] already exists in the folder index", key)
+	}
+
+	parentKey := ""
+	if parent != "" {
+		parentKey = folderindex.NamespacedFolderKey(namespace, parent)
+		if _, exists := root.Spec.NamespacedFolderEntries[parentKey]; !exists {
+			return fmt.Errorf("parent namespaced folder [%s] does not exist in the folder index", parentKey)
+		}
+	}
+
+	folder := &v1alpha1.NamespacedFolder{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	if err := cl.Create(ctx, folder); err != nil {
+		return fmt.Errorf("failed to create namespaced folder [%s]: %v", key, err)
+	}
+
+	newRoot := root.DeepCopy()
+	newRoot.Spec.NamespacedFolderEntries[key] = v1alpha1.NamespacedFolderEntry{}
+	if parentKey != "" {
+		parentEntry := newRoot.Spec.NamespacedFolderEntries[parentKey]
+		parentEntry.ChildFolders = append(parentEntry.ChildFolders, key)
+		newRoot.Spec.NamespacedFolderEntries[parentKey] = parentEntry
+	}
+
+	if err := patchRootIndex(ctx, cl, root, newRoot); err != nil {
+		return rollbackFolderCreation(ctx, cl, folder, fmt.Sprintf("namespaced folder [%s]", key), err)
+	}
+
+	return nil
+}
+
+// rollbackFolderCreation removes a folder object that was created for an
+// index entry which the API server refused to add, so a rejected mkdir does
+// not leave a folder behind that is missing from the index.
+func rollbackFolderCreation(ctx context.Context, cl client.Client, folder client.Object, description string, indexErr error) error {
+	if err := cl.Delete(ctx, folder); err != nil {
+		return fmt.Errorf("%v. rollback of %s failed: %v", indexErr, description, err)
+	}
+	return fmt.Errorf("%v. %s was rolled back", indexErr, description)
+}
+
+func newMkdirCmd() *cobra.Command {
+	var parent string
+
+	cmd := &cobra.Command{
+		Use:   "mkdir NAME",
+		Short: "Create a folder and add it to the folder index",
+		Long: `Create a folder and add it to the folder index.
+
+Without --namespace a ClusterFolder is created. With --namespace a
+NamespacedFolder is created in that namespace. --parent nests the new folder
+under an existing folder of the same kind.
+
+If the folder index rejects the new entry, the folder object is deleted again.`,
+		Example: `  kubectl folder mkdir --parent operations production
+  kubectl folder mkdir -n prod-web-apps temp-folder-debug`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			name := args[0]
+			namespace := namespaceFlag(cmd)
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			if namespace == "" {
+				if err := mkdirClusterFolder(ctx, cl, name, parent); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "ClusterFolder [%s] created\n", name)
+				return nil
+			}
+
+			if err := mkdirNamespacedFolder(ctx, cl, namespace, name, parent); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "NamespacedFolder [%s] created\n", folderindex.NamespacedFolderKey(namespace, name))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&parent, "parent", "", "Name of the folder to create the new folder in")
+	_ = cmd.RegisterFlagCompletionFunc("parent", completeFolderNames)
+
+	return cmd
+}
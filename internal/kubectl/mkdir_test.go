@@ -0,0 +1,256 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+var _ = Describe("mkdir and rmdir", func() {
+	ctx := context.Background()
+
+	var root *v1alpha1.FolderIndex
+
+	BeforeEach(func() {
+		root = &v1alpha1.FolderIndex{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "root",
+			},
+			Spec: v1alpha1.FolderIndexSpec{
+				ClusterFolderEntries: map[string]v1alpha1.ClusterFolderEntry{
+					"operations": {
+						ChildFolders: []string{"production"},
+					},
+					"production": {
+						Namespaces: []string{"prod-web-apps"},
+					},
+				},
+				NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{
+					"prod-web-apps/prod-web-app-a": {
+						ChildFolders:    []string{"prod-web-apps/nested"},
+						VirtualMachines: []string{"web-app-a"},
+					},
+					"prod-web-apps/nested": {
+						VirtualMachines: []string{"web-app-a-db"},
+					},
+				},
+			},
+		}
+	})
+
+	getRoot := func(cl client.Client) *v1alpha1.FolderIndex {
+		updated := &v1alpha1.FolderIndex{}
+		Expect(cl.Get(ctx, client.ObjectKey{Name: "root"}, updated)).To(Succeed())
+		return updated
+	}
+
+	It("should create a cluster folder and its index entry under the parent", func() {
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root).Build()
+
+		Expect(mkdirClusterFolder(ctx, cl, "staging", "operations")).To(Succeed())
+
+		Expect(cl.Get(ctx, client.ObjectKey{Name: "staging"}, &v1alpha1.ClusterFolder{})).To(Succeed())
+		updated := getRoot(cl)
+		Expect(updated.Spec.ClusterFolderEntries).To(HaveKey("staging"))
+		Expect(updated.Spec.ClusterFolderEntries["operations"].ChildFolders).To(ConsistOf("production", "staging"))
+	})
+
+	It("should create a namespaced folder keyed by namespace and name", func() {
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root).Build()
+
+		Expect(mkdirNamespacedFolder(ctx, cl, "prod-web-apps", "temp-folder-debug", "prod-web-app-a")).To(Succeed())
+
+		key := client.ObjectKey{Namespace: "prod-web-apps", Name: "temp-folder-debug"}
+		Expect(cl.Get(ctx, key, &v1alpha1.NamespacedFolder{})).To(Succeed())
+		updated := getRoot(cl)
+		Expect(updated.Spec.NamespacedFolderEntries).To(HaveKey("prod-web-apps/temp-folder-debug"))
+		Expect(updated.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].ChildFolders).To(
+			ConsistOf("prod-web-apps/nested", "prod-web-apps/temp-folder-debug"))
+	})
+
+	It("should reject a parent that is not in the index", func() {
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root).Build()
+
+		Expect(mkdirClusterFolder(ctx, cl, "staging", "missing")).NotTo(Succeed())
+		err := cl.Get(ctx, client.ObjectKey{Name: "staging"}, &v1alpha1.ClusterFolder{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should roll back the folder object when the index patch is rejected", func() {
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				return fmt.Errorf("denied by webhook")
+			},
+		}).Build()
+
+		err := mkdirClusterFolder(ctx, cl, "staging", "operations")
+		Expect(err).To(MatchError(ContainSubstring("rolled back")))
+
+		err = cl.Get(ctx, client.ObjectKey{Name: "staging"}, &v1alpha1.ClusterFolder{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should refuse to remove a non-empty folder without --recursive or --reparent", func() {
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root).Build()
+
+		_, _, err := rmdirClusterFolder(ctx, cl, "production", rmdirOptions{})
+		Expect(err).To(HaveOccurred())
+		Expect(getRoot(cl).Spec.ClusterFolderEntries).To(HaveKey("production"))
+	})
+
+	It("should move the contents into the parent with --reparent", func() {
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root).Build()
+
+		removed, moved, err := rmdirClusterFolder(ctx, cl, "production", rmdirOptions{reparent: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(ConsistOf("production"))
+		Expect(moved).To(BeEmpty())
+
+		updated := getRoot(cl)
+		Expect(updated.Spec.ClusterFolderEntries).NotTo(HaveKey("production"))
+		Expect(updated.Spec.ClusterFolderEntries["operations"].ChildFolders).To(BeEmpty())
+		Expect(updated.Spec.ClusterFolderEntries["operations"].Namespaces).To(ConsistOf("prod-web-apps"))
+	})
+
+	It("should move the contents of a folder without a parent to the top level with --reparent", func() {
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root).Build()
+
+		removed, moved, err := rmdirClusterFolder(ctx, cl, "operations", rmdirOptions{reparent: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(ConsistOf("operations"))
+		Expect(moved).To(Equal([]folderindex.PathEntry{{Kind: folderindex.KindClusterFolder, Name: "production"}}))
+
+		updated := getRoot(cl)
+		Expect(updated.Spec.ClusterFolderEntries).NotTo(HaveKey("operations"))
+		Expect(folderindex.ResolvePath(updated, "/production/prod-web-apps")).To(
+			Equal(folderindex.PathEntry{Kind: folderindex.KindNamespace, Name: "prod-web-apps"}))
+
+		By("moving namespaces and VirtualMachines to the top level as well")
+		removed, moved, err = rmdirClusterFolder(ctx, cl, "production", rmdirOptions{reparent: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(ConsistOf("production"))
+		Expect(moved).To(Equal([]folderindex.PathEntry{{Kind: folderindex.KindNamespace, Name: "prod-web-apps"}}))
+
+		removed, moved, err = rmdirNamespacedFolder(ctx, cl, "prod-web-apps", "prod-web-app-a", rmdirOptions{reparent: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(ConsistOf("prod-web-apps/prod-web-app-a"))
+		Expect(moved).To(Equal([]folderindex.PathEntry{
+			{Kind: folderindex.KindNamespacedFolder, Name: "prod-web-apps/nested"},
+			{Kind: folderindex.KindVirtualMachine, Name: "prod-web-apps/web-app-a"},
+		}))
+
+		updated = getRoot(cl)
+		Expect(folderindex.ResolvePath(updated, "/prod-web-apps/nested/web-app-a-db")).To(
+			Equal(folderindex.PathEntry{Kind: folderindex.KindVirtualMachine, Name: "prod-web-apps/web-app-a-db"}))
+		_, filed := folderindex.VirtualMachineParent(updated, "prod-web-apps", "web-app-a")
+		Expect(filed).To(BeFalse())
+	})
+
+	It("should keep a cluster folder whose removal the webhooks reject", func() {
+		folder := &v1alpha1.ClusterFolder{ObjectMeta: metav1.ObjectMeta{Name: "production"}}
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root, folder).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				return fmt.Errorf("denied by webhook")
+			},
+		}).Build()
+
+		_, _, err := rmdirClusterFolder(ctx, cl, "production", rmdirOptions{reparent: true})
+		Expect(err).To(MatchError(ContainSubstring("folder index update rejected")))
+		Expect(getRoot(cl).Spec.ClusterFolderEntries).To(HaveKey("production"))
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(folder), folder)).To(Succeed())
+	})
+
+	It("should remove all descendant folders with --recursive", func() {
+		parent := &v1alpha1.NamespacedFolder{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "prod-web-app-a"}}
+		nested := &v1alpha1.NamespacedFolder{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "nested"}}
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root, parent, nested).Build()
+
+		removed, _, err := rmdirNamespacedFolder(ctx, cl, "prod-web-apps", "prod-web-app-a", rmdirOptions{recursive: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removed).To(ConsistOf("prod-web-apps/prod-web-app-a", "prod-web-apps/nested"))
+
+		Expect(getRoot(cl).Spec.NamespacedFolderEntries).To(BeEmpty())
+		err = cl.Get(ctx, client.ObjectKeyFromObject(nested), &v1alpha1.NamespacedFolder{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should count, move and delete the FolderMemberships of removed folders", func() {
+		root.Spec.NamespacedFolderEntries["prod-web-apps/empty"] = v1alpha1.NamespacedFolderEntry{}
+		filed := &v1alpha1.FolderMembership{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-b"},
+			Spec:       v1alpha1.FolderMembershipSpec{Folder: "empty"},
+		}
+		nested := &v1alpha1.FolderMembership{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-b-db"},
+			Spec:       v1alpha1.FolderMembershipSpec{Folder: "nested"},
+		}
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root, filed, nested).Build()
+
+		By("refusing to remove a folder that only FolderMemberships file VMs into")
+		_, _, err := rmdirNamespacedFolder(ctx, cl, "prod-web-apps", "empty", rmdirOptions{})
+		Expect(err).To(MatchError(ContainSubstring("is not empty")))
+
+		By("moving FolderMemberships into the parent with --reparent")
+		_, _, err = rmdirNamespacedFolder(ctx, cl, "prod-web-apps", "nested", rmdirOptions{reparent: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(nested), nested)).To(Succeed())
+		Expect(nested.Spec.Folder).To(Equal("prod-web-app-a"))
+
+		By("deleting FolderMemberships with --recursive")
+		_, _, err = rmdirNamespacedFolder(ctx, cl, "prod-web-apps", "prod-web-app-a", rmdirOptions{recursive: true})
+		Expect(err).NotTo(HaveOccurred())
+		err = cl.Get(ctx, client.ObjectKeyFromObject(nested), nested)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(filed), filed)).To(Succeed())
+	})
+
+	It("should delete FolderMemberships filed into the folders while they are removed", func() {
+		late := &v1alpha1.FolderMembership{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-c"},
+			Spec:       v1alpha1.FolderMembershipSpec{Folder: "nested"},
+		}
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if err := cl.Patch(ctx, obj, patch, opts...); err != nil {
+					return err
+				}
+				return client.IgnoreAlreadyExists(cl.Create(ctx, late.DeepCopy()))
+			},
+		}).Build()
+
+		_, _, err := rmdirNamespacedFolder(ctx, cl, "prod-web-apps", "prod-web-app-a", rmdirOptions{recursive: true})
+		Expect(err).NotTo(HaveOccurred())
+		err = cl.Get(ctx, client.ObjectKeyFromObject(late), &v1alpha1.FolderMembership{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should clear the default folder of the namespace when it is removed", func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "prod-web-apps",
+			Annotations: map[string]string{v1alpha1.DefaultFolderAnnotation: "nested"},
+		}}
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(root, namespace).Build()
+
+		_, _, err := rmdirNamespacedFolder(ctx, cl, "prod-web-apps", "prod-web-app-a", rmdirOptions{reparent: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)).To(Succeed())
+		Expect(namespace.Annotations).To(HaveKey(v1alpha1.DefaultFolderAnnotation))
+
+		_, _, err = rmdirNamespacedFolder(ctx, cl, "prod-web-apps", "nested", rmdirOptions{recursive: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cl.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)).To(Succeed())
+		Expect(namespace.Annotations).NotTo(HaveKey(v1alpha1.DefaultFolderAnnotation))
+	})
+})
@@ -0,0 +1,164 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// moveClusterFolderInIndex makes parent the parent of the ClusterFolder, or
+// makes it a top level folder when parent is empty.
+func moveClusterFolderInIndex(root *v1alpha1.FolderIndex, folder string, parent string) error {
+	if _, exists := root.Spec.ClusterFolderEntries[folder]; !exists {
+		return fmt.Errorf("cluster folder [%s] does not exist in the folder index", folder)
+	}
+	if parent != "" {
+		if _, exists := root.Spec.ClusterFolderEntries[parent]; !exists {
+			return fmt.Errorf("cluster folder [%s] does not exist in the folder index", parent)
+		}
+		if slices.Contains(folderindex.ClusterFolderAncestry(root, parent), folder) {
+			return fmt.Errorf("cannot move cluster folder [%s] into itself or one of its descendants", folder)
+		}
+	}
+
+	if oldParent, ok := folderindex.ClusterFolderParent(root, folder); ok {
+		entry := root.Spec.ClusterFolderEntries[oldParent]
+		entry.ChildFolders = folderindex.Remove(entry.ChildFolders, folder)
+		root.Spec.ClusterFolderEntries[oldParent] = entry
+	}
+	if parent != "" {
+		entry := root.Spec.ClusterFolderEntries[parent]
+		entry.ChildFolders = append(entry.ChildFolders, folder)
+		root.Spec.ClusterFolderEntries[parent] = entry
+	}
+	return nil
+}
+
+// moveNamespaceInIndex makes the ClusterFolder parent hold the namespace, or
+// removes the namespace from all folders when parent is empty.
+func moveNamespaceInIndex(root *v1alpha1.FolderIndex, namespace string, parent string) error {
+	if parent != "" {
+		if _, exists := root.Spec.ClusterFolderEntries[parent]; !exists {
+			return fmt.Errorf("cluster folder [%s] does not exist in the folder index", parent)
+		}
+	}
+
+	if oldParent, ok := folderindex.NamespaceParent(root, namespace); ok {
+		entry := root.Spec.ClusterFolderEntries[oldParent]
+		entry.Namespaces = folderindex.Remove(entry.Namespaces, namespace)
+		root.Spec.ClusterFolderEntries[oldParent] = entry
+	}
+	if parent != "" {
+		entry := root.Spec.ClusterFolderEntries[parent]
+		entry.Namespaces = append(entry.Namespaces, namespace)
+		root.Spec.ClusterFolderEntries[parent] = entry
+	}
+	return nil
+}
+
+// moveNamespacedFolderInIndex makes the NamespacedFolder parentKey the
+// parent of the folder key, or moves it to the top of its namespace when
+// parentKey is empty. Both folders must be in the same namespace.
+func moveNamespacedFolderInIndex(root *v1alpha1.FolderIndex, key string, parentKey string) error {
+	if _, exists := root.Spec.NamespacedFolderEntries[key]; !exists {
+		return fmt.Errorf("namespaced folder [%s] does not exist in the folder index", key)
+	}
+	if parentKey != "" {
+		if _, exists := root.Spec.NamespacedFolderEntries[parentKey]; !exists {
+			return fmt.Errorf("namespaced folder [%s] does not exist in the folder index", parentKey)
+		}
+		if err := sameNamespace(key, parentKey); err != nil {
+			return err
+		}
+		if slices.Contains(folderindex.NamespacedFolderAncestry(root, parentKey), key) {
+			return fmt.Errorf("cannot move namespaced folder [%s] into itself or one of its descendants", key)
+		}
+	}
+
+	if oldParent, ok := folderindex.NamespacedFolderParent(root, key); ok {
+		entry := root.Spec.NamespacedFolderEntries[oldParent]
+		entry.ChildFolders = folderindex.Remove(entry.ChildFolders, key)
+		root.Spec.NamespacedFolderEntries[oldParent] = entry
+	}
+	if parentKey != "" {
+		entry := root.Spec.NamespacedFolderEntries[parentKey]
+		entry.ChildFolders = append(entry.ChildFolders, key)
+		root.Spec.NamespacedFolderEntries[parentKey] = entry
+	}
+	return nil
+}
+
+// moveVMInIndex files the VirtualMachine into the NamespacedFolder
+// folderKey, or removes it from all folders when folderKey is empty.
+func moveVMInIndex(root *v1alpha1.FolderIndex, namespace string, vm string, folderKey string) error {
+	if folderKey != "" {
+		if _, exists := root.Spec.NamespacedFolderEntries[folderKey]; !exists {
+			return fmt.Errorf("namespaced folder [%s] does not exist in the folder index", folderKey)
+		}
+		if err := sameNamespace(folderindex.NamespacedFolderKey(namespace, vm), folderKey); err != nil {
+			return err
+		}
+	}
+
+	if oldFolder, ok := folderindex.VirtualMachineParent(root, namespace, vm); ok {
+		entry := root.Spec.NamespacedFolderEntries[oldFolder]
+		entry.VirtualMachines = folderindex.Remove(entry.VirtualMachines, vm)
+		root.Spec.NamespacedFolderEntries[oldFolder] = entry
+	}
+	if folderKey != "" {
+		entry := root.Spec.NamespacedFolderEntries[folderKey]
+		entry.VirtualMachines = append(entry.VirtualMachines, vm)
+		root.Spec.NamespacedFolderEntries[folderKey] = entry
+	}
+	return nil
+}
+
+func sameNamespace(key string, parentKey string) error {
+	namespace, _, err := folderindex.SplitNamespacedFolderKey(key)
+	if err != nil {
+		return err
+	}
+	parentNamespace, _, err := folderindex.SplitNamespacedFolderKey(parentKey)
+	if err != nil {
+		return err
+	}
+	if namespace != parentNamespace {
+		return fmt.Errorf("cannot move [%s] into namespaced folder [%s] in another namespace", key, parentKey)
+	}
+	return nil
+}
+
+// updateRootIndex reads the root FolderIndex, applies change to a copy and
+// writes the result back with the guarded patch, so a concurrent edit
+// fails the update instead of being overwritten. The change sees the
+// VirtualMachines of the FolderMemberships, and moving one of them updates
+// its membership rather than the index.
+func updateRootIndex(ctx context.Context, cl client.Client, change func(root *v1alpha1.FolderIndex) error) error {
+	root, err := getRootIndex(ctx, cl)
+	if err != nil {
+		return err
+	}
+	memberships, err := listFolderMemberships(ctx, cl)
+	if err != nil {
+		return err
+	}
+
+	before, view, err := changeFolderView(root, memberships, change)
+	if err != nil {
+		return err
+	}
+
+	newRoot, changed, deleted := splitFolderView(before, view, memberships)
+	if err := dryRunFolderView(ctx, cl, root, newRoot, changed); err != nil {
+		return err
+	}
+	if err := patchRootIndex(ctx, cl, root, newRoot); err != nil {
+		return err
+	}
+	return updateMemberships(ctx, cl, changed, deleted)
+}
@@ -0,0 +1,62 @@
+package kubectl
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("index moves", func() {
+	var root *v1alpha1.FolderIndex
+
+	BeforeEach(func() {
+		fixture, _ := folderFixtureObjects()
+		root = fixture.DeepCopy()
+		root.Spec.ClusterFolderEntries["staging"] = v1alpha1.ClusterFolderEntry{}
+		root.Spec.NamespacedFolderEntries["dev/dev-apps"] = v1alpha1.NamespacedFolderEntry{}
+	})
+
+	It("should move a cluster folder to a new parent and to the top level", func() {
+		Expect(moveClusterFolderInIndex(root, "production", "staging")).To(Succeed())
+		Expect(root.Spec.ClusterFolderEntries["operations"].ChildFolders).To(BeEmpty())
+		Expect(root.Spec.ClusterFolderEntries["staging"].ChildFolders).To(ConsistOf("production"))
+
+		Expect(moveClusterFolderInIndex(root, "production", "")).To(Succeed())
+		Expect(root.Spec.ClusterFolderEntries["staging"].ChildFolders).To(BeEmpty())
+	})
+
+	It("should refuse to move a cluster folder below itself", func() {
+		Expect(moveClusterFolderInIndex(root, "operations", "production")).To(MatchError(
+			"cannot move cluster folder [operations] into itself or one of its descendants"))
+		Expect(moveClusterFolderInIndex(root, "operations", "missing")).To(MatchError(
+			"cluster folder [missing] does not exist in the folder index"))
+	})
+
+	It("should move namespaces between cluster folders", func() {
+		Expect(moveNamespaceInIndex(root, "prod-web-apps", "staging")).To(Succeed())
+		Expect(root.Spec.ClusterFolderEntries["production"].Namespaces).To(BeEmpty())
+		Expect(root.Spec.ClusterFolderEntries["staging"].Namespaces).To(ConsistOf("prod-web-apps"))
+	})
+
+	It("should nest namespaced folders within a namespace only", func() {
+		Expect(moveNamespacedFolderInIndex(root, "prod-web-apps/prod-web-app-b", "prod-web-apps/prod-web-app-a")).To(Succeed())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].ChildFolders).To(ConsistOf("prod-web-apps/prod-web-app-b"))
+
+		Expect(moveNamespacedFolderInIndex(root, "prod-web-apps/prod-web-app-a", "prod-web-apps/prod-web-app-b")).To(MatchError(
+			"cannot move namespaced folder [prod-web-apps/prod-web-app-a] into itself or one of its descendants"))
+		Expect(moveNamespacedFolderInIndex(root, "prod-web-apps/prod-web-app-a", "dev/dev-apps")).To(MatchError(
+			"cannot move [prod-web-apps/prod-web-app-a] into namespaced folder [dev/dev-apps] in another namespace"))
+	})
+
+	It("should refile and unfile VMs", func() {
+		Expect(moveVMInIndex(root, "prod-web-apps", "web-app-a", "prod-web-apps/prod-web-app-b")).To(Succeed())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"].VirtualMachines).To(BeEmpty())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-b"].VirtualMachines).To(ConsistOf("web-app-a", "web-app-b"))
+
+		Expect(moveVMInIndex(root, "prod-web-apps", "web-app-a", "")).To(Succeed())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-b"].VirtualMachines).To(ConsistOf("web-app-b"))
+
+		Expect(moveVMInIndex(root, "prod-web-apps", "web-app-b", "dev/dev-apps")).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,383 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// mvKinds maps the accepted spellings of the kind argument of mv to the
+// kind of entry moved.
+var mvKinds = map[string]string{
+	"vm":                folderindex.KindVirtualMachine,
+	"vms":               folderindex.KindVirtualMachine,
+	"virtualmachine":    folderindex.KindVirtualMachine,
+	"virtualmachines":   folderindex.KindVirtualMachine,
+	"ns":                folderindex.KindNamespace,
+	"namespace":         folderindex.KindNamespace,
+	"namespaces":        folderindex.KindNamespace,
+	"clusterfolder":     folderindex.KindClusterFolder,
+	"clusterfolders":    folderindex.KindClusterFolder,
+	"namespacedfolder":  folderindex.KindNamespacedFolder,
+	"namespacedfolders": folderindex.KindNamespacedFolder,
+}
+
+// mvRequest is a move of many entries of one kind into a single folder.
+type mvRequest struct {
+	kind string
+	// namespace of the VMs and NamespacedFolders moved
+	namespace string
+	names     []string
+	selector  labels.Selector
+	// target is the folder name, empty to move the entries out of their
+	// folder to the top level
+	target string
+}
+
+// selectMvNames returns the names given on the command line together with
+// the names of the objects of the kind matching the label selector, sorted
+// and without duplicates.
+func selectMvNames(ctx context.Context, cl client.Reader, req mvRequest) ([]string, error) {
+	names := slices.Clone(req.names)
+
+	if req.selector != nil {
+		opts := []client.ListOption{client.MatchingLabelsSelector{Selector: req.selector}}
+		if req.namespace != "" {
+			opts = append(opts, client.InNamespace(req.namespace))
+		}
+
+		matched := []string{}
+		switch req.kind {
+		case folderindex.KindVirtualMachine:
+			list := &virtv1.VirtualMachineList{}
+			if err := cl.List(ctx, list, opts...); err != nil {
+				return nil, fmt.Errorf("failed to list virtual machines: %v", err)
+			}
+			for _, vm := range list.Items {
+				matched = append(matched, vm.Name)
+			}
+		case folderindex.KindNamespace:
+			list := &corev1.NamespaceList{}
+			if err := cl.List(ctx, list, opts...); err != nil {
+				return nil, fmt.Errorf("failed to list namespaces: %v", err)
+			}
+			for _, ns := range list.Items {
+				matched = append(matched, ns.Name)
+			}
+		case folderindex.KindClusterFolder:
+			list := &v1alpha1.ClusterFolderList{}
+			if err := cl.List(ctx, list, opts...); err != nil {
+				return nil, fmt.Errorf("failed to list cluster folders: %v", err)
+			}
+			for _, folder := range list.Items {
+				matched = append(matched, folder.Name)
+			}
+		case folderindex.KindNamespacedFolder:
+			list := &v1alpha1.NamespacedFolderList{}
+			if err := cl.List(ctx, list, opts...); err != nil {
+				return nil, fmt.Errorf("failed to list namespaced folders: %v", err)
+			}
+			for _, folder := range list.Items {
+				matched = append(matched, folder.Name)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no %s matches the selector [%s]", strings.ToLower(req.kind), req.selector)
+		}
+		names = append(names, matched...)
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// mvChange returns the change to the index moving every named entry into
+// the target. All moves are checked against the index the change is applied
+// to, so the first invalid move fails the whole change.
+func mvChange(req mvRequest, names []string) func(root *v1alpha1.FolderIndex) error {
+	return func(root *v1alpha1.FolderIndex) error {
+		for _, name := range names {
+			var err error
+			switch req.kind {
+			case folderindex.KindVirtualMachine:
+				target := ""
+				if req.target != "" {
+					target = folderindex.NamespacedFolderKey(req.namespace, req.target)
+				}
+				err = moveVMInIndex(root, req.namespace, name, target)
+			case folderindex.KindNamespace:
+				err = moveNamespaceInIndex(root, name, req.target)
+			case folderindex.KindClusterFolder:
+				err = moveClusterFolderInIndex(root, name, req.target)
+			case folderindex.KindNamespacedFolder:
+				target := ""
+				if req.target != "" {
+					target = folderindex.NamespacedFolderKey(req.namespace, req.target)
+				}
+				err = moveNamespacedFolderInIndex(root, folderindex.NamespacedFolderKey(req.namespace, name), target)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// diffIndex describes every entry of the index that differs between orig
+// and modified, one line per changed field.
+func diffIndex(orig *v1alpha1.FolderIndex, modified *v1alpha1.FolderIndex) []string {
+	lines := []string{}
+	field := func(path string, before []string, after []string) {
+		if !equality.Semantic.DeepEqual(emptyToNil(before), emptyToNil(after)) {
+			lines = append(lines, fmt.Sprintf("~ %s: [%s] -> [%s]", path, strings.Join(before, ", "), strings.Join(after, ", ")))
+		}
+	}
+
+	clusterKeys := map[string]bool{}
+	for key := range orig.Spec.ClusterFolderEntries {
+		clusterKeys[key] = true
+	}
+	for key := range modified.Spec.ClusterFolderEntries {
+		clusterKeys[key] = true
+	}
+	for _, key := range slices.Sorted(maps.Keys(clusterKeys)) {
+		before := orig.Spec.ClusterFolderEntries[key]
+		after := modified.Spec.ClusterFolderEntries[key]
+		field(fmt.Sprintf("clusterFolderEntries[%s].childFolders", key), before.ChildFolders, after.ChildFolders)
+		field(fmt.Sprintf("clusterFolderEntries[%s].namespaces", key), before.Namespaces, after.Namespaces)
+	}
+
+	namespacedKeys := map[string]bool{}
+	for key := range orig.Spec.NamespacedFolderEntries {
+		namespacedKeys[key] = true
+	}
+	for key := range modified.Spec.NamespacedFolderEntries {
+		namespacedKeys[key] = true
+	}
+	for _, key := range slices.Sorted(maps.Keys(namespacedKeys)) {
+		before := orig.Spec.NamespacedFolderEntries[key]
+		after := modified.Spec.NamespacedFolderEntries[key]
+		field(fmt.Sprintf("namespacedFolderEntries[%s].childFolders", key), before.ChildFolders, after.ChildFolders)
+		field(fmt.Sprintf("namespacedFolderEntries[%s].virtualMachines", key), before.VirtualMachines, after.VirtualMachines)
+	}
+
+	return lines
+}
+
+func emptyToNil(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// printMvDryRun prints the index diff of the move followed by the Roles and
+// RoleBindings it would create and delete.
+func printMvDryRun(ctx context.Context, w io.Writer, current []client.Object, orig *v1alpha1.FolderIndex, modified *v1alpha1.FolderIndex) error {
+	fmt.Fprintln(w, "Folder index changes:")
+	for _, line := range diffIndex(orig, modified) {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+
+	plan, err := planRBAC(ctx, current, []client.Object{modified})
+	if err != nil {
+		return fmt.Errorf("failed to plan folder changes: %v", err)
+	}
+	fmt.Fprintln(w, "RBAC changes:")
+	printPlan(w, plan)
+	return nil
+}
+
+func completeMvArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return []string{"vm", "ns", "clusterfolder", "namespacedfolder"}, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	namespace := ""
+	switch mvKinds[args[0]] {
+	case folderindex.KindVirtualMachine, folderindex.KindNamespacedFolder:
+		var err error
+		if namespace, err = contextNamespace(); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	switch mvKinds[args[0]] {
+	case folderindex.KindVirtualMachine:
+		return completeWith(func(ctx context.Context, cl client.Reader) []string {
+			names := []string{}
+			for _, key := range vmCompletions(ctx, cl) {
+				vmNamespace, vm, _ := folderindex.SplitNamespacedFolderKey(key)
+				if vmNamespace == namespace {
+					names = append(names, vm)
+				}
+			}
+			return names
+		})
+	case folderindex.KindNamespace:
+		return completeWith(namespaceCompletions)
+	case folderindex.KindClusterFolder, folderindex.KindNamespacedFolder:
+		return completeWith(func(ctx context.Context, cl client.Reader) []string {
+			return folderCompletions(ctx, cl, namespace)
+		})
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeMvTarget completes --to with the folders the entries can be moved
+// into, NamespacedFolders of the namespace for VMs and NamespacedFolders and
+// ClusterFolders otherwise.
+func completeMvTarget(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	namespace := ""
+	if len(args) != 0 {
+		switch mvKinds[args[0]] {
+		case folderindex.KindVirtualMachine, folderindex.KindNamespacedFolder:
+			var err error
+			if namespace, err = contextNamespace(); err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		}
+	}
+	return completeWith(func(ctx context.Context, cl client.Reader) []string {
+		return folderCompletions(ctx, cl, namespace)
+	})
+}
+
+func newMvCmd() *cobra.Command {
+	var selector string
+	var target string
+	var unfile bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "mv (vm | ns | clusterfolder | namespacedfolder) [NAME...] (--to FOLDER | --unfile)",
+		Short: "Move VMs, namespaces or folders into another folder",
+		Long: `Move VMs, namespaces or folders into another folder.
+
+The entries to move are named on the command line, selected with a label
+selector, or both. All of them are moved in a single update of the folder
+index, so either every move is applied or, when one of them is invalid or
+the index changed in the meantime, none is.
+
+VMs are moved into the NamespacedFolder --to of their namespace, given with
+--namespace or taken from the kubeconfig context, and namespaces into the ClusterFolder --to. ClusterFolders and
+NamespacedFolders are nested below the folder --to. --unfile removes the
+entries from their folders instead, moving folders to the top level.
+
+Before anything is written, the update of the folder index and of the
+FolderMemberships of the moved VMs is sent as a dry run, so a move the
+webhooks reject, such as one past the limits of the target folder, changes
+nothing. --dry-run prints the changes to the folder index and the Roles and
+RoleBindings the folder controllers would create and delete, after the
+webhooks accepted the change without persisting it.`,
+		Example: `  kubectl folder mv vm -n prod-web-apps -l app=web-app-b --to temp-folder-debug
+  kubectl folder mv ns -l env=staging --to staging --dry-run
+  kubectl folder mv vm -n prod-web-apps web-app-a web-app-a-db --unfile`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeMvArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			kind, ok := mvKinds[args[0]]
+			if !ok {
+				return fmt.Errorf("unsupported kind [%s], expected vm, ns, clusterfolder or namespacedfolder", args[0])
+			}
+			if (target == "") == !unfile {
+				return fmt.Errorf("exactly one of --to or --unfile is required")
+			}
+
+			req := mvRequest{kind: kind, names: args[1:], target: target}
+			if kind == folderindex.KindVirtualMachine || kind == folderindex.KindNamespacedFolder {
+				namespace, err := contextNamespace()
+				if err != nil {
+					return fmt.Errorf("failed to resolve the namespace to move %s in: %v", args[0], err)
+				}
+				req.namespace = namespace
+			}
+			if selector != "" {
+				parsed, err := labels.Parse(selector)
+				if err != nil {
+					return fmt.Errorf("invalid selector [%s]: %v", selector, err)
+				}
+				req.selector = parsed
+			}
+			if len(req.names) == 0 && req.selector == nil {
+				return fmt.Errorf("names or a --selector of the entries to move are required")
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			names, err := selectMvNames(ctx, cl, req)
+			if err != nil {
+				return err
+			}
+			change := mvChange(req, names)
+
+			if dryRun {
+				current, err := snapshotCluster(ctx, cl)
+				if err != nil {
+					return err
+				}
+				root, err := getRootIndex(ctx, cl)
+				if err != nil {
+					return err
+				}
+				memberships, err := listFolderMemberships(ctx, cl)
+				if err != nil {
+					return err
+				}
+				before, modified, err := changeFolderView(root, memberships, change)
+				if err != nil {
+					return err
+				}
+
+				newRoot, changed, _ := splitFolderView(before, modified, memberships)
+				if err := dryRunFolderView(ctx, cl, root, newRoot, changed); err != nil {
+					return err
+				}
+				return printMvDryRun(ctx, cmd.OutOrStdout(), withFolderView(current, before), before, modified)
+			}
+
+			if err := updateRootIndex(ctx, cl, change); err != nil {
+				return err
+			}
+
+			destination := "the top level"
+			if !unfile {
+				destination = fmt.Sprintf("[%s]", target)
+			}
+			for _, name := range names {
+				if req.namespace != "" {
+					name = folderindex.NamespacedFolderKey(req.namespace, name)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s [%s] moved to %s\n", args[0], name, destination)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Label selector of the entries to move, e.g. app=web-app-b")
+	cmd.Flags().StringVar(&target, "to", "", "Name of the folder to move the entries into")
+	_ = cmd.RegisterFlagCompletionFunc("to", completeMvTarget)
+	cmd.Flags().BoolVar(&unfile, "unfile", false, "Move the entries out of their folders instead")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the index and RBAC changes without applying them")
+
+	return cmd
+}
@@ -0,0 +1,147 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+var _ = Describe("mv", func() {
+	ctx := context.Background()
+
+	var objs []client.Object
+	var cl client.Client
+
+	BeforeEach(func() {
+		var root *v1alpha1.FolderIndex
+		root, objs = folderFixtureObjects()
+		root.Spec.ClusterFolderEntries["staging"] = v1alpha1.ClusterFolderEntry{}
+		root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-b"] = v1alpha1.NamespacedFolderEntry{
+			VirtualMachines: []string{"web-app-b", "web-app-b-db"},
+		}
+		root.Spec.NamespacedFolderEntries["prod-web-apps/temp-folder-debug"] = v1alpha1.NamespacedFolderEntry{}
+
+		vm := func(name string, app string) *virtv1.VirtualMachine {
+			return &virtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "prod-web-apps", Name: name, Labels: map[string]string{"app": app},
+			}}
+		}
+		objs = append(objs,
+			vm("web-app-a", "web-app-a"), vm("web-app-b", "web-app-b"), vm("web-app-b-db", "web-app-b"),
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "staging-a", Labels: map[string]string{"env": "staging"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "staging-b", Labels: map[string]string{"env": "staging"}}},
+			&v1alpha1.NamespacedFolder{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "temp-folder-debug", UID: "debug-uid"}},
+		)
+		cl = newStateClient(objs)
+	})
+
+	It("should move every VM matching a selector in one change", func() {
+		req := mvRequest{
+			kind:      folderindex.KindVirtualMachine,
+			namespace: "prod-web-apps",
+			selector:  labels.SelectorFromSet(labels.Set{"app": "web-app-b"}),
+			target:    "temp-folder-debug",
+		}
+		names, err := selectMvNames(ctx, cl, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"web-app-b", "web-app-b-db"}))
+
+		Expect(updateRootIndex(ctx, cl, mvChange(req, names))).To(Succeed())
+
+		root, err := getRootIndex(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-b"].VirtualMachines).To(BeEmpty())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/temp-folder-debug"].VirtualMachines).To(Equal([]string{"web-app-b", "web-app-b-db"}))
+	})
+
+	It("should apply none of the moves when one is invalid", func() {
+		req := mvRequest{kind: folderindex.KindClusterFolder, names: []string{"staging", "operations"}, target: "production"}
+		Expect(updateRootIndex(ctx, cl, mvChange(req, req.names))).To(MatchError(
+			"cannot move cluster folder [operations] into itself or one of its descendants"))
+
+		root, err := getRootIndex(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(root.Spec.ClusterFolderEntries["production"].ChildFolders).To(BeEmpty())
+	})
+
+	It("should write nothing when the webhooks reject the move of a membership", func() {
+		objs = append(objs, &v1alpha1.FolderMembership{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-a"},
+			Spec:       v1alpha1.FolderMembershipSpec{Folder: "prod-web-app-a"},
+		})
+		cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if _, ok := obj.(*v1alpha1.FolderMembership); ok {
+						return fmt.Errorf("filing vms into namespaced folder [prod-web-apps/temp-folder-debug] exceeds its limits")
+					}
+					return c.Update(ctx, obj, opts...)
+				},
+			}).Build()
+
+		req := mvRequest{
+			kind:      folderindex.KindVirtualMachine,
+			namespace: "prod-web-apps",
+			names:     []string{"web-app-a", "web-app-b"},
+			target:    "temp-folder-debug",
+		}
+		Expect(updateRootIndex(ctx, cl, mvChange(req, req.names))).To(MatchError(ContainSubstring("exceeds its limits")))
+
+		root, err := getRootIndex(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-b"].VirtualMachines).To(ContainElement("web-app-b"))
+		Expect(root.Spec.NamespacedFolderEntries["prod-web-apps/temp-folder-debug"].VirtualMachines).To(BeEmpty())
+	})
+
+	It("should fail when the selector matches nothing", func() {
+		req := mvRequest{kind: folderindex.KindNamespace, selector: labels.SelectorFromSet(labels.Set{"env": "missing"})}
+		_, err := selectMvNames(ctx, cl, req)
+		Expect(err).To(MatchError("no namespace matches the selector [env=missing]"))
+	})
+
+	It("should show the index diff and RBAC delta of a dry run", func() {
+		req := mvRequest{
+			kind:     folderindex.KindNamespace,
+			names:    []string{"prod-web-apps"},
+			selector: labels.SelectorFromSet(labels.Set{"env": "staging"}),
+			target:   "staging",
+		}
+		names, err := selectMvNames(ctx, cl, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"prod-web-apps", "staging-a", "staging-b"}))
+
+		root, err := getRootIndex(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		modified := root.DeepCopy()
+		Expect(mvChange(req, names)(modified)).To(Succeed())
+
+		current, err := snapshotCluster(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+
+		var out bytes.Buffer
+		Expect(printMvDryRun(ctx, &out, current, root, modified)).To(Succeed())
+		Expect(out.String()).To(HavePrefix(`Folder index changes:
+  ~ clusterFolderEntries[production].namespaces: [prod-web-apps] -> []
+  ~ clusterFolderEntries[staging].namespaces: [] -> [prod-web-apps, staging-a, staging-b]
+RBAC changes:
+`))
+		// prod-web-apps leaves the operations ClusterFolder, so its admin
+		// binding there goes away
+		Expect(out.String()).To(ContainSubstring("- RoleBinding prod-web-apps/"))
+		Expect(out.String()).To(ContainSubstring("Plan: 0 to create, 1 to delete."))
+	})
+})
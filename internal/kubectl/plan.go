@@ -0,0 +1,405 @@
+package kubectl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// pendingUIDPrefix marks the UID given to proposed folders that do not exist
+// yet. The API server assigns the real UID on creation, so the names of the
+// RBAC objects generated for these folders are not known in advance.
+const pendingUIDPrefix = "pending-"
+
+// plannedObject is a Role or RoleBinding the folder controllers maintain.
+type plannedObject struct {
+	Kind      string              `json:"kind"`
+	Namespace string              `json:"namespace"`
+	Name      string              `json:"name,omitempty"`
+	Folder    string              `json:"folder"`
+	Subjects  []rbacv1.Subject    `json:"subjects,omitempty"`
+	RoleRef   *rbacv1.RoleRef     `json:"roleRef,omitempty"`
+	Rules     []rbacv1.PolicyRule `json:"rules,omitempty"`
+
+	// key identifies the object across plans even when Name is not known
+	key string
+}
+
+// rbacPlan is the difference between the RBAC generated for the current and
+// the proposed folder state.
+type rbacPlan struct {
+	Create   []plannedObject `json:"create"`
+	Delete   []plannedObject `json:"delete"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// snapshotCluster reads every object that influences the RBAC the folder
+// controllers generate.
+func snapshotCluster(ctx context.Context, cl client.Reader) ([]client.Object, error) {
+	objs := []client.Object{}
+
+	lists := []client.ObjectList{
+		&v1alpha1.FolderIndexList{},
+		&v1alpha1.ClusterFolderList{},
+		&v1alpha1.NamespacedFolderList{},
+		&corev1.NamespaceList{},
+		&rbacv1.ClusterRoleList{},
+		&rbacv1.RoleList{},
+	}
+	for _, list := range lists {
+		if err := cl.List(ctx, list); err != nil {
+			return nil, fmt.Errorf("failed to list %T: %v", list, err)
+		}
+
+		items, err := apimeta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			objs = append(objs, item.(client.Object))
+		}
+	}
+
+	memberships, err := listFolderMemberships(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	for i := range memberships {
+		objs = append(objs, &memberships[i])
+	}
+
+	return objs, nil
+}
+
+func objectKey(obj client.Object) string {
+	gvk, _ := apiutil.GVKForObject(obj, scheme.Scheme)
+	return fmt.Sprintf("%s/%s/%s", gvk.Kind, obj.GetNamespace(), obj.GetName())
+}
+
+// withFolderView replaces the FolderIndex and FolderMemberships of a
+// snapshot with the folder view, so changes to the view can be planned like
+// changes to the index.
+func withFolderView(objs []client.Object, view *v1alpha1.FolderIndex) []client.Object {
+	state := []client.Object{}
+	for _, obj := range objs {
+		switch obj.(type) {
+		case *v1alpha1.FolderIndex, *v1alpha1.FolderMembership:
+		default:
+			state = append(state, obj)
+		}
+	}
+	return append(state, view)
+}
+
+// overlayManifests replaces objects in the current state with the proposed
+// manifests of the same kind, namespace and name. Proposed folders keep the
+// UID of the folder they replace, new folders get a pending UID.
+func overlayManifests(current []client.Object, proposed []client.Object) ([]client.Object, error) {
+	byKey := map[string]client.Object{}
+	order := []string{}
+	for _, obj := range current {
+		key := objectKey(obj)
+		byKey[key] = obj
+		order = append(order, key)
+	}
+
+	for _, obj := range proposed {
+		switch obj.(type) {
+		case *v1alpha1.FolderIndex, *v1alpha1.ClusterFolder, *v1alpha1.NamespacedFolder:
+		default:
+			return nil, fmt.Errorf("unsupported proposed object %s, only FolderIndex, ClusterFolder and NamespacedFolder are supported", objectKey(obj))
+		}
+
+		obj = obj.DeepCopyObject().(client.Object)
+		obj.SetResourceVersion("")
+		key := objectKey(obj)
+		if existing, exists := byKey[key]; exists {
+			obj.SetUID(existing.GetUID())
+		} else {
+			obj.SetUID(types.UID(pendingUIDPrefix + key))
+			order = append(order, key)
+		}
+		byKey[key] = obj
+	}
+
+	objs := []client.Object{}
+	for _, key := range order {
+		objs = append(objs, byKey[key])
+	}
+	return objs, nil
+}
+
+// desiredRBAC computes every Role and RoleBinding the folder controllers
+// would maintain for the state held by cl.
+func desiredRBAC(ctx context.Context, cl client.Reader) (map[string]plannedObject, []string, error) {
+	planned := map[string]plannedObject{}
+	warnings := []string{}
+
+	root, err := getFolderView(ctx, cl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := cl.List(ctx, namespaceList); err != nil {
+		return nil, nil, err
+	}
+	existingNamespaces := map[string]bool{}
+	for _, ns := range namespaceList.Items {
+		existingNamespaces[ns.Name] = true
+	}
+
+	add := func(obj plannedObject, folderUID types.UID) {
+		obj.key = fmt.Sprintf("%s/%s/%s", obj.Kind, obj.Namespace, obj.Name)
+		if strings.HasPrefix(string(folderUID), pendingUIDPrefix) {
+			obj.Name = ""
+		}
+		planned[obj.key] = obj
+	}
+
+	clusterFolders := &v1alpha1.ClusterFolderList{}
+	if err := cl.List(ctx, clusterFolders); err != nil {
+		return nil, nil, err
+	}
+	blocked, err := controller.BlockedClusterFolders(ctx, cl)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range clusterFolders.Items {
+		folder := &clusterFolders.Items[i]
+		directNamespaces, inheritedNamespaces := folderindex.ClusterFolderNamespaces(root, folder.Name, blocked)
+		for j, ns := range append(directNamespaces, inheritedNamespaces...) {
+			if !existingNamespaces[ns] {
+				warnings = append(warnings, fmt.Sprintf("namespace [%s] of cluster folder [%s] does not exist, no RoleBindings are created in it", ns, folder.Name))
+				continue
+			}
+			roleBindings, err := controller.ClusterFolderRoleBindings(folder, ns, j >= len(directNamespaces))
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, rb := range roleBindings {
+				add(plannedObject{
+					Kind:      "RoleBinding",
+					Namespace: rb.Namespace,
+					Name:      rb.Name,
+					Folder:    fmt.Sprintf("ClusterFolder/%s", folder.Name),
+					Subjects:  rb.Subjects,
+					RoleRef:   &rb.RoleRef,
+				}, folder.UID)
+			}
+		}
+	}
+
+	namespacedFolders := &v1alpha1.NamespacedFolderList{}
+	if err := cl.List(ctx, namespacedFolders); err != nil {
+		return nil, nil, err
+	}
+	for i := range namespacedFolders.Items {
+		folder := &namespacedFolders.Items[i]
+		key := folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)
+
+		for _, fp := range folder.Spec.FolderPermissions {
+			for _, rr := range fp.RoleRefs {
+				rules, err := controller.GetRoleRefRules(ctx, cl, folder.Namespace, rr)
+				if err != nil {
+					return nil, nil, err
+				}
+				if rules == nil {
+					warnings = append(warnings, fmt.Sprintf("%s referenced by namespaced folder [%s] was not found, it grants nothing", formatRoleRef(rr), key))
+				}
+			}
+		}
+
+		vms, directVMs, err := namespacedFolderVMs(ctx, cl, root, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		grants, err := controller.NamespacedFolderGrants(ctx, cl, folder, vms, directVMs)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, grant := range grants {
+			add(plannedObject{
+				Kind:      "Role",
+				Namespace: grant.Role.Namespace,
+				Name:      grant.Role.Name,
+				Folder:    fmt.Sprintf("NamespacedFolder/%s", key),
+				Rules:     grant.Role.Rules,
+			}, folder.UID)
+			add(plannedObject{
+				Kind:      "RoleBinding",
+				Namespace: grant.RoleBinding.Namespace,
+				Name:      grant.RoleBinding.Name,
+				Folder:    fmt.Sprintf("NamespacedFolder/%s", key),
+				Subjects:  grant.RoleBinding.Subjects,
+				RoleRef:   &grant.RoleBinding.RoleRef,
+			}, folder.UID)
+		}
+	}
+
+	return planned, warnings, nil
+}
+
+// planRBAC computes the Roles and RoleBindings that applying the proposed
+// manifests on top of the current state would create and delete.
+func planRBAC(ctx context.Context, current []client.Object, proposed []client.Object) (*rbacPlan, error) {
+	after, err := overlayManifests(current, proposed)
+	if err != nil {
+		return nil, err
+	}
+
+	before, _, err := desiredRBAC(ctx, newStateReader(current))
+	if err != nil {
+		return nil, err
+	}
+	planned, warnings, err := desiredRBAC(ctx, newStateReader(after))
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &rbacPlan{
+		Create:   []plannedObject{},
+		Delete:   []plannedObject{},
+		Warnings: warnings,
+	}
+	for key, obj := range planned {
+		if _, exists := before[key]; !exists {
+			plan.Create = append(plan.Create, obj)
+		}
+	}
+	for key, obj := range before {
+		if _, exists := planned[key]; !exists {
+			plan.Delete = append(plan.Delete, obj)
+		}
+	}
+
+	for _, objs := range [][]plannedObject{plan.Create, plan.Delete} {
+		sort.Slice(objs, func(i, j int) bool {
+			if objs[i].Folder != objs[j].Folder {
+				return objs[i].Folder < objs[j].Folder
+			}
+			return objs[i].key < objs[j].key
+		})
+	}
+
+	return plan, nil
+}
+
+func describePlannedObject(obj plannedObject) string {
+	name := obj.Name
+	if name == "" {
+		name = "<assigned on folder creation>"
+	}
+	s := fmt.Sprintf("%s %s/%s from %s", obj.Kind, obj.Namespace, name, obj.Folder)
+	if obj.RoleRef != nil && len(obj.Subjects) != 0 {
+		s = fmt.Sprintf("%s: %s -> %s", s, formatSubject(obj.Subjects[0]), formatRoleRef(*obj.RoleRef))
+	}
+	return s
+}
+
+func printPlan(w io.Writer, plan *rbacPlan) {
+	for _, warning := range plan.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+	for _, obj := range plan.Create {
+		fmt.Fprintf(w, "+ %s\n", describePlannedObject(obj))
+	}
+	for _, obj := range plan.Delete {
+		fmt.Fprintf(w, "- %s\n", describePlannedObject(obj))
+	}
+	fmt.Fprintf(w, "Plan: %d to create, %d to delete.\n", len(plan.Create), len(plan.Delete))
+}
+
+func newPlanCmd() *cobra.Command {
+	var filename string
+	var fromFile string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "plan -f FILE",
+		Short: "Show the Roles and RoleBindings a folder change would create or delete",
+		Long: `Show the Roles and RoleBindings a folder change would create or delete.
+
+FILE holds proposed FolderIndex, ClusterFolder and NamespacedFolder manifests.
+They replace the objects of the same name in the current state, and the RBAC
+the folder controllers generate is computed for the state before and after
+the change.
+
+The current state is read from the cluster, or with --from-file from a local
+snapshot holding the FolderIndex, folders, Namespaces, ClusterRoles and Roles.
+No cluster is contacted when --from-file is given.
+
+Objects generated for folders that do not exist yet are named from the UID
+the folder receives when it is created, so their names are not shown.`,
+		Example: `  kubectl folder plan -f changes.yaml
+  kubectl folder plan -f changes.yaml --from-file snapshot.yaml -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			if output != "text" && output != "json" {
+				return fmt.Errorf("unsupported output format [%s], expected text or json", output)
+			}
+
+			proposed, err := readManifests(filename)
+			if err != nil {
+				return err
+			}
+
+			var current []client.Object
+			if fromFile != "" {
+				current, err = readManifests(fromFile)
+				if err != nil {
+					return err
+				}
+			} else {
+				cl, err := newClient()
+				if err != nil {
+					return fmt.Errorf("failed to create client: %v", err)
+				}
+				current, err = snapshotCluster(ctx, cl)
+				if err != nil {
+					return err
+				}
+			}
+
+			plan, err := planRBAC(ctx, current, proposed)
+			if err != nil {
+				return fmt.Errorf("failed to plan folder changes: %v", err)
+			}
+
+			if output == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(plan)
+			}
+			printPlan(os.Stdout, plan)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "File holding the proposed folder manifests, - for stdin")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "Snapshot file to use as the current state instead of the cluster")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format, text or json")
+	_ = cmd.RegisterFlagCompletionFunc("output", completeFixed("text", "json"))
+	_ = cmd.MarkFlagRequired("filename")
+
+	return cmd
+}
@@ -0,0 +1,113 @@
+package kubectl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("plan", func() {
+	ctx := context.Background()
+
+	var root *v1alpha1.FolderIndex
+	var current []client.Object
+
+	BeforeEach(func() {
+		root, current = folderFixtureObjects()
+	})
+
+	folders := func(objs []plannedObject) []string {
+		names := []string{}
+		for _, obj := range objs {
+			names = append(names, obj.Kind+" "+obj.Folder)
+		}
+		return names
+	}
+
+	It("should plan nothing when the proposed state matches", func() {
+		plan, err := planRBAC(ctx, current, []client.Object{root.DeepCopy()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Create).To(BeEmpty())
+		Expect(plan.Delete).To(BeEmpty())
+	})
+
+	It("should replace the generated Roles when a VM moves between folders", func() {
+		proposed := root.DeepCopy()
+		proposed.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-a"] = v1alpha1.NamespacedFolderEntry{}
+		proposed.Spec.NamespacedFolderEntries["prod-web-apps/prod-web-app-b"] = v1alpha1.NamespacedFolderEntry{
+			VirtualMachines: []string{"web-app-a", "web-app-b"},
+		}
+
+		plan, err := planRBAC(ctx, current, []client.Object{proposed})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(folders(plan.Create)).To(ConsistOf(
+			"Role NamespacedFolder/prod-web-apps/prod-web-app-b",
+			"RoleBinding NamespacedFolder/prod-web-apps/prod-web-app-b"))
+		Expect(folders(plan.Delete)).To(ConsistOf(
+			"Role NamespacedFolder/prod-web-apps/prod-web-app-a",
+			"RoleBinding NamespacedFolder/prod-web-apps/prod-web-app-a",
+			"Role NamespacedFolder/prod-web-apps/prod-web-app-b",
+			"RoleBinding NamespacedFolder/prod-web-apps/prod-web-app-b"))
+	})
+
+	It("should not name objects generated for folders that do not exist yet", func() {
+		proposedRoot := root.DeepCopy()
+		proposedRoot.Spec.NamespacedFolderEntries["prod-web-apps/temp-folder-debug"] = v1alpha1.NamespacedFolderEntry{
+			VirtualMachines: []string{"web-app-c"},
+		}
+		folder := &v1alpha1.NamespacedFolder{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "temp-folder-debug"},
+			Spec: v1alpha1.NamespacedFolderSpec{
+				FolderPermissions: []v1alpha1.FolderPermission{{
+					Subject:  rbacv1.Subject{Kind: "User", Name: "steve"},
+					RoleRefs: []rbacv1.RoleRef{{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "admin"}},
+				}},
+			},
+		}
+
+		plan, err := planRBAC(ctx, current, []client.Object{proposedRoot, folder})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Delete).To(BeEmpty())
+		Expect(plan.Create).To(HaveLen(2))
+		for _, obj := range plan.Create {
+			Expect(obj.Name).To(BeEmpty())
+		}
+	})
+
+	It("should reject proposed objects that are not folders", func() {
+		_, err := planRBAC(ctx, current, []client.Object{&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "edit"}}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should read multi document manifests and lists", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "changes.yaml")
+		Expect(os.WriteFile(path, []byte(`apiVersion: kubevirtfolderview.kubevirt.io.github.com/v1alpha1
+kind: ClusterFolder
+metadata:
+  name: operations
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: kubevirtfolderview.kubevirt.io.github.com/v1alpha1
+  kind: NamespacedFolder
+  metadata:
+    name: temp-folder-debug
+    namespace: prod-web-apps
+`), 0o600)).To(Succeed())
+
+		objs, err := readManifests(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(2))
+		Expect(objs[0]).To(BeAssignableToTypeOf(&v1alpha1.ClusterFolder{}))
+		Expect(objs[1]).To(BeAssignableToTypeOf(&v1alpha1.NamespacedFolder{}))
+	})
+})
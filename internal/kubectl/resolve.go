@@ -0,0 +1,267 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// folderGrant is a single subject and RoleRef from a folder's permissions,
+// resolved into the RBAC objects the controllers generate for it.
+type folderGrant struct {
+	subject rbacv1.Subject
+	// folder is the folder that declares the permission, e.g.
+	// ClusterFolder/operations or NamespacedFolder/prod-web-apps/prod-web-app-a
+	folder  string
+	roleRef rbacv1.RoleRef
+	// role is the generated Role for NamespacedFolder grants, ClusterFolder
+	// grants bind roleRef directly.
+	role        string
+	roleBinding string
+	namespace   string
+	rules       []rbacv1.PolicyRule
+	// vms are the VirtualMachines a NamespacedFolder grant reaches.
+	vms []string
+}
+
+// vmAccess is the RBAC request an action on a VirtualMachine is authorized against.
+type vmAccess struct {
+	verb     string
+	apiGroup string
+	resource string
+}
+
+// vmActions maps the KubeVirt actions users think in to the subresource
+// requests virtctl and the UI issue for them.
+var vmActions = map[string]vmAccess{
+	"start":        {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachines/start"},
+	"stop":         {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachines/stop"},
+	"restart":      {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachines/restart"},
+	"migrate":      {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachines/migrate"},
+	"addvolume":    {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachines/addvolume"},
+	"removevolume": {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachines/removevolume"},
+	"memorydump":   {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachines/memorydump"},
+	"portforward":  {verb: "get", apiGroup: "subresources.kubevirt.io", resource: "virtualmachines/portforward"},
+	"console":      {verb: "get", apiGroup: "subresources.kubevirt.io", resource: "virtualmachineinstances/console"},
+	"vnc":          {verb: "get", apiGroup: "subresources.kubevirt.io", resource: "virtualmachineinstances/vnc"},
+	"guestosinfo":  {verb: "get", apiGroup: "subresources.kubevirt.io", resource: "virtualmachineinstances/guestosinfo"},
+	"pause":        {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachineinstances/pause"},
+	"unpause":      {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachineinstances/unpause"},
+	"softreboot":   {verb: "update", apiGroup: "subresources.kubevirt.io", resource: "virtualmachineinstances/softreboot"},
+}
+
+// vmAccessFor returns the RBAC request for a KubeVirt action such as start,
+// or for a plain verb such as get or delete on the VirtualMachine itself.
+func vmAccessFor(verb string) vmAccess {
+	if access, ok := vmActions[verb]; ok {
+		return access
+	}
+	return vmAccess{verb: verb, apiGroup: "kubevirt.io", resource: "virtualmachines"}
+}
+
+func (a vmAccess) String() string {
+	return fmt.Sprintf("%s %s.%s", a.verb, a.resource, a.apiGroup)
+}
+
+// rulesAllow reports whether any of the rules authorize access to the named
+// object, following the matching semantics of the Kubernetes RBAC authorizer.
+func rulesAllow(rules []rbacv1.PolicyRule, access vmAccess, name string) bool {
+	for _, rule := range rules {
+		if ruleAllows(rule, access, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleAllows(rule rbacv1.PolicyRule, access vmAccess, name string) bool {
+	if !slices.Contains(rule.Verbs, rbacv1.VerbAll) && !slices.Contains(rule.Verbs, access.verb) {
+		return false
+	}
+	if !slices.Contains(rule.APIGroups, rbacv1.APIGroupAll) && !slices.Contains(rule.APIGroups, access.apiGroup) {
+		return false
+	}
+	if len(rule.ResourceNames) != 0 && !slices.Contains(rule.ResourceNames, name) {
+		return false
+	}
+
+	subresource := ""
+	if parts := strings.SplitN(access.resource, "/", 2); len(parts) == 2 {
+		subresource = parts[1]
+	}
+	for _, resource := range rule.Resources {
+		if resource == rbacv1.ResourceAll || resource == access.resource {
+			return true
+		}
+		if subresource != "" && resource == "*/"+subresource {
+			return true
+		}
+	}
+	return false
+}
+
+func formatSubject(subject rbacv1.Subject) string {
+	if subject.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s", subject.Kind, subject.Namespace, subject.Name)
+	}
+	return fmt.Sprintf("%s/%s", subject.Kind, subject.Name)
+}
+
+func formatRoleRef(roleRef rbacv1.RoleRef) string {
+	return fmt.Sprintf("%s/%s", roleRef.Kind, roleRef.Name)
+}
+
+// resolveClusterFolderGrants returns the grants a ClusterFolder applies to a
+// namespace, using the same RoleBindings the ClusterFolder controller creates.
+// inherited is set for namespaces of descendants of the folder.
+func resolveClusterFolderGrants(ctx context.Context, cl client.Reader, folder *v1alpha1.ClusterFolder, namespace string, inherited bool) ([]folderGrant, error) {
+	grants := []folderGrant{}
+
+	roleBindings, err := controller.ClusterFolderRoleBindings(folder, namespace, inherited)
+	if err != nil {
+		return grants, err
+	}
+
+	for _, rb := range roleBindings {
+		rules, err := controller.GetRoleRefRules(ctx, cl, namespace, rb.RoleRef)
+		if err != nil {
+			return grants, err
+		}
+		grants = append(grants, folderGrant{
+			subject:     rb.Subjects[0],
+			folder:      fmt.Sprintf("ClusterFolder/%s", folder.Name),
+			roleRef:     rb.RoleRef,
+			roleBinding: rb.Name,
+			namespace:   namespace,
+			rules:       rules,
+		})
+	}
+
+	return grants, nil
+}
+
+// resolveNamespacedFolderGrants returns the grants a NamespacedFolder applies
+// to its VirtualMachines, using the same Roles and RoleBindings the
+// NamespacedFolder controller creates.
+func resolveNamespacedFolderGrants(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, folder *v1alpha1.NamespacedFolder) ([]folderGrant, error) {
+	grants := []folderGrant{}
+
+	key := folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)
+	vms, directVMs, err := namespacedFolderVMs(ctx, cl, root, key)
+	if err != nil {
+		return grants, err
+	}
+
+	namespacedGrants, err := controller.NamespacedFolderGrants(ctx, cl, folder, vms, directVMs)
+	if err != nil {
+		return grants, err
+	}
+
+	for _, grant := range namespacedGrants {
+		grants = append(grants, folderGrant{
+			subject:     grant.Subject,
+			folder:      fmt.Sprintf("NamespacedFolder/%s", key),
+			roleRef:     grant.RoleRef,
+			role:        grant.Role.Name,
+			roleBinding: grant.RoleBinding.Name,
+			namespace:   folder.Namespace,
+			rules:       grant.Role.Rules,
+			// every rule of the generated Role names the VirtualMachines
+			// the grant reaches
+			vms: grant.Role.Rules[0].ResourceNames,
+		})
+	}
+
+	return grants, nil
+}
+
+// namespacedFolderVMs returns the VirtualMachines the permissions of the
+// NamespacedFolder key reach, and the ones the folder holds itself, as
+// NamespacedFolderGrants takes them.
+func namespacedFolderVMs(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, key string) ([]string, []string, error) {
+	blocked, err := controller.BlockedNamespacedFolders(ctx, cl, folderindex.NamespacedFolderNamespace(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	directVMs, inheritedVMs := folderindex.NamespacedFolderVirtualMachines(root, key, blocked)
+	return append(slices.Clone(directVMs), inheritedVMs...), directVMs, nil
+}
+
+// resolveVMGrants returns every folder grant that applies to a VirtualMachine.
+// These come from the NamespacedFolder holding the VirtualMachine and its
+// ancestors, followed by the ClusterFolder holding the namespace and its
+// ancestors, up to the first folder of each that blocks inheritance. Folders
+// that are in the index but have no folder object are skipped, as the
+// controllers have nothing to reconcile for them.
+func resolveVMGrants(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, namespace string, vm string) ([]folderGrant, error) {
+	grants := []folderGrant{}
+
+	if parent, exists := folderindex.VirtualMachineParent(root, namespace, vm); exists {
+		blocked, err := controller.BlockedNamespacedFolders(ctx, cl, namespace)
+		if err != nil {
+			return grants, err
+		}
+		for _, key := range folderindex.InheritedAncestry(folderindex.NamespacedFolderAncestry(root, parent), blocked) {
+			folderNamespace, folderName, err := folderindex.SplitNamespacedFolderKey(key)
+			if err != nil {
+				return grants, err
+			}
+
+			folder := &v1alpha1.NamespacedFolder{}
+			if err := cl.Get(ctx, client.ObjectKey{Namespace: folderNamespace, Name: folderName}, folder); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return grants, err
+			}
+
+			folderGrants, err := resolveNamespacedFolderGrants(ctx, cl, root, folder)
+			if err != nil {
+				return grants, err
+			}
+			grants = append(grants, folderGrants...)
+		}
+	}
+
+	// the ClusterFolder controller only binds roles in namespaces that exist
+	if err := cl.Get(ctx, client.ObjectKey{Name: namespace}, &corev1.Namespace{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return grants, nil
+		}
+		return grants, err
+	}
+
+	if parent, exists := folderindex.NamespaceParent(root, namespace); exists {
+		blocked, err := controller.BlockedClusterFolders(ctx, cl)
+		if err != nil {
+			return grants, err
+		}
+		for i, name := range folderindex.InheritedAncestry(folderindex.ClusterFolderAncestry(root, parent), blocked) {
+			folder := &v1alpha1.ClusterFolder{}
+			if err := cl.Get(ctx, client.ObjectKey{Name: name}, folder); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return grants, err
+			}
+
+			folderGrants, err := resolveClusterFolderGrants(ctx, cl, folder, namespace, i > 0)
+			if err != nil {
+				return grants, err
+			}
+			grants = append(grants, folderGrants...)
+		}
+	}
+
+	return grants, nil
+}
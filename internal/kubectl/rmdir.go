@@ -0,0 +1,314 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+type rmdirOptions struct {
+	recursive bool
+	reparent  bool
+}
+
+// rmdirClusterFolder removes a ClusterFolder from the index and deletes its
+// object. Namespaces are never deleted, with --recursive they simply become
+// unfiled along with the removal of every descendant folder. With --reparent
+// the contents of a folder without a parent move to the top level, and are
+// returned after the removed folders.
+func rmdirClusterFolder(ctx context.Context, cl client.Client, name string, opts rmdirOptions) ([]string, []folderindex.PathEntry, error) {
+	removed := []string{name}
+	moved := []folderindex.PathEntry{}
+
+	err := updateRootIndex(ctx, cl, func(root *v1alpha1.FolderIndex) error {
+		entry, exists := root.Spec.ClusterFolderEntries[name]
+		if !exists {
+			return fmt.Errorf("cluster folder [%s] does not exist in the folder index", name)
+		}
+
+		isEmpty := len(entry.ChildFolders) == 0 && len(entry.Namespaces) == 0
+		if !isEmpty && !opts.recursive && !opts.reparent {
+			return fmt.Errorf("cluster folder [%s] is not empty, use --recursive or --reparent", name)
+		}
+
+		if opts.recursive {
+			var collect func(folder string)
+			collect = func(folder string) {
+				for _, child := range root.Spec.ClusterFolderEntries[folder].ChildFolders {
+					removed = append(removed, child)
+					collect(child)
+				}
+			}
+			collect(name)
+		}
+
+		if parent, hasParent := folderindex.ClusterFolderParent(root, name); hasParent {
+			parentEntry := root.Spec.ClusterFolderEntries[parent]
+			parentEntry.ChildFolders = folderindex.Remove(parentEntry.ChildFolders, name)
+			if opts.reparent {
+				parentEntry.ChildFolders = append(parentEntry.ChildFolders, entry.ChildFolders...)
+				parentEntry.Namespaces = append(parentEntry.Namespaces, entry.Namespaces...)
+			}
+			root.Spec.ClusterFolderEntries[parent] = parentEntry
+		} else if opts.reparent {
+			// child folders without a parent and namespaces in no folder are
+			// at the top level, so removing the entry moves them there
+			for _, child := range entry.ChildFolders {
+				moved = append(moved, folderindex.PathEntry{Kind: folderindex.KindClusterFolder, Name: child})
+			}
+			for _, namespace := range entry.Namespaces {
+				moved = append(moved, folderindex.PathEntry{Kind: folderindex.KindNamespace, Name: namespace})
+			}
+		}
+
+		for _, folder := range removed {
+			delete(root.Spec.ClusterFolderEntries, folder)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, folder := range removed {
+		obj := &v1alpha1.ClusterFolder{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: folder,
+			},
+		}
+		if err := cl.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return removed, moved, fmt.Errorf("removed cluster folder [%s] from the folder index but failed to delete it: %v", folder, err)
+		}
+	}
+
+	return removed, moved, nil
+}
+
+// rmdirNamespacedFolder removes a NamespacedFolder from the index and deletes
+// its object. VirtualMachines are never deleted, with --recursive they simply
+// become unfiled along with the removal of every descendant folder. With
+// --reparent the contents of a folder without a parent move to the top of its
+// namespace, and are returned after the removed folders.
+func rmdirNamespacedFolder(ctx context.Context, cl client.Client, namespace string, name string, opts rmdirOptions) ([]string, []folderindex.PathEntry, error) {
+	key := folderindex.NamespacedFolderKey(namespace, name)
+	removed := []string{key}
+	moved := []folderindex.PathEntry{}
+	parent := ""
+
+	err := updateRootIndex(ctx, cl, func(root *v1alpha1.FolderIndex) error {
+		entry, exists := root.Spec.NamespacedFolderEntries[key]
+		if !exists {
+			return fmt.Errorf("namespaced folder [%s] does not exist in the folder index", key)
+		}
+
+		isEmpty := len(entry.ChildFolders) == 0 && len(entry.VirtualMachines) == 0
+		if !isEmpty && !opts.recursive && !opts.reparent {
+			return fmt.Errorf("namespaced folder [%s] is not empty, use --recursive or --reparent", key)
+		}
+
+		if opts.recursive {
+			var collect func(folder string)
+			collect = func(folder string) {
+				for _, child := range root.Spec.NamespacedFolderEntries[folder].ChildFolders {
+					removed = append(removed, child)
+					collect(child)
+				}
+			}
+			collect(key)
+		}
+
+		var hasParent bool
+		if parent, hasParent = folderindex.NamespacedFolderParent(root, key); hasParent {
+			parentEntry := root.Spec.NamespacedFolderEntries[parent]
+			parentEntry.ChildFolders = folderindex.Remove(parentEntry.ChildFolders, key)
+			if opts.reparent {
+				parentEntry.ChildFolders = append(parentEntry.ChildFolders, entry.ChildFolders...)
+				parentEntry.VirtualMachines = append(parentEntry.VirtualMachines, entry.VirtualMachines...)
+			}
+			root.Spec.NamespacedFolderEntries[parent] = parentEntry
+		} else if opts.reparent {
+			// child folders without a parent are at the top of the namespace
+			// and VirtualMachines in no folder directly in it, so removing the
+			// entry moves them there
+			for _, child := range entry.ChildFolders {
+				moved = append(moved, folderindex.PathEntry{Kind: folderindex.KindNamespacedFolder, Name: child})
+			}
+			for _, vm := range entry.VirtualMachines {
+				moved = append(moved, folderindex.PathEntry{Kind: folderindex.KindVirtualMachine, Name: folderindex.NamespacedFolderKey(namespace, vm)})
+			}
+		}
+
+		for _, folder := range removed {
+			delete(root.Spec.NamespacedFolderEntries, folder)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := clearDefaultFolder(ctx, cl, namespace, removed); err != nil {
+		return removed, moved, fmt.Errorf("removed namespaced folder [%s] from the folder index but failed to clear the default folder of namespace [%s]: %v", key, namespace, err)
+	}
+	if !opts.reparent {
+		parent = ""
+	}
+	if err := settleMemberships(ctx, cl, namespace, removed, parent); err != nil {
+		return removed, moved, fmt.Errorf("removed namespaced folder [%s] from the folder index but failed to update its folder memberships: %v", key, err)
+	}
+
+	for _, folder := range removed {
+		folderNamespace, folderName, err := folderindex.SplitNamespacedFolderKey(folder)
+		if err != nil {
+			return removed, moved, err
+		}
+		obj := &v1alpha1.NamespacedFolder{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      folderName,
+				Namespace: folderNamespace,
+			},
+		}
+		if err := cl.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return removed, moved, fmt.Errorf("removed namespaced folder [%s] from the folder index but failed to delete it: %v", folder, err)
+		}
+	}
+
+	return removed, moved, nil
+}
+
+// clearDefaultFolder removes the DefaultFolderAnnotation of namespace when it
+// names one of the removed folders, so new VirtualMachines are not filed into
+// a folder that no longer exists.
+func clearDefaultFolder(ctx context.Context, cl client.Client, namespace string, removed []string) error {
+	ns := &corev1.Namespace{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	folder := ns.Annotations[v1alpha1.DefaultFolderAnnotation]
+	if folder == "" || !slices.Contains(removed, folderindex.NamespacedFolderKey(namespace, folder)) {
+		return nil
+	}
+
+	orig := ns.DeepCopy()
+	delete(ns.Annotations, v1alpha1.DefaultFolderAnnotation)
+	return cl.Patch(ctx, ns, client.MergeFrom(orig))
+}
+
+// settleMemberships moves the FolderMemberships of namespace that still name
+// one of the removed folders into the parent folder, or deletes them when
+// parent is empty. updateRootIndex already did so for the memberships it
+// read, this catches those filed into the folders while they were removed,
+// which would otherwise file their VirtualMachines into a folder later
+// created with the same name.
+func settleMemberships(ctx context.Context, cl client.Client, namespace string, removed []string, parent string) error {
+	list := &v1alpha1.FolderMembershipList{}
+	if err := cl.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	parentName := ""
+	if parent != "" {
+		_, name, err := folderindex.SplitNamespacedFolderKey(parent)
+		if err != nil {
+			return err
+		}
+		parentName = name
+	}
+	for i := range list.Items {
+		membership := &list.Items[i]
+		if !slices.Contains(removed, folderindex.NamespacedFolderKey(namespace, membership.Spec.Folder)) {
+			continue
+		}
+		if parentName == "" {
+			if err := cl.Delete(ctx, membership); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			continue
+		}
+		membership.Spec.Folder = parentName
+		if err := cl.Update(ctx, membership); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newRmdirCmd() *cobra.Command {
+	opts := rmdirOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "rmdir NAME",
+		Short: "Remove a folder from the folder index and delete it",
+		Long: `Remove a folder from the folder index and delete it.
+
+Without --namespace a ClusterFolder is removed. With --namespace a
+NamespacedFolder in that namespace is removed.
+
+A folder that still contains folders, namespaces or VirtualMachines is only
+removed when either --recursive or --reparent is given. --recursive removes
+every descendant folder as well, leaving their namespaces or VirtualMachines
+unfiled. --reparent moves the contents of the folder into its parent folder.
+The contents of a ClusterFolder without a parent move to the top level, and
+those of a NamespacedFolder without a parent to the top of its namespace,
+where its VirtualMachines are in no NamespacedFolder.
+
+FolderMemberships count toward the contents of a folder. --reparent moves
+them into the parent folder, while --recursive, or --reparent on a
+NamespacedFolder without a parent, deletes them.
+
+Namespaces and VirtualMachines themselves are never deleted. A namespace
+whose default folder is removed is left without a default folder.`,
+		Example: `  kubectl folder rmdir --reparent staging
+  kubectl folder rmdir -n prod-web-apps --recursive temp-folder-debug`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFolderArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			name := args[0]
+			namespace := namespaceFlag(cmd)
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			kind, top := "ClusterFolder", "the top level"
+			var removed []string
+			var moved []folderindex.PathEntry
+			if namespace == "" {
+				removed, moved, err = rmdirClusterFolder(ctx, cl, name, opts)
+			} else {
+				kind, top = "NamespacedFolder", fmt.Sprintf("the top of namespace [%s]", namespace)
+				removed, moved, err = rmdirNamespacedFolder(ctx, cl, namespace, name, opts)
+			}
+			if err != nil {
+				return err
+			}
+			for _, folder := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s [%s] removed\n", kind, folder)
+			}
+			for _, entry := range moved {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s [%s] moved to %s\n", entry.Kind, entry.Name, top)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.recursive, "recursive", false, "Also remove all descendant folders")
+	cmd.Flags().BoolVar(&opts.reparent, "reparent", false, "Move the folder's contents into its parent folder")
+	cmd.MarkFlagsMutuallyExclusive("recursive", "reparent")
+
+	return cmd
+}
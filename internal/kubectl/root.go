@@ -1,11 +1,13 @@
 package kubectl
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
@@ -16,23 +18,85 @@ import (
 
 var rootCmd *cobra.Command
 
+// configFlags are the standard kubectl flags such as --kubeconfig,
+// --context, --namespace, --as and --request-timeout.
+var configFlags = genericclioptions.NewConfigFlags(true)
+
 func init() {
 	utilruntime.Must(v1alpha1.AddToScheme(scheme.Scheme))
 	utilruntime.Must(virtv1.AddToScheme(scheme.Scheme))
 	utilruntime.Must(corev1.AddToScheme(scheme.Scheme))
 
 	rootCmd = &cobra.Command{
-		Use: "folder-view",
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			return nil
+		Use:   "kubectl-folder",
+		Short: "Manage KubeVirt folders",
+		Long: `Manage KubeVirt folders.
+
+Installed as kubectl-folder on the PATH, this is run as the kubectl plugin
+"kubectl folder" and accepts the standard kubectl flags.`,
+		Annotations: map[string]string{
+			cobra.CommandDisplayNameAnnotation: "kubectl folder",
 		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
 	}
+	configFlags.AddFlags(rootCmd.PersistentFlags())
+	utilruntime.Must(rootCmd.RegisterFlagCompletionFunc("namespace", completeNamespaceFlag))
+
 	rootCmd.AddCommand(newTreeCmd())
+	rootCmd.AddCommand(newMkdirCmd())
+	rootCmd.AddCommand(newRmdirCmd())
+	rootCmd.AddCommand(newWhoCanCmd())
+	rootCmd.AddCommand(newAccessCmd())
+	rootCmd.AddCommand(newPlanCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newImportCmd())
+	rootCmd.AddCommand(newBrowseCmd())
+	rootCmd.AddCommand(newLintCmd())
+	rootCmd.AddCommand(newDescribeCmd())
+	rootCmd.AddCommand(newLsCmd())
+	rootCmd.AddCommand(newPathCmd())
+	rootCmd.AddCommand(newCanICmd())
+	rootCmd.AddCommand(newMvCmd())
+	rootCmd.AddCommand(newGrantCmd())
+	rootCmd.AddCommand(newRevokeCmd())
+	rootCmd.AddCommand(newMigrateMembershipsCmd())
+}
+
+// namespaceFlag returns the namespace given with --namespace, or an empty
+// string when the flag was not set. The namespace of the kubeconfig context
+// is deliberately not used, as commands such as mkdir act on ClusterFolders
+// unless a namespace is given explicitly.
+func namespaceFlag(cmd *cobra.Command) string {
+	if !cmd.Flags().Changed("namespace") || configFlags.Namespace == nil {
+		return ""
+	}
+	return *configFlags.Namespace
 }
 
+// contextNamespace returns the namespace given with --namespace, falling back
+// to the namespace of the kubeconfig context and then to default, for
+// commands such as mv vm that always act within a namespace.
+func contextNamespace() (string, error) {
+	namespace, _, err := configFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return "", err
+	}
+	if namespace == "" {
+		namespace = corev1.NamespaceDefault
+	}
+	return namespace, nil
+}
+
+// errSilentExit makes the command exit with status 1 without printing an
+// error, for commands such as can-i whose output already holds the answer.
+var errSilentExit = errors.New("exit status 1")
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		if !errors.Is(err, errSilentExit) {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,97 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// stateReader is a client.Reader over a fixed set of objects, such as the
+// manifests of a file written by export or the folder state a plan proposes.
+// Get and List return copies of the objects. List filters by namespace and
+// labels, field selectors are not supported.
+type stateReader struct {
+	objs []client.Object
+}
+
+var _ client.Reader = &stateReader{}
+
+func newStateReader(objs []client.Object) *stateReader {
+	copied := []client.Object{}
+	for _, obj := range objs {
+		copied = append(copied, obj.DeepCopyObject().(client.Object))
+	}
+	return &stateReader{objs: copied}
+}
+
+// objectsOfKind returns the objects of a kind, sorted by namespace and name.
+func (r *stateReader) objectsOfKind(gvk schema.GroupVersionKind) []client.Object {
+	objs := []client.Object{}
+	for _, obj := range r.objs {
+		if objGVK, err := apiutil.GVKForObject(obj, scheme.Scheme); err == nil && objGVK == gvk {
+			objs = append(objs, obj)
+		}
+	}
+	sort.SliceStable(objs, func(i, j int) bool {
+		if objs[i].GetNamespace() != objs[j].GetNamespace() {
+			return objs[i].GetNamespace() < objs[j].GetNamespace()
+		}
+		return objs[i].GetName() < objs[j].GetName()
+	})
+	return objs
+}
+
+func (r *stateReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	gvk, err := apiutil.GVKForObject(obj, scheme.Scheme)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range r.objectsOfKind(gvk) {
+		if candidate.GetNamespace() != key.Namespace || candidate.GetName() != key.Name {
+			continue
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(candidate)
+		if err != nil {
+			return err
+		}
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(content, obj)
+	}
+	resource, _ := apimeta.UnsafeGuessKindToResource(gvk)
+	return apierrors.NewNotFound(resource.GroupResource(), key.Name)
+}
+
+func (r *stateReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector != nil && !listOpts.FieldSelector.Empty() {
+		return fmt.Errorf("field selector [%s] is not supported on a fixed set of objects", listOpts.FieldSelector)
+	}
+
+	gvk, err := apiutil.GVKForObject(list, scheme.Scheme)
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	items := []runtime.Object{}
+	for _, obj := range r.objectsOfKind(gvk) {
+		if listOpts.Namespace != "" && obj.GetNamespace() != listOpts.Namespace {
+			continue
+		}
+		if listOpts.LabelSelector != nil && !listOpts.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		items = append(items, obj.DeepCopyObject())
+	}
+	return apimeta.SetList(list, items)
+}
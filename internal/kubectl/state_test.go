@@ -0,0 +1,56 @@
+package kubectl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("state reader", func() {
+	ctx := context.Background()
+
+	var r *stateReader
+
+	BeforeEach(func() {
+		_, objs := folderFixtureObjects()
+		r = newStateReader(objs)
+	})
+
+	It("should get copies of the objects it holds", func() {
+		folder := &v1alpha1.NamespacedFolder{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "prod-web-apps", Name: "prod-web-app-a"}, folder)).To(Succeed())
+		Expect(folder.Spec.FolderPermissions).To(HaveLen(1))
+
+		folder.Spec.FolderPermissions = nil
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "prod-web-apps", Name: "prod-web-app-a"}, folder)).To(Succeed())
+		Expect(folder.Spec.FolderPermissions).To(HaveLen(1))
+
+		err := r.Get(ctx, client.ObjectKey{Name: "prod-web-app-a"}, folder)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should list the objects of a kind by namespace and labels", func() {
+		_, objs := folderFixtureObjects()
+		objs[5].SetLabels(map[string]string{"team": "web"})
+		r = newStateReader(objs)
+
+		folders := &v1alpha1.NamespacedFolderList{}
+		Expect(r.List(ctx, folders)).To(Succeed())
+		Expect(folders.Items).To(HaveLen(2))
+		Expect(folders.Items[0].Name).To(Equal("prod-web-app-a"))
+
+		Expect(r.List(ctx, folders, client.InNamespace("staging-web-apps"))).To(Succeed())
+		Expect(folders.Items).To(BeEmpty())
+
+		Expect(r.List(ctx, folders, client.MatchingLabels{"team": "web"})).To(Succeed())
+		Expect(folders.Items).To(HaveLen(1))
+		Expect(folders.Items[0].Name).To(Equal("prod-web-app-a"))
+
+		Expect(r.List(ctx, folders, client.MatchingFields{"spec.folder": "prod-web-app-a"})).NotTo(Succeed())
+	})
+})
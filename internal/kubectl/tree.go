@@ -3,15 +3,22 @@ package kubectl
 import (
 	"context"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"os/signal"
+	"slices"
+	"sort"
 	"strings"
+	"syscall"
 
 	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	virtv1 "kubevirt.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
 )
 
 type printTreeData struct {
@@ -22,16 +29,104 @@ type printTreeData struct {
 	vmNamespaceMap map[string][]string
 	namespaceMap   map[string]struct{}
 	vmMap          map[string]struct{}
+
+	// folders and namespaces at the top of the tree, sorted
+	rootClusterFolders []string
+	rootNamespaces     []string
+
+	// folder permissions, keyed by ClusterFolder name and by
+	// NamespacedFolder namespace/name. Only loaded when requested.
+	clusterFolderPermissions    map[string][]v1alpha1.FolderPermission
+	namespacedFolderPermissions map[string][]v1alpha1.FolderPermission
+	// folders that block the permissions of their ancestors, keyed like
+	// the folder permissions. Only loaded with the permissions.
+	blockedFolders map[string]bool
+
+	// folder metadata, keyed like the folder permissions
+	clusterFolderMetadata    map[string]v1alpha1.FolderMetadata
+	namespacedFolderMetadata map[string]v1alpha1.FolderMetadata
+}
+
+// treeOptions controls what newPrintTreeData loads.
+type treeOptions struct {
+	// permissions loads the folder permissions of every folder
+	permissions bool
+	// offline treats every namespace and VM referenced by the index as
+	// existing, for rendering an exported file that holds only folders
+	offline bool
+}
+
+// namespaceRootFolders returns the NamespacedFolders at the top of a
+// namespace, sorted.
+func (data *printTreeData) namespaceRootFolders(namespace string) []string {
+	namespaceParentKey := fmt.Sprintf("NAMESPACE:%s", namespace)
+	folders := []string{}
+	for childFolder, parentNS := range data.childParentMap {
+		if parentNS == namespaceParentKey {
+			folders = append(folders, childFolder)
+		}
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+// unfiledVMs returns the VMs of a namespace that are not in any
+// NamespacedFolder, sorted.
+func (data *printTreeData) unfiledVMs(namespace string) []string {
+	vms := []string{}
+	for _, vm := range data.vmNamespaceMap[namespace] {
+		if _, isInFolder := data.vmToFolderMap[fmt.Sprintf("%s/%s", namespace, vm)]; !isInFolder {
+			vms = append(vms, vm)
+		}
+	}
+	sort.Strings(vms)
+	return vms
+}
+
+// folderVMs returns the VMs of a NamespacedFolder that exist.
+func (data *printTreeData) folderVMs(namespacedFolder string) []string {
+	vms := []string{}
+	namespace := folderindex.NamespacedFolderNamespace(namespacedFolder)
+	for _, vm := range data.root.Spec.NamespacedFolderEntries[namespacedFolder].VirtualMachines {
+		if _, exists := data.vmMap[fmt.Sprintf("%s/%s", namespace, vm)]; exists {
+			vms = append(vms, vm)
+		}
+	}
+	return vms
+}
+
+// formatTreeMetadata returns the display name and tags of a folder as they
+// follow the folder name in the text tree, or nothing for folders without
+// them.
+func formatTreeMetadata(metadata v1alpha1.FolderMetadata) string {
+	s := ""
+	if metadata.DisplayName != "" {
+		s += " " + metadata.DisplayName
+	}
+	if len(metadata.Tags) != 0 {
+		s += " (" + strings.Join(formatTags(metadata.Tags), ", ") + ")"
+	}
+	return s
+}
+
+// formatTags returns the tags as key=value, sorted by key.
+func formatTags(tags map[string]string) []string {
+	formatted := []string{}
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		formatted = append(formatted, fmt.Sprintf("%s=%s", key, tags[key]))
+	}
+	return formatted
 }
 
-func printTree(data *printTreeData,
+func printTree(w io.Writer,
+	data *printTreeData,
 	parentClusterFolder string,
 	parentNamespace string,
 	parentNamespacedFolder string,
 	indention string) {
 
 	if parentClusterFolder != "" {
-		fmt.Printf("%s* ClusterFolder: [%s]\n", indention, parentClusterFolder)
+		fmt.Fprintf(w, "%s* ClusterFolder: [%s]%s\n", indention, parentClusterFolder, formatTreeMetadata(data.clusterFolderMetadata[parentClusterFolder]))
 		node, ok := data.root.Spec.ClusterFolderEntries[parentClusterFolder]
 		if !ok {
 			return
@@ -40,37 +135,24 @@ func printTree(data *printTreeData,
 		indention = fmt.Sprintf("%s  ", indention)
 
 		for _, ns := range node.Namespaces {
-			printTree(data, "", ns, "", indention)
+			printTree(w, data, "", ns, "", indention)
 		}
 		for _, child := range node.ChildFolders {
-			printTree(data, child, "", "", indention)
+			printTree(w, data, child, "", "", indention)
 		}
 	} else if parentNamespace != "" {
 		_, exists := data.namespaceMap[parentNamespace]
 		if !exists {
 			return
 		}
-		fmt.Printf("%s* Namespace: [%s]\n", indention, parentNamespace)
-		namespaceParentKey := fmt.Sprintf("NAMESPACE:%s", parentNamespace)
-		for childFolder, parentNS := range data.childParentMap {
-			if parentNS != namespaceParentKey {
-				continue
-			}
-			printTree(data, "", "", childFolder, indention+"  ")
-		}
-
-		vmList, exists := data.vmNamespaceMap[parentNamespace]
-		if !exists {
-			return
+		fmt.Fprintf(w, "%s* Namespace: [%s]\n", indention, parentNamespace)
+		for _, childFolder := range data.namespaceRootFolders(parentNamespace) {
+			printTree(w, data, "", "", childFolder, indention+"  ")
 		}
 
 		// Print all VMs in a namespace that are not nested into folders
-		for _, vm := range vmList {
-			_, isInFolder := data.vmToFolderMap[vm]
-			if !isInFolder {
-				fmt.Printf("%s* Namespace: [%s]\n", indention, parentNamespace)
-			}
-
+		for _, vm := range data.unfiledVMs(parentNamespace) {
+			fmt.Fprintf(w, "%s* VM: [%s]\n", indention+"  ", vm)
 		}
 
 	} else if parentNamespacedFolder != "" {
@@ -79,141 +161,294 @@ func printTree(data *printTreeData,
 			return
 		}
 
-		fmt.Printf("%s* NamespacedFolder: [%s]\n", indention, parentNamespacedFolder)
+		fmt.Fprintf(w, "%s* NamespacedFolder: [%s]%s\n", indention, parentNamespacedFolder, formatTreeMetadata(data.namespacedFolderMetadata[parentNamespacedFolder]))
 
-		for _, vm := range node.VirtualMachines {
-			namespace := strings.Split(parentNamespacedFolder, "/")[0]
-			_, exists := data.vmMap[fmt.Sprintf("%s/%s", namespace, vm)]
-			if exists {
-				fmt.Printf("%s* VM: [%s]\n", indention+"  ", vm)
-			}
+		for _, vm := range data.folderVMs(parentNamespacedFolder) {
+			fmt.Fprintf(w, "%s* VM: [%s]\n", indention+"  ", vm)
 		}
 		for _, child := range node.ChildFolders {
-			printTree(data, "", "", child, indention+"  ")
+			printTree(w, data, "", "", child, indention+"  ")
 		}
 	}
 }
 
-var treeCmd = &cobra.Command{
-	Use:   "tree TREE",
-	Short: "Display folder tree view",
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
+// newPrintTreeData builds the tree model from the root FolderIndex and the
+// namespaces and VMs that exist.
+func newPrintTreeData(ctx context.Context, cl client.Reader, opts treeOptions) (*printTreeData, error) {
+	childParentMap := map[string]string{}
+	rootClusterFolders := map[string]struct{}{}
+	rootNamespacedFolders := map[string]struct{}{}
+
+	vmToFolderMap := map[string]string{}
+	namespaceToFolderMap := map[string]string{}
+
+	// tells us if namespaces exist or not
+	namespaceMap := map[string]struct{}{}
 
-		childParentMap := map[string]string{}
-		rootClusterFolders := map[string]struct{}{}
-		rootNamespacedFolders := map[string]struct{}{}
+	// tells us if vms exist or not
+	vmMap := map[string]struct{}{}
 
-		vmToFolderMap := map[string]string{}
-		namespaceToFolderMap := map[string]string{}
+	// tells us if vms exist or not in a namespace
+	vmNamespaceMap := map[string][]string{}
 
-		// tells us if namespaces exist or not
-		namespaceMap := map[string]struct{}{}
+	var vmList virtv1.VirtualMachineList
+	if err := cl.List(ctx, &vmList); err != nil {
+		return nil, fmt.Errorf("failed to list vms: %v", err)
+	}
+	for _, vm := range vmList.Items {
+		vmMap[fmt.Sprintf("%s/%s", vm.Namespace, vm.Name)] = struct{}{}
+		vmNamespaceMap[vm.Namespace] = append(vmNamespaceMap[vm.Namespace], vm.Name)
+	}
 
-		// tells us if vms exist or not
-		vmMap := map[string]struct{}{}
+	var namespaceList corev1.NamespaceList
+	if err := cl.List(ctx, &namespaceList); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+	for _, ns := range namespaceList.Items {
+		namespaceMap[ns.Name] = struct{}{}
+	}
 
-		// tells us if vms exist or not in a namespace
-		vmNamespaceMap := map[string][]string{}
+	root, err := getFolderView(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
 
-		cl, err := client.New(config.GetConfigOrDie(), client.Options{})
-		if err != nil {
-			fmt.Printf("failed to create client: %v\n", err)
-			os.Exit(1)
+	if opts.offline {
+		for _, clusterEntry := range root.Spec.ClusterFolderEntries {
+			for _, ns := range clusterEntry.Namespaces {
+				namespaceMap[ns] = struct{}{}
+			}
 		}
+		for key, namespacedEntry := range root.Spec.NamespacedFolderEntries {
+			namespace := folderindex.NamespacedFolderNamespace(key)
+			namespaceMap[namespace] = struct{}{}
+			for _, vm := range namespacedEntry.VirtualMachines {
+				vmKey := fmt.Sprintf("%s/%s", namespace, vm)
+				if _, exists := vmMap[vmKey]; !exists {
+					vmMap[vmKey] = struct{}{}
+					vmNamespaceMap[namespace] = append(vmNamespaceMap[namespace], vm)
+				}
+			}
+		}
+	}
 
-		var vmList virtv1.VirtualMachineList
-		if err := cl.List(ctx, &vmList); err != nil {
-			fmt.Printf("failed to list vms: %v\n", err)
-			os.Exit(1)
+	// Discover the root folders
+	// start by discovering all child parent relationships
+	for parent, clusterEntry := range root.Spec.ClusterFolderEntries {
+		for _, child := range clusterEntry.ChildFolders {
+			childParentMap[child] = parent
+			delete(rootClusterFolders, child)
 		}
-		for _, vm := range vmList.Items {
-			vmMap[fmt.Sprintf("%s/%s", vm.Namespace, vm.Name)] = struct{}{}
 
-			vmList, exists := vmNamespaceMap[vm.Namespace]
-			if exists {
-				vmNamespaceMap[vm.Namespace] = []string{vm.Name}
-			} else {
-				vmNamespaceMap[vm.Namespace] = append(vmList, vm.Name)
-			}
+		for _, ns := range clusterEntry.Namespaces {
+			namespaceToFolderMap[ns] = parent
+		}
+		_, isChild := childParentMap[parent]
+		if !isChild {
+			rootClusterFolders[parent] = struct{}{}
 		}
+	}
+
+	for parent, namespacedEntry := range root.Spec.NamespacedFolderEntries {
+		namespace := folderindex.NamespacedFolderNamespace(parent)
+		namespaceParentKey := fmt.Sprintf("NAMESPACE:%s", namespace)
 
-		var namespaceList corev1.NamespaceList
-		if err := cl.List(ctx, &namespaceList); err != nil {
-			fmt.Printf("failed to list namespaces: %v\n", err)
-			os.Exit(1)
+		for _, child := range namespacedEntry.ChildFolders {
+			childParentMap[child] = parent
+			delete(rootNamespacedFolders, child)
 		}
-		for _, ns := range namespaceList.Items {
-			namespaceMap[ns.Name] = struct{}{}
+		for _, vm := range namespacedEntry.VirtualMachines {
+			vmToFolderMap[fmt.Sprintf("%s/%s", namespace, vm)] = parent
 		}
 
-		root := &v1alpha1.FolderIndex{}
-		err = cl.Get(ctx, client.ObjectKey{Name: "root"}, root)
-		if err != nil {
-			fmt.Printf("failed to find root folder index: %v\n", err)
-			os.Exit(1)
+		_, isChild := childParentMap[parent]
+		if !isChild {
+			rootNamespacedFolders[parent] = struct{}{}
+			childParentMap[parent] = namespaceParentKey
 		}
+	}
 
-		// Discover the root folders
-		// start by discovering all child parent relationships
-		for parent, clusterEntry := range root.Spec.ClusterFolderEntries {
-			for _, child := range clusterEntry.ChildFolders {
-				childParentMap[child] = parent
-				delete(rootClusterFolders, child)
-			}
+	data := &printTreeData{
+		root:           root,
+		childParentMap: childParentMap,
+		vmToFolderMap:  vmToFolderMap,
+		vmNamespaceMap: vmNamespaceMap,
+		vmMap:          vmMap,
+		namespaceMap:   namespaceMap,
+	}
 
-			for _, ns := range clusterEntry.Namespaces {
-				namespaceToFolderMap[ns] = parent
+	for parent := range rootClusterFolders {
+		data.rootClusterFolders = append(data.rootClusterFolders, parent)
+	}
+	sort.Strings(data.rootClusterFolders)
+
+	// namespaces in the cluster that are not nested into folders
+	for ns := range namespaceMap {
+		if _, isInFolder := namespaceToFolderMap[ns]; !isInFolder {
+			data.rootNamespaces = append(data.rootNamespaces, ns)
+		}
+	}
+	sort.Strings(data.rootNamespaces)
+
+	data.clusterFolderMetadata = map[string]v1alpha1.FolderMetadata{}
+	data.namespacedFolderMetadata = map[string]v1alpha1.FolderMetadata{}
+	if opts.permissions {
+		data.clusterFolderPermissions = map[string][]v1alpha1.FolderPermission{}
+		data.namespacedFolderPermissions = map[string][]v1alpha1.FolderPermission{}
+		data.blockedFolders = map[string]bool{}
+	}
+
+	clusterFolders := &v1alpha1.ClusterFolderList{}
+	if err := cl.List(ctx, clusterFolders); err != nil {
+		return nil, fmt.Errorf("failed to list cluster folders: %v", err)
+	}
+	for _, folder := range clusterFolders.Items {
+		data.clusterFolderMetadata[folder.Name] = folder.Spec.FolderMetadata
+		if opts.permissions {
+			data.clusterFolderPermissions[folder.Name] = folder.Spec.FolderPermissions
+			if folder.Spec.Inheritance != nil && folder.Spec.Inheritance.Block {
+				data.blockedFolders[folder.Name] = true
 			}
-			_, isChild := childParentMap[parent]
-			if !isChild {
-				rootClusterFolders[parent] = struct{}{}
+		}
+	}
+
+	namespacedFolders := &v1alpha1.NamespacedFolderList{}
+	if err := cl.List(ctx, namespacedFolders); err != nil {
+		return nil, fmt.Errorf("failed to list namespaced folders: %v", err)
+	}
+	for _, folder := range namespacedFolders.Items {
+		key := folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)
+		data.namespacedFolderMetadata[key] = folder.Spec.FolderMetadata
+		if opts.permissions {
+			data.namespacedFolderPermissions[key] = folder.Spec.FolderPermissions
+			if folder.Spec.Inheritance != nil && folder.Spec.Inheritance.Block {
+				data.blockedFolders[key] = true
 			}
 		}
+	}
 
-		for parent, namespacedEntry := range root.Spec.NamespacedFolderEntries {
-			namespace := strings.Split(parent, "/")[0]
-			namespaceParentKey := fmt.Sprintf("NAMESPACE:%s", namespace)
+	return data, nil
+}
 
-			for _, child := range namespacedEntry.ChildFolders {
-				childParentMap[child] = parent
-				delete(rootNamespacedFolders, child)
+// printTreeText prints the text tree, all root folders followed by the
+// namespaces that are not nested into folders.
+func printTreeText(w io.Writer, data *printTreeData) {
+	for _, parent := range data.rootClusterFolders {
+		printTree(w, data, parent, "", "", "")
+	}
+	for _, ns := range data.rootNamespaces {
+		printTree(w, data, "", ns, "", "")
+	}
+}
+
+// treeOutputFormats are the values accepted by tree -o.
+var treeOutputFormats = []string{"text", "json", "dot", "mermaid"}
+
+func newTreeCmd() *cobra.Command {
+	var output string
+	var fromFile string
+	var permissions bool
+	var watch bool
+
+	cmd := &cobra.Command{
+		Use:   "tree",
+		Short: "Display folder tree view",
+		Long: `Display folder tree view.
+
+With -o dot or -o mermaid the hierarchy of ClusterFolders, namespaces,
+NamespacedFolders and VMs is rendered as a Graphviz or Mermaid graph instead.
+--permissions adds an edge from every subject to each folder that grants it
+access, labeled with the granted roles.
+
+-o json writes a line for every folder, namespace and VM with the node
+directly above it.
+
+--watch keeps the tree up to date using informers on the FolderIndex,
+folders, Namespaces and VirtualMachines. The text tree is redrawn whenever it
+changes. With -o json a line is streamed for every entry that is ADDED,
+DELETED or MODIFIED, that is moved to another parent.
+
+--from-file renders a file written by export instead of the cluster. No
+cluster is contacted, and every namespace and VM the index references is
+shown.`,
+		Example: `  kubectl folder tree
+  kubectl folder tree --watch
+  kubectl folder tree --watch -o json
+  kubectl folder tree -o dot --permissions | dot -Tsvg > folders.svg
+  kubectl folder tree -o mermaid --from-file folders.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			if !slices.Contains(treeOutputFormats, output) {
+				return fmt.Errorf("unsupported output format [%s], expected one of %s", output, strings.Join(treeOutputFormats, ", "))
 			}
-			for _, vm := range namespacedEntry.VirtualMachines {
-				vmToFolderMap[vm] = parent
+			if permissions && output != "dot" && output != "mermaid" {
+				return fmt.Errorf("--permissions requires -o dot or -o mermaid")
 			}
 
-			_, isChild := childParentMap[parent]
-			if !isChild {
-				rootNamespacedFolders[parent] = struct{}{}
-				childParentMap[parent] = namespaceParentKey
+			if watch {
+				if output != "text" && output != "json" {
+					return fmt.Errorf("--watch requires -o text or -o json")
+				}
+				if fromFile != "" {
+					return fmt.Errorf("--watch cannot be combined with --from-file")
+				}
+
+				ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer cancel()
+
+				cfg, err := configFlags.ToRESTConfig()
+				if err != nil {
+					return fmt.Errorf("failed to create client: %v", err)
+				}
+
+				w := &treeWatcher{out: os.Stdout, output: output, redraw: output == "text"}
+				return watchTree(ctx, cfg, w)
 			}
-		}
 
-		printData := &printTreeData{
-			root:           root,
-			childParentMap: childParentMap,
-			vmToFolderMap:  vmToFolderMap,
-			vmNamespaceMap: vmNamespaceMap,
-			vmMap:          vmMap,
-			namespaceMap:   namespaceMap,
-		}
+			var cl client.Reader
+			if fromFile != "" {
+				objs, err := readManifests(fromFile)
+				if err != nil {
+					return err
+				}
+				cl = newStateReader(objs)
+			} else {
+				var err error
+				cl, err = newClient()
+				if err != nil {
+					return fmt.Errorf("failed to create client: %v", err)
+				}
+			}
 
-		// Print all root folders
-		for parent := range rootClusterFolders {
-			printTree(printData, parent, "", "", "")
-		}
+			data, err := newPrintTreeData(ctx, cl, treeOptions{permissions: permissions, offline: fromFile != ""})
+			if err != nil {
+				return err
+			}
 
-		// Print all namespaces in the cluster that are not nested into folders
-		for ns := range namespaceMap {
-			_, isInFolder := namespaceToFolderMap[ns]
-			if !isInFolder {
-				printTree(printData, "", ns, "", "")
+			switch output {
+			case "dot":
+				writeDot(os.Stdout, newTreeGraph(data))
+			case "mermaid":
+				writeMermaid(os.Stdout, newTreeGraph(data))
+			case "json":
+				w := &treeWatcher{out: os.Stdout, output: output}
+				if err := w.update(data); err != nil {
+					return err
+				}
+			default:
+				printTreeText(os.Stdout, data)
 			}
-		}
-	},
-}
+			return nil
+		},
+	}
 
-func newTreeCmd() *cobra.Command {
-	return treeCmd
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format, text, json, dot or mermaid")
+	_ = cmd.RegisterFlagCompletionFunc("output", completeFixed(treeOutputFormats...))
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "File written by export to render instead of the cluster")
+	cmd.Flags().BoolVar(&permissions, "permissions", false, "Include edges from subjects to the folders granting them access")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep watching and update the output whenever the tree changes")
+
+	return cmd
 }
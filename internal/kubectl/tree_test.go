@@ -0,0 +1,96 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("tree", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+
+	BeforeEach(func() {
+		cl, _ = newFolderFixture()
+		for _, name := range []string{"web-app-a", "scratch"} {
+			Expect(cl.Create(ctx, &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: name},
+			})).To(Succeed())
+		}
+	})
+
+	printAll := func(data *printTreeData) string {
+		out := &bytes.Buffer{}
+		printTreeText(out, data)
+		return out.String()
+	}
+
+	It("should print existing namespaces and VMs, including VMs outside of folders", func() {
+		data, err := newPrintTreeData(ctx, cl, treeOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(printAll(data)).To(Equal(`* ClusterFolder: [operations]
+  * ClusterFolder: [production]
+    * Namespace: [prod-web-apps]
+      * NamespacedFolder: [prod-web-apps/prod-web-app-a]
+        * VM: [web-app-a]
+      * NamespacedFolder: [prod-web-apps/prod-web-app-b]
+      * VM: [scratch]
+`))
+	})
+
+	It("should print the display name and tags of folders", func() {
+		folder := &v1alpha1.NamespacedFolder{}
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: "prod-web-apps", Name: "prod-web-app-a"}, folder)).To(Succeed())
+		folder.Spec.DisplayName = "Web App A"
+		folder.Spec.Tags = map[string]string{"team": "web", "env": "production"}
+		Expect(cl.Update(ctx, folder)).To(Succeed())
+
+		data, err := newPrintTreeData(ctx, cl, treeOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(printAll(data)).To(ContainSubstring("* NamespacedFolder: [prod-web-apps/prod-web-app-a] Web App A (env=production, team=web)\n"))
+		Expect(printAll(data)).To(ContainSubstring("* NamespacedFolder: [prod-web-apps/prod-web-app-b]\n"))
+	})
+
+	It("should render every referenced namespace and VM offline", func() {
+		objs, err := exportFolders(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := newPrintTreeData(ctx, newStateReader(objs), treeOptions{offline: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(printAll(data)).To(ContainSubstring("* VM: [web-app-b]"))
+		Expect(printAll(data)).NotTo(ContainSubstring("scratch"))
+	})
+
+	It("should render a DOT graph with permission edges", func() {
+		data, err := newPrintTreeData(ctx, cl, treeOptions{permissions: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		out := &bytes.Buffer{}
+		writeDot(out, newTreeGraph(data))
+		Expect(out.String()).To(HavePrefix("digraph folders {\n"))
+		Expect(out.String()).To(ContainSubstring(`n0 [label="ClusterFolder: operations", shape=folder];`))
+		Expect(out.String()).To(ContainSubstring(`[label="Subject: Group/operation-team", shape=hexagon];`))
+		Expect(out.String()).To(MatchRegexp(`n\d+ -> n0 \[label="ClusterRole/admin", style=dashed\];`))
+		Expect(out.String()).To(MatchRegexp(`n0 -> n\d+;`))
+	})
+
+	It("should render a Mermaid flowchart", func() {
+		data, err := newPrintTreeData(ctx, cl, treeOptions{permissions: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		out := &bytes.Buffer{}
+		writeMermaid(out, newTreeGraph(data))
+		Expect(out.String()).To(HavePrefix("flowchart LR\n"))
+		Expect(out.String()).To(ContainSubstring(`n0["ClusterFolder: operations"]`))
+		Expect(out.String()).To(ContainSubstring(`(["VM: prod-web-apps/scratch"])`))
+		Expect(out.String()).To(MatchRegexp(`n\d+ -.->\|"ClusterRole/view"\| n\d+`))
+	})
+})
@@ -0,0 +1,203 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// treeEntry is a single folder, namespace or VM in the tree and the node
+// directly above it. Parent is empty for the top level.
+type treeEntry struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Parent string `json:"parent,omitempty"`
+}
+
+// treeEvent is a change line written by tree -o json. MODIFIED means the
+// entry moved, OldParent is where it was before.
+type treeEvent struct {
+	Type string `json:"type"`
+	treeEntry
+	OldParent string `json:"oldParent,omitempty"`
+}
+
+// treeEntries flattens the graph into its entries, keyed like graph nodes.
+// Subjects are not part of the hierarchy and are left out.
+func treeEntries(g *treeGraph) map[string]treeEntry {
+	byID := map[string]graphNode{}
+	for _, node := range g.nodes {
+		byID[node.id] = node
+	}
+
+	entries := map[string]treeEntry{}
+	for _, node := range g.nodes {
+		if node.kind == graphSubject {
+			continue
+		}
+		entries[node.kind+"/"+node.label] = treeEntry{Kind: node.kind, Name: node.label}
+	}
+	for _, edge := range g.edges {
+		if edge.permission {
+			continue
+		}
+		parent, child := byID[edge.from], byID[edge.to]
+		entry := entries[child.kind+"/"+child.label]
+		entry.Parent = parent.kind + "/" + parent.label
+		entries[child.kind+"/"+child.label] = entry
+	}
+	return entries
+}
+
+// diffTreeEntries returns the events turning old into current, sorted by
+// entry.
+func diffTreeEntries(old map[string]treeEntry, current map[string]treeEntry) []treeEvent {
+	keys := []string{}
+	for key := range old {
+		keys = append(keys, key)
+	}
+	for key := range current {
+		if _, exists := old[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	events := []treeEvent{}
+	for _, key := range keys {
+		before, existed := old[key]
+		after, exists := current[key]
+		switch {
+		case !existed:
+			events = append(events, treeEvent{Type: "ADDED", treeEntry: after})
+		case !exists:
+			events = append(events, treeEvent{Type: "DELETED", treeEntry: before})
+		case before.Parent != after.Parent:
+			events = append(events, treeEvent{Type: "MODIFIED", treeEntry: after, OldParent: before.Parent})
+		}
+	}
+	return events
+}
+
+// treeWatcher writes the tree each time it changes, either redrawing the
+// text tree or, with -o json, streaming a line per changed entry.
+type treeWatcher struct {
+	out    io.Writer
+	output string
+	// redraw clears the terminal before each text tree
+	redraw bool
+
+	entries map[string]treeEntry
+	text    string
+}
+
+func (w *treeWatcher) update(data *printTreeData) error {
+	if w.output == "json" {
+		entries := treeEntries(newTreeGraph(data))
+		encoder := json.NewEncoder(w.out)
+		for _, event := range diffTreeEntries(w.entries, entries) {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+		w.entries = entries
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	printTreeText(buf, data)
+	if buf.String() == w.text {
+		return nil
+	}
+	w.text = buf.String()
+
+	if w.redraw {
+		fmt.Fprint(w.out, "\x1b[H\x1b[2J")
+	}
+	_, err := io.WriteString(w.out, w.text)
+	return err
+}
+
+// watchTree keeps informers on everything the tree is built from and
+// updates the watcher whenever any of it changes, until ctx is done.
+func watchTree(ctx context.Context, cfg *rest.Config, w *treeWatcher) error {
+	informers, err := cache.New(cfg, cache.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return fmt.Errorf("failed to create informers: %v", err)
+	}
+
+	// changes arriving while the tree is being redrawn are coalesced into
+	// a single redraw
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	handler := toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { notify() },
+		UpdateFunc: func(oldObj, newObj interface{}) { notify() },
+		DeleteFunc: func(obj interface{}) { notify() },
+	}
+
+	for _, obj := range []client.Object{
+		&v1alpha1.FolderIndex{},
+		&v1alpha1.ClusterFolder{},
+		&v1alpha1.NamespacedFolder{},
+		&v1alpha1.FolderMembership{},
+		&corev1.Namespace{},
+		&virtv1.VirtualMachine{},
+	} {
+		informer, err := informers.GetInformer(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("failed to watch %T: %v", obj, err)
+		}
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return fmt.Errorf("failed to watch %T: %v", obj, err)
+		}
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- informers.Start(ctx)
+	}()
+	if !informers.WaitForCacheSync(ctx) {
+		return fmt.Errorf("failed to sync informers")
+	}
+	notify()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			return err
+		case <-changed:
+			data, err := newPrintTreeData(ctx, informers, treeOptions{})
+			if err != nil {
+				// the index may be recreated, keep watching
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				w.text = ""
+				continue
+			}
+			if err := w.update(data); err != nil {
+				return err
+			}
+		}
+	}
+}
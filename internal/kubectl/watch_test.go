@@ -0,0 +1,80 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("tree watch", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+	var out *bytes.Buffer
+
+	BeforeEach(func() {
+		cl, _ = newFolderFixture()
+		Expect(cl.Create(ctx, &virtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-a"},
+		})).To(Succeed())
+		out = &bytes.Buffer{}
+	})
+
+	update := func(w *treeWatcher) {
+		data, err := newPrintTreeData(ctx, cl, treeOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.update(data)).To(Succeed())
+	}
+
+	moveVM := func(folderKey string) {
+		Expect(updateRootIndex(ctx, cl, func(root *v1alpha1.FolderIndex) error {
+			return moveVMInIndex(root, "prod-web-apps", "web-app-a", folderKey)
+		})).To(Succeed())
+	}
+
+	It("should stream added, moved and deleted entries as json lines", func() {
+		w := &treeWatcher{out: out, output: "json"}
+		update(w)
+		Expect(strings.Split(strings.TrimSpace(out.String()), "\n")).To(ContainElements(
+			`{"type":"ADDED","kind":"ClusterFolder","name":"operations"}`,
+			`{"type":"ADDED","kind":"VM","name":"prod-web-apps/web-app-a","parent":"NamespacedFolder/prod-web-apps/prod-web-app-a"}`,
+		))
+
+		out.Reset()
+		update(w)
+		Expect(out.String()).To(BeEmpty())
+
+		moveVM("prod-web-apps/prod-web-app-b")
+		update(w)
+		Expect(out.String()).To(Equal(`{"type":"MODIFIED","kind":"VM","name":"prod-web-apps/web-app-a","parent":"NamespacedFolder/prod-web-apps/prod-web-app-b","oldParent":"NamespacedFolder/prod-web-apps/prod-web-app-a"}` + "\n"))
+
+		out.Reset()
+		Expect(cl.Delete(ctx, &virtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-a"},
+		})).To(Succeed())
+		update(w)
+		Expect(out.String()).To(HavePrefix(`{"type":"DELETED","kind":"VM","name":"prod-web-apps/web-app-a"`))
+	})
+
+	It("should redraw the text tree only when it changes", func() {
+		w := &treeWatcher{out: out, output: "text", redraw: true}
+		update(w)
+		Expect(out.String()).To(HavePrefix("\x1b[H\x1b[2J* ClusterFolder: [operations]\n"))
+
+		out.Reset()
+		update(w)
+		Expect(out.String()).To(BeEmpty())
+
+		moveVM("")
+		update(w)
+		Expect(out.String()).To(HaveSuffix("      * NamespacedFolder: [prod-web-apps/prod-web-app-b]\n      * VM: [web-app-a]\n"))
+	})
+})
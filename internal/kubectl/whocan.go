@@ -0,0 +1,153 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// vmResourceNames are the accepted spellings of the resource argument.
+var vmResourceNames = []string{"vm", "vms", "virtualmachine", "virtualmachines"}
+
+func parseVMArgs(resource string, vmNamespaceName string) (string, string, error) {
+	if !slices.Contains(vmResourceNames, resource) {
+		return "", "", fmt.Errorf("unsupported resource [%s], only vm is supported", resource)
+	}
+
+	// VirtualMachines are addressed the same way NamespacedFolders are keyed
+	// in the index, namespace/name
+	namespace, name, err := folderindex.SplitNamespacedFolderKey(vmNamespaceName)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid vm [%s], expected the format namespace/name", vmNamespaceName)
+	}
+	return namespace, name, nil
+}
+
+// whoCan returns the folder grants that allow the access to the VirtualMachine.
+func whoCan(ctx context.Context, cl client.Reader, root *v1alpha1.FolderIndex, access vmAccess, namespace string, vm string) ([]folderGrant, error) {
+	allowed := []folderGrant{}
+
+	grants, err := resolveVMGrants(ctx, cl, root, namespace, vm)
+	if err != nil {
+		return allowed, err
+	}
+
+	for _, grant := range grants {
+		if rulesAllow(grant.rules, access, vm) {
+			allowed = append(allowed, grant)
+		}
+	}
+
+	return allowed, nil
+}
+
+// whoCanVerbs returns the verbs and KubeVirt actions who-can completes.
+func whoCanVerbs() []string {
+	verbs := []string{}
+	for _, verb := range accessVerbs {
+		if !slices.Contains(verbs, verb) {
+			verbs = append(verbs, verb)
+		}
+	}
+	for action := range vmActions {
+		if !slices.Contains(verbs, action) {
+			verbs = append(verbs, action)
+		}
+	}
+	slices.Sort(verbs)
+	return verbs
+}
+
+func completeWhoCanArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return whoCanVerbs(), cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		return vmResourceNames[:1], cobra.ShellCompDirectiveNoFileComp
+	case 2:
+		return completeWith(vmCompletions)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func printGrants(out io.Writer, grants []folderGrant) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUBJECT\tFOLDER\tROLEREF\tROLE\tROLEBINDING")
+	for _, grant := range grants {
+		role := grant.role
+		if role == "" {
+			role = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			formatSubject(grant.subject),
+			grant.folder,
+			formatRoleRef(grant.roleRef),
+			role,
+			grant.roleBinding)
+	}
+	w.Flush()
+}
+
+func newWhoCanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "who-can VERB vm NAMESPACE/NAME",
+		Short: "List the subjects folder permissions allow to act on a VirtualMachine",
+		Long: `List the subjects folder permissions allow to act on a VirtualMachine.
+
+The VirtualMachine's NamespacedFolder and its ancestors, and the ClusterFolder
+holding its namespace and its ancestors, are expanded into the Roles and
+RoleBindings the folder controllers generate. Every subject whose generated
+access allows VERB is printed along with the folder that granted it.
+
+VERB is either a Kubernetes verb such as get or delete, or one of the KubeVirt
+actions start, stop, restart, migrate, addvolume, removevolume, memorydump,
+portforward, console, vnc, guestosinfo, pause, unpause or softreboot.
+
+Only access granted through folders is reported. RoleBindings created outside
+of folders are not considered.`,
+		Example:           `  kubectl folder who-can start vm prod-web-apps/web-app-a`,
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeWhoCanArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			access := vmAccessFor(args[0])
+
+			namespace, vm, err := parseVMArgs(args[1], args[2])
+			if err != nil {
+				return err
+			}
+
+			cl, err := newClient()
+			if err != nil {
+				return fmt.Errorf("failed to create client: %v", err)
+			}
+
+			root, err := getFolderView(ctx, cl)
+			if err != nil {
+				return err
+			}
+
+			grants, err := whoCan(ctx, cl, root, access, namespace, vm)
+			if err != nil {
+				return fmt.Errorf("failed to resolve folder permissions: %v", err)
+			}
+
+			if len(grants) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No folder permissions allow [%s] on vm [%s/%s]\n", access, namespace, vm)
+				return nil
+			}
+			printGrants(cmd.OutOrStdout(), grants)
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,186 @@
+package kubectl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// folderFixtureObjects returns the operations/production hierarchy from the
+// README, with permissions on one ClusterFolder and two NamespacedFolders.
+func folderFixtureObjects() (*v1alpha1.FolderIndex, []client.Object) {
+	root := &v1alpha1.FolderIndex{
+		ObjectMeta: metav1.ObjectMeta{Name: "root"},
+		Spec: v1alpha1.FolderIndexSpec{
+			ClusterFolderEntries: map[string]v1alpha1.ClusterFolderEntry{
+				"operations": {ChildFolders: []string{"production"}},
+				"production": {Namespaces: []string{"prod-web-apps"}},
+			},
+			NamespacedFolderEntries: map[string]v1alpha1.NamespacedFolderEntry{
+				"prod-web-apps/prod-web-app-a": {VirtualMachines: []string{"web-app-a"}},
+				"prod-web-apps/prod-web-app-b": {VirtualMachines: []string{"web-app-b"}},
+			},
+		},
+	}
+
+	objs := []client.Object{
+		root,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod-web-apps"}},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+			Rules: []rbacv1.PolicyRule{{
+				Verbs:     []string{"*"},
+				APIGroups: []string{"kubevirt.io", "subresources.kubevirt.io"},
+				Resources: []string{"*"},
+			}},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "view"},
+			Rules: []rbacv1.PolicyRule{{
+				Verbs:     []string{"get", "list", "watch"},
+				APIGroups: []string{"kubevirt.io"},
+				Resources: []string{"virtualmachines"},
+			}},
+		},
+		&v1alpha1.ClusterFolder{
+			ObjectMeta: metav1.ObjectMeta{Name: "operations", UID: "operations-uid"},
+			Spec: v1alpha1.ClusterFolderSpec{
+				FolderPermissions: []v1alpha1.FolderPermission{{
+					Subject:  rbacv1.Subject{Kind: "Group", Name: "operation-team"},
+					RoleRefs: []rbacv1.RoleRef{{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "admin"}},
+				}},
+			},
+		},
+		&v1alpha1.NamespacedFolder{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "prod-web-app-a", UID: "app-a-uid"},
+			Spec: v1alpha1.NamespacedFolderSpec{
+				FolderPermissions: []v1alpha1.FolderPermission{{
+					Subject:  rbacv1.Subject{Kind: "Group", Name: "dev-team-a"},
+					RoleRefs: []rbacv1.RoleRef{{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "view"}},
+				}},
+			},
+		},
+		&v1alpha1.NamespacedFolder{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "prod-web-app-b", UID: "app-b-uid"},
+			Spec: v1alpha1.NamespacedFolderSpec{
+				FolderPermissions: []v1alpha1.FolderPermission{{
+					Subject:  rbacv1.Subject{Kind: "Group", Name: "dev-team-b"},
+					RoleRefs: []rbacv1.RoleRef{{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "admin"}},
+				}},
+			},
+		},
+	}
+	return root, objs
+}
+
+// newStateClient returns a fake client holding copies of objs.
+func newStateClient(objs []client.Object) client.Client {
+	copied := []client.Object{}
+	for _, obj := range objs {
+		obj = obj.DeepCopyObject().(client.Object)
+		obj.SetResourceVersion("")
+		copied = append(copied, obj)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(copied...).Build()
+}
+
+func newFolderFixture() (client.Client, *v1alpha1.FolderIndex) {
+	root, objs := folderFixtureObjects()
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+	return cl, root
+}
+
+var _ = Describe("who-can", func() {
+	ctx := context.Background()
+
+	var cl client.Client
+	var root *v1alpha1.FolderIndex
+
+	BeforeEach(func() {
+		cl, root = newFolderFixture()
+	})
+
+	subjects := func(grants []folderGrant) []string {
+		names := []string{}
+		for _, grant := range grants {
+			names = append(names, grant.subject.Name)
+		}
+		return names
+	}
+
+	It("should include grants inherited from ancestor ClusterFolders", func() {
+		grants, err := whoCan(ctx, cl, root, vmAccessFor("start"), "prod-web-apps", "web-app-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(grants)).To(ConsistOf("operation-team"))
+		Expect(grants[0].folder).To(Equal("ClusterFolder/operations"))
+		Expect(grants[0].role).To(BeEmpty())
+		Expect(grants[0].roleBinding).NotTo(BeEmpty())
+	})
+
+	It("should include NamespacedFolder grants for the VMs in the folder only", func() {
+		grants, err := whoCan(ctx, cl, root, vmAccessFor("get"), "prod-web-apps", "web-app-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(grants)).To(ConsistOf("operation-team", "dev-team-a"))
+
+		grants, err = whoCan(ctx, cl, root, vmAccessFor("get"), "prod-web-apps", "web-app-b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(grants)).To(ConsistOf("operation-team", "dev-team-b"))
+	})
+
+	It("should report the generated Role for NamespacedFolder grants", func() {
+		grants, err := whoCan(ctx, cl, root, vmAccessFor("start"), "prod-web-apps", "web-app-b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(grants)).To(ConsistOf("operation-team", "dev-team-b"))
+		for _, grant := range grants {
+			if grant.subject.Name == "dev-team-b" {
+				Expect(grant.folder).To(Equal("NamespacedFolder/prod-web-apps/prod-web-app-b"))
+				Expect(grant.role).NotTo(BeEmpty())
+			}
+		}
+	})
+
+	It("should leave out members the index flags as deleted", func() {
+		root, objs := folderFixtureObjects()
+		root.Status.DeletedMembers = []v1alpha1.DeletedMember{
+			{Kind: "VirtualMachine", Namespace: "prod-web-apps", Name: "web-app-a"},
+		}
+		cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+
+		view, err := getFolderView(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		grants, err := whoCan(ctx, cl, view, vmAccessFor("get"), "prod-web-apps", "web-app-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(grants)).To(ConsistOf("operation-team"))
+
+		root.Status.DeletedMembers = []v1alpha1.DeletedMember{{Kind: "Namespace", Name: "prod-web-apps"}}
+		cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+
+		view, err = getFolderView(ctx, cl)
+		Expect(err).NotTo(HaveOccurred())
+		grants, err = whoCan(ctx, cl, view, vmAccessFor("get"), "prod-web-apps", "web-app-b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects(grants)).To(ConsistOf("dev-team-b"))
+	})
+
+	It("should match rules like the RBAC authorizer", func() {
+		rule := rbacv1.PolicyRule{
+			Verbs:     []string{"update"},
+			APIGroups: []string{"subresources.kubevirt.io"},
+			Resources: []string{"*/start"},
+		}
+		Expect(ruleAllows(rule, vmAccessFor("start"), "web-app-a")).To(BeTrue())
+		Expect(ruleAllows(rule, vmAccessFor("stop"), "web-app-a")).To(BeFalse())
+
+		rule.ResourceNames = []string{"web-app-b"}
+		Expect(ruleAllows(rule, vmAccessFor("start"), "web-app-a")).To(BeFalse())
+	})
+})
@@ -0,0 +1,464 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	virtv1 "kubevirt.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var virtualmachinelog = logf.Log.WithName("virtualmachine-resource")
+
+// SetupVirtualMachineWebhookWithManager registers the webhook for VirtualMachine in the manager.
+func SetupVirtualMachineWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&virtv1.VirtualMachine{}).
+		WithValidator(&VirtualMachineCustomValidator{Client: mgr.GetClient()}).
+		WithDefaulter(&VirtualMachineCustomDefaulter{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// getFolderIndex returns the root FolderIndex, without the members it flags
+// as deleted, reporting false when there is none.
+func getFolderIndex(ctx context.Context, c client.Reader) (*v1alpha1.FolderIndex, bool, error) {
+	root := &v1alpha1.FolderIndex{}
+	if err := c.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	folderindex.RemoveDeletedMembers(root)
+	return root, true, nil
+}
+
+// virtualMachineFolder returns the key of the NamespacedFolder vm is filed
+// in. When creating is true, the folder named by the FolderAnnotation of vm
+// is the one it is about to be filed in.
+func virtualMachineFolder(ctx context.Context, c client.Reader, root *v1alpha1.FolderIndex, vm *virtv1.VirtualMachine, creating bool) (string, bool, error) {
+	if folder := vm.Annotations[v1alpha1.FolderAnnotation]; creating && folder != "" {
+		key := folderindex.NamespacedFolderKey(vm.Namespace, folder)
+		_, exists := root.Spec.NamespacedFolderEntries[key]
+		return key, exists, nil
+	}
+	return controller.VirtualMachineFolder(ctx, c, root, vm.Namespace, vm.Name)
+}
+
+// +kubebuilder:webhook:path=/mutate-kubevirt-io-v1-virtualmachine,mutating=true,failurePolicy=fail,sideEffects=None,groups=kubevirt.io,resources=virtualmachines,verbs=create;update,versions=v1,name=mvirtualmachine-v1.kb.io,admissionReviewVersions=v1
+
+// VirtualMachineCustomDefaulter struct is responsible for setting default values on the VirtualMachine
+// resource when it is created or updated.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as it is used only for temporary operations and does not need to be deeply copied.
+type VirtualMachineCustomDefaulter struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomDefaulter = &VirtualMachineCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type VirtualMachine.
+func (d *VirtualMachineCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	vm, ok := obj.(*virtv1.VirtualMachine)
+	if !ok {
+		return fmt.Errorf("expected a VirtualMachine object but got %T", obj)
+	}
+	virtualmachinelog.Info("Defaulting for VirtualMachine", "name", vm.GetName())
+
+	root, exists, err := getFolderIndex(ctx, d.Client)
+	if err != nil || !exists {
+		return err
+	}
+
+	creating := true
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		creating = req.Operation == admissionv1.Create
+	}
+	if creating {
+		if err := d.defaultFolder(ctx, root, vm); err != nil {
+			return err
+		}
+	}
+
+	key, exists, err := virtualMachineFolder(ctx, d.Client, root, vm, creating)
+	if err != nil {
+		return err
+	}
+	// a new VirtualMachine gets the defaults of its folder here, so the
+	// manager only defaults it again once it is placed in another folder
+	if creating {
+		if vm.Annotations == nil {
+			vm.Annotations = map[string]string{}
+		}
+		vm.Annotations[v1alpha1.PlacedFolderAnnotation] = ""
+		controller.SetPlacedFolder(vm, key, exists)
+	}
+	if !exists {
+		return nil
+	}
+	folders, err := controller.NamespacedFolders(ctx, d.Client, root, key)
+	if err != nil {
+		return err
+	}
+	controller.ApplyDefaults(vm, folders, creating)
+	return nil
+}
+
+// defaultFolder sets the FolderAnnotation of a new VirtualMachine that is in
+// no folder to the default folder of its namespace. A default folder that is
+// not in the FolderIndex, such as one that was removed, is skipped rather than
+// have every VM created in the namespace denied.
+func (d *VirtualMachineCustomDefaulter) defaultFolder(ctx context.Context, root *v1alpha1.FolderIndex, vm *virtv1.VirtualMachine) error {
+	if vm.Annotations[v1alpha1.FolderAnnotation] != "" {
+		return nil
+	}
+	if _, exists, err := controller.VirtualMachineFolder(ctx, d.Client, root, vm.Namespace, vm.Name); err != nil || exists {
+		return err
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := d.Client.Get(ctx, client.ObjectKey{Name: vm.Namespace}, namespace); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	folder := namespace.Annotations[v1alpha1.DefaultFolderAnnotation]
+	if folder == "" {
+		return nil
+	}
+	if _, exists := root.Spec.NamespacedFolderEntries[folderindex.NamespacedFolderKey(vm.Namespace, folder)]; !exists {
+		virtualmachinelog.Info("Skipping a default folder that is not in the FolderIndex",
+			"namespace", vm.Namespace, "folder", folder, "name", vm.GetName())
+		return nil
+	}
+	if vm.Annotations == nil {
+		vm.Annotations = map[string]string{}
+	}
+	vm.Annotations[v1alpha1.FolderAnnotation] = folder
+	return nil
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-kubevirt-io-v1-virtualmachine,mutating=false,failurePolicy=fail,sideEffects=None,groups=kubevirt.io,resources=virtualmachines,verbs=create;update,versions=v1,name=vvirtualmachine-v1.kb.io,admissionReviewVersions=v1
+
+// VirtualMachineCustomValidator struct is responsible for validating the VirtualMachine resource
+// when it is created, updated, or deleted.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type VirtualMachineCustomValidator struct {
+	Client client.Client
+}
+
+var _ webhook.CustomValidator = &VirtualMachineCustomValidator{}
+
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Validation rules
+//
+// quotas
+// 1. a VM counts against the quota of every ClusterFolder above its namespace.
+// 2. a VM cannot be created or grown past the quota of any of those folders.
+// 3. a VM that does not grow is admitted even if the folder is over quota,
+//    so lowering a quota does not block unrelated changes.
+//
+// limits
+// 1. a VM is held to the limits of the NamespacedFolder it is filed in and of
+//    each of its ancestors.
+// 2. the number of VMs is only checked when a VM is created.
+// 3. as with quotas, updates are only checked against the limits they change.
+//
+// placement
+// 1. the folder annotation can only be set when a VM is created.
+// 2. it must name a NamespacedFolder of the FolderIndex.
+// 3. it must be the default folder of the namespace, or the user creating the
+//    VM must be allowed to create FolderMemberships in the namespace.
+//
+
+// validatePlacement checks the folder vm asks to be filed in with the
+// FolderAnnotation. oldVM is the VirtualMachine being updated, or nil on
+// creation.
+func (v *VirtualMachineCustomValidator) validatePlacement(ctx context.Context, oldVM, vm *virtv1.VirtualMachine) error {
+	folder := vm.Annotations[v1alpha1.FolderAnnotation]
+	if folder == "" {
+		return nil
+	}
+	if oldVM != nil {
+		if oldVM.Annotations[v1alpha1.FolderAnnotation] == folder {
+			return nil
+		}
+		return fmt.Errorf("annotation [%s] can only be set when vm [%s] is created", v1alpha1.FolderAnnotation, vm.Name)
+	}
+
+	root, exists, err := getFolderIndex(ctx, v.Client)
+	if err != nil {
+		return err
+	}
+	key := folderindex.NamespacedFolderKey(vm.Namespace, folder)
+	if _, inIndex := root.Spec.NamespacedFolderEntries[key]; !exists || !inIndex {
+		return fmt.Errorf("namespaced folder [%s] of vm [%s] is not in the FolderIndex", key, vm.Name)
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := v.Client.Get(ctx, client.ObjectKey{Name: vm.Namespace}, namespace); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if namespace.Annotations[v1alpha1.DefaultFolderAnnotation] == folder {
+		return nil
+	}
+
+	// filing a VM takes the place of creating its FolderMembership, so the
+	// user needs the same permission
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, values := range req.UserInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(values)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: vm.Namespace,
+				Verb:      "create",
+				Group:     v1alpha1.GroupVersion.Group,
+				Resource:  "foldermemberships",
+			},
+			User:   req.UserInfo.Username,
+			Groups: req.UserInfo.Groups,
+			UID:    req.UserInfo.UID,
+			Extra:  extra,
+		},
+	}
+	if err := v.Client.Create(ctx, review); err != nil {
+		return err
+	}
+	if !review.Status.Allowed {
+		return fmt.Errorf("user [%s] is not allowed to file vm [%s] into namespaced folder [%s]", req.UserInfo.Username, vm.Name, key)
+	}
+	return nil
+}
+
+// validateLimits checks vm keeps to the limits of the NamespacedFolders it is
+// filed in. oldVM is the VirtualMachine being updated, or nil on creation.
+func (v *VirtualMachineCustomValidator) validateLimits(ctx context.Context, oldVM, vm *virtv1.VirtualMachine) error {
+	root, exists, err := getFolderIndex(ctx, v.Client)
+	if err != nil || !exists {
+		return err
+	}
+	key, exists, err := virtualMachineFolder(ctx, v.Client, root, vm, oldVM == nil)
+	if err != nil || !exists {
+		return err
+	}
+	folders, err := controller.NamespacedFolders(ctx, v.Client, root, key)
+	if err != nil {
+		return err
+	}
+
+	usage, err := controller.VirtualMachineUsage(ctx, v.Client, vm)
+	if err != nil {
+		return err
+	}
+	var oldUsage *v1alpha1.FolderQuotaUsage
+	if oldVM != nil {
+		u, err := controller.VirtualMachineUsage(ctx, v.Client, oldVM)
+		if err != nil {
+			return err
+		}
+		oldUsage = &u
+	}
+
+	for _, folder := range folders {
+		limits := folder.Spec.Limits
+		if limits == nil {
+			continue
+		}
+		key := folderindex.NamespacedFolderKey(folder.Namespace, folder.Name)
+		violations := []string{}
+
+		if limits.VirtualMachines != nil && oldVM == nil {
+			count, err := v.countVirtualMachines(ctx, root, key, vm)
+			if err != nil {
+				return err
+			}
+			if count > *limits.VirtualMachines {
+				violations = append(violations, fmt.Sprintf("virtualMachines: %d, limited to %d", count, *limits.VirtualMachines))
+			}
+		}
+		violations = append(violations, controller.LimitViolations(limits, vm, usage, oldVM, oldUsage)...)
+
+		if len(violations) != 0 {
+			return fmt.Errorf("vm [%s] in namespace [%s] exceeds the limits of namespaced folder [%s]: %s", vm.Name, vm.Namespace, key, strings.Join(violations, ", "))
+		}
+	}
+	return nil
+}
+
+// countVirtualMachines returns the number of VirtualMachines in the
+// NamespacedFolder key and its descendants once vm is created. VirtualMachines
+// the folder lists that do not exist are not counted.
+func (v *VirtualMachineCustomValidator) countVirtualMachines(ctx context.Context, root *v1alpha1.FolderIndex, key string, vm *virtv1.VirtualMachine) (int64, error) {
+	names, err := controller.FolderVirtualMachines(ctx, v.Client, root, key)
+	if err != nil {
+		return 0, err
+	}
+
+	vms := &virtv1.VirtualMachineList{}
+	if err := v.Client.List(ctx, vms, client.InNamespace(vm.Namespace)); err != nil {
+		return 0, err
+	}
+	existing := map[string]bool{}
+	for _, item := range vms.Items {
+		existing[item.Name] = true
+	}
+
+	count := int64(1)
+	for _, name := range names {
+		if name != vm.Name && existing[name] {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// validateQuotas checks vm fits in the quotas of the ClusterFolders above its
+// namespace. oldVM is the VirtualMachine being updated, or nil on creation.
+func (v *VirtualMachineCustomValidator) validateQuotas(ctx context.Context, oldVM, vm *virtv1.VirtualMachine) error {
+	root, exists, err := getFolderIndex(ctx, v.Client)
+	if err != nil || !exists {
+		return err
+	}
+	parent, exists := folderindex.NamespaceParent(root, vm.Namespace)
+	if !exists {
+		return nil
+	}
+
+	usage, err := controller.VirtualMachineUsage(ctx, v.Client, vm)
+	if err != nil {
+		return err
+	}
+	var oldUsage *v1alpha1.FolderQuotaUsage
+	if oldVM != nil {
+		u, err := controller.VirtualMachineUsage(ctx, v.Client, oldVM)
+		if err != nil {
+			return err
+		}
+		oldUsage = &u
+	}
+
+	for _, name := range folderindex.ClusterFolderAncestry(root, parent) {
+		folder := &v1alpha1.ClusterFolder{}
+		if err := v.Client.Get(ctx, client.ObjectKey{Name: name}, folder); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if folder.Spec.Quota == nil {
+			continue
+		}
+
+		// only the limits the VM grows against are enforced
+		hard := *folder.Spec.Quota
+		if oldUsage != nil {
+			hard = controller.GrowingQuota(hard, usage, *oldUsage)
+		}
+
+		// the usage is added up from the VMs below the folder rather than
+		// taken from its status, which trails the VMs admitted just before,
+		// and counts the VM as it was before the update
+		used, err := controller.ClusterFolderUsage(ctx, v.Client, root, name)
+		if err != nil {
+			return err
+		}
+		if oldUsage != nil {
+			controller.SubtractUsage(&used, *oldUsage)
+		}
+		controller.AddUsage(&used, usage)
+		if exceeded := controller.QuotaExceeded(hard, used); len(exceeded) != 0 {
+			return fmt.Errorf("vm [%s] in namespace [%s] exceeds the quota of cluster folder [%s]: %s", vm.Name, vm.Namespace, name, strings.Join(exceeded, ", "))
+		}
+	}
+	return nil
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type VirtualMachine.
+func (v *VirtualMachineCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	vm, ok := obj.(*virtv1.VirtualMachine)
+	if !ok {
+		return nil, fmt.Errorf("expected a VirtualMachine object but got %T", obj)
+	}
+	virtualmachinelog.Info("Validation for VirtualMachine upon creation", "name", vm.GetName())
+
+	if err := v.validatePlacement(ctx, nil, vm); err != nil {
+		return nil, err
+	}
+	if err := v.validateLimits(ctx, nil, vm); err != nil {
+		return nil, err
+	}
+	return nil, v.validateQuotas(ctx, nil, vm)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type VirtualMachine.
+func (v *VirtualMachineCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldVM, ok := oldObj.(*virtv1.VirtualMachine)
+	if !ok {
+		return nil, fmt.Errorf("expected a VirtualMachine object for the oldObj but got %T", oldObj)
+	}
+	vm, ok := newObj.(*virtv1.VirtualMachine)
+	if !ok {
+		return nil, fmt.Errorf("expected a VirtualMachine object for the newObj but got %T", newObj)
+	}
+	virtualmachinelog.Info("Validation for VirtualMachine upon update", "name", vm.GetName())
+
+	if err := v.validatePlacement(ctx, oldVM, vm); err != nil {
+		return nil, err
+	}
+	if err := v.validateLimits(ctx, oldVM, vm); err != nil {
+		return nil, err
+	}
+	return nil, v.validateQuotas(ctx, oldVM, vm)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type VirtualMachine.
+func (v *VirtualMachineCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	vm, ok := obj.(*virtv1.VirtualMachine)
+	if !ok {
+		return nil, fmt.Errorf("expected a VirtualMachine object but got %T", obj)
+	}
+	virtualmachinelog.Info("Validation for VirtualMachine upon deletion", "name", vm.GetName())
+
+	return nil, nil
+}
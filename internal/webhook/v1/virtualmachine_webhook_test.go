@@ -0,0 +1,370 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+)
+
+// newVirtualMachine returns a VirtualMachine with the given vCPUs and memory.
+func newVirtualMachine(namespace, name string, cpus uint32, memory string) *virtv1.VirtualMachine {
+	return &virtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: virtv1.VirtualMachineSpec{
+			Template: &virtv1.VirtualMachineInstanceTemplateSpec{
+				Spec: virtv1.VirtualMachineInstanceSpec{
+					Domain: virtv1.DomainSpec{
+						CPU:    &virtv1.CPU{Cores: cpus},
+						Memory: &virtv1.Memory{Guest: ptr.To(resource.MustParse(memory))},
+					},
+				},
+			},
+		},
+	}
+}
+
+var _ = Describe("VirtualMachine Webhook", func() {
+	ctx := context.Background()
+
+	var objs []client.Object
+
+	BeforeEach(func() {
+		objs = []client.Object{
+			&kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{
+				ObjectMeta: metav1.ObjectMeta{Name: "root"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderIndexSpec{
+					ClusterFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{
+						"operations": {ChildFolders: []string{"production"}},
+						"production": {Namespaces: []string{"prod-web-apps", "prod-db"}},
+					},
+					NamespacedFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+						"prod-web-apps/apps":     {ChildFolders: []string{"prod-web-apps/web-apps"}},
+						"prod-web-apps/web-apps": {VirtualMachines: []string{"web-app-a", "web-app-b", "web-app-c"}},
+					},
+				},
+			},
+			&kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{
+				ObjectMeta: metav1.ObjectMeta{Name: "operations"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderSpec{
+					Quota: &kubevirtfolderviewkubevirtiov1alpha1.FolderQuota{
+						VirtualMachines: ptr.To[int64](3),
+						CPU:             ptr.To(resource.MustParse("8")),
+						Memory:          ptr.To(resource.MustParse("16Gi")),
+					},
+				},
+			},
+			&kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{
+				ObjectMeta: metav1.ObjectMeta{Name: "production"},
+			},
+			&kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "apps"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderSpec{
+					Limits: &kubevirtfolderviewkubevirtiov1alpha1.FolderLimits{
+						CPUPerVirtualMachine: ptr.To(resource.MustParse("2")),
+						Instancetypes:        []string{"u1.small", "u1.medium"},
+					},
+					Defaults: &kubevirtfolderviewkubevirtiov1alpha1.FolderDefaults{
+						Labels:      map[string]string{"team": "apps", "tier": "backend"},
+						RunStrategy: "Halted",
+					},
+				},
+			},
+			&kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-apps"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderSpec{
+					Limits: &kubevirtfolderviewkubevirtiov1alpha1.FolderLimits{
+						VirtualMachines: ptr.To[int64](2),
+					},
+					Defaults: &kubevirtfolderviewkubevirtiov1alpha1.FolderDefaults{
+						Labels:       map[string]string{"tier": "frontend"},
+						Instancetype: &kubevirtfolderviewkubevirtiov1alpha1.InstancetypeReference{Name: "u1.small"},
+					},
+				},
+			},
+			newVirtualMachine("prod-web-apps", "web-app-a", 2, "4Gi"),
+			newVirtualMachine("prod-db", "db", 4, "8Gi"),
+		}
+	})
+
+	newClient := func() client.WithWatch {
+		return fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(objs...).
+			WithIndex(&kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{}, controller.FolderMembershipFolderField, func(obj client.Object) []string {
+				return []string{obj.(*kubevirtfolderviewkubevirtiov1alpha1.FolderMembership).Spec.Folder}
+			}).
+			Build()
+	}
+	newValidator := func() *VirtualMachineCustomValidator {
+		return &VirtualMachineCustomValidator{Client: newClient()}
+	}
+
+	Context("When creating or updating VirtualMachine under Validating Webhook", func() {
+		It("Should admit VMs within the quota of every folder above the namespace", func() {
+			vm := newVirtualMachine("prod-web-apps", "web-app-d", 2, "4Gi")
+			Expect(newValidator().ValidateCreate(ctx, vm)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should admit VMs in namespaces outside of folders", func() {
+			vm := newVirtualMachine("default", "web-app-b", 64, "256Gi")
+			Expect(newValidator().ValidateCreate(ctx, vm)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny VMs that exceed the quota of an ancestor folder", func() {
+			vm := newVirtualMachine("prod-web-apps", "web-app-d", 4, "4Gi")
+			Expect(newValidator().ValidateCreate(ctx, vm)).Error().To(MatchError(ContainSubstring(
+				"exceeds the quota of cluster folder [operations]: cpu: used 10, limited to 8")))
+		})
+
+		It("Should deny VMs past the VM count of the quota", func() {
+			objs = append(objs, newVirtualMachine("prod-db", "db-replica", 1, "1Gi"))
+			vm := newVirtualMachine("prod-web-apps", "web-app-d", 1, "1Gi")
+			Expect(newValidator().ValidateCreate(ctx, vm)).Error().To(MatchError(ContainSubstring(
+				"virtualMachines: used 4, limited to 3")))
+		})
+
+		It("Should add up the usage of the quota rather than take it from the status of the folder", func() {
+			folder := objs[1].(*kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder)
+			folder.Status.Quota = &kubevirtfolderviewkubevirtiov1alpha1.FolderQuotaStatus{
+				Hard: *folder.Spec.Quota.DeepCopy(),
+				Used: kubevirtfolderviewkubevirtiov1alpha1.FolderQuotaUsage{
+					VirtualMachines: 2,
+					CPU:             resource.MustParse("6"),
+					Memory:          resource.MustParse("12Gi"),
+				},
+			}
+			cl := newClient()
+			validator := &VirtualMachineCustomValidator{Client: cl}
+
+			vm := newVirtualMachine("prod-web-apps", "web-app-d", 1, "1Gi")
+			Expect(validator.ValidateCreate(ctx, vm)).Error().NotTo(HaveOccurred())
+			Expect(cl.Create(ctx, vm)).To(Succeed())
+
+			By("counting a VM admitted before the folder reports it")
+			vm = newVirtualMachine("prod-web-apps", "web-app-e", 1, "1Gi")
+			Expect(validator.ValidateCreate(ctx, vm)).Error().To(MatchError(ContainSubstring(
+				"virtualMachines: used 4, limited to 3")))
+		})
+
+		It("Should count the VM being updated only once", func() {
+			oldVM := newVirtualMachine("prod-db", "db", 4, "8Gi")
+			vm := newVirtualMachine("prod-db", "db", 6, "8Gi")
+			Expect(newValidator().ValidateUpdate(ctx, oldVM, vm)).Error().NotTo(HaveOccurred())
+
+			vm = newVirtualMachine("prod-db", "db", 7, "8Gi")
+			Expect(newValidator().ValidateUpdate(ctx, oldVM, vm)).Error().To(MatchError(ContainSubstring("cpu: used 9, limited to 8")))
+		})
+
+		It("Should admit updates that do not grow a VM of a folder over its quota", func() {
+			objs = append(objs, newVirtualMachine("prod-db", "db-replica", 6, "1Gi"))
+			oldVM := newVirtualMachine("prod-db", "db", 4, "8Gi")
+			vm := newVirtualMachine("prod-db", "db", 2, "8Gi")
+			Expect(newValidator().ValidateUpdate(ctx, oldVM, vm)).Error().NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When creating or updating VirtualMachine in a NamespacedFolder", func() {
+		It("Should deny VMs past the limits of the folder and of its ancestors", func() {
+			vm := newVirtualMachine("prod-web-apps", "web-app-b", 1, "1Gi")
+			vm.Spec.Instancetype = &virtv1.InstancetypeMatcher{Name: "u1.large"}
+			Expect(newValidator().ValidateCreate(ctx, vm)).Error().To(MatchError(ContainSubstring(
+				"exceeds the limits of namespaced folder [prod-web-apps/apps]: instancetype [u1.large] is not one of [u1.small, u1.medium]")))
+
+			vm = newVirtualMachine("prod-web-apps", "web-app-b", 1, "1Gi")
+			vm.Spec.Instancetype = &virtv1.InstancetypeMatcher{Name: "u1.small"}
+			Expect(newValidator().ValidateCreate(ctx, vm)).Error().NotTo(HaveOccurred())
+
+			oldVM := vm.DeepCopy()
+			vm.Spec.Template.Spec.Domain.CPU.Cores = 3
+			Expect(newValidator().ValidateUpdate(ctx, oldVM, vm)).Error().To(MatchError(ContainSubstring(
+				"cpu: 3, limited to 2 per VM")))
+		})
+
+		It("Should deny VMs past the VM count of the folder", func() {
+			objs = append(objs, newVirtualMachine("prod-web-apps", "web-app-b", 1, "1Gi"))
+			vm := newVirtualMachine("prod-web-apps", "web-app-c", 1, "1Gi")
+			vm.Spec.Instancetype = &virtv1.InstancetypeMatcher{Name: "u1.small"}
+			Expect(newValidator().ValidateCreate(ctx, vm)).Error().To(MatchError(ContainSubstring(
+				"exceeds the limits of namespaced folder [prod-web-apps/web-apps]: virtualMachines: 3, limited to 2")))
+		})
+
+		It("Should admit updates that do not change what the limits constrain", func() {
+			objs = append(objs, newVirtualMachine("prod-web-apps", "web-app-b", 1, "1Gi"))
+			oldVM := newVirtualMachine("prod-web-apps", "web-app-a", 4, "4Gi")
+			vm := oldVM.DeepCopy()
+			vm.Labels = map[string]string{"app": "web"}
+			Expect(newValidator().ValidateUpdate(ctx, oldVM, vm)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should set the defaults of the nearest folder on new VMs", func() {
+			vm := &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-b", Labels: map[string]string{"team": "web"}},
+			}
+			defaulter := &VirtualMachineCustomDefaulter{Client: newClient()}
+			Expect(defaulter.Default(ctx, vm)).To(Succeed())
+
+			Expect(vm.Labels).To(Equal(map[string]string{"team": "web", "tier": "frontend"}))
+			Expect(vm.Spec.Instancetype).To(Equal(&virtv1.InstancetypeMatcher{Name: "u1.small"}))
+			Expect(vm.Spec.RunStrategy).To(HaveValue(Equal(virtv1.RunStrategyHalted)))
+			Expect(vm.Annotations).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation, "web-apps"))
+		})
+
+		It("Should only set default labels on existing VMs", func() {
+			vm := newVirtualMachine("prod-web-apps", "web-app-a", 2, "4Gi")
+			defaulter := &VirtualMachineCustomDefaulter{Client: newClient()}
+			updateCtx := admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update},
+			})
+			Expect(defaulter.Default(updateCtx, vm)).To(Succeed())
+
+			Expect(vm.Labels).To(Equal(map[string]string{"team": "apps", "tier": "frontend"}))
+			Expect(vm.Spec.Instancetype).To(BeNil())
+			Expect(vm.Spec.RunStrategy).To(BeNil())
+			Expect(vm.Annotations).NotTo(HaveKey(kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation))
+		})
+
+		It("Should only track the folder of new VMs outside of NamespacedFolders", func() {
+			vm := &virtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "prod-web-apps",
+				Name:        "unfiled",
+				Annotations: map[string]string{kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation: "web-apps"},
+			}}
+			defaulter := &VirtualMachineCustomDefaulter{Client: newClient()}
+			Expect(defaulter.Default(ctx, vm)).To(Succeed())
+			Expect(vm.Labels).To(BeEmpty())
+			Expect(vm.Spec.Instancetype).To(BeNil())
+			Expect(vm.Annotations).To(HaveKeyWithValue(kubevirtfolderviewkubevirtiov1alpha1.PlacedFolderAnnotation, ""))
+		})
+	})
+
+	Context("When creating VirtualMachine with a folder annotation", func() {
+		userCtx := func(username string) context.Context {
+			return admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Create,
+					UserInfo:  authenticationv1.UserInfo{Username: username},
+				},
+			})
+		}
+
+		newAuthorizingValidator := func(allowed string) *VirtualMachineCustomValidator {
+			cl := interceptor.NewClient(newClient(), interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					review, ok := obj.(*authorizationv1.SubjectAccessReview)
+					if !ok {
+						return c.Create(ctx, obj, opts...)
+					}
+					review.Status.Allowed = review.Spec.User == allowed &&
+						review.Spec.ResourceAttributes.Resource == "foldermemberships" &&
+						review.Spec.ResourceAttributes.Namespace == "prod-web-apps"
+					return nil
+				},
+			})
+			return &VirtualMachineCustomValidator{Client: cl}
+		}
+
+		It("Should file new VMs in no folder into the default folder of the namespace", func() {
+			objs = append(objs, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "prod-web-apps",
+					Annotations: map[string]string{kubevirtfolderviewkubevirtiov1alpha1.DefaultFolderAnnotation: "apps"},
+				},
+			})
+			defaulter := &VirtualMachineCustomDefaulter{Client: newClient()}
+
+			vm := &virtv1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "unfiled"},
+				Spec:       virtv1.VirtualMachineSpec{Instancetype: &virtv1.InstancetypeMatcher{Name: "u1.medium"}},
+			}
+			Expect(defaulter.Default(userCtx("developer"), vm)).To(Succeed())
+			Expect(vm.Annotations).To(HaveKeyWithValue("folderview.kubevirt.io/folder", "apps"))
+			Expect(vm.Labels).To(HaveKeyWithValue("team", "apps"))
+			Expect(newAuthorizingValidator("").ValidateCreate(userCtx("developer"), vm)).Error().NotTo(HaveOccurred())
+
+			vm = &virtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-b"}}
+			Expect(defaulter.Default(userCtx("developer"), vm)).To(Succeed())
+			Expect(vm.Annotations).NotTo(HaveKey("folderview.kubevirt.io/folder"))
+		})
+
+		It("Should skip a default folder that is not in the index", func() {
+			objs = append(objs, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "prod-web-apps",
+					Annotations: map[string]string{kubevirtfolderviewkubevirtiov1alpha1.DefaultFolderAnnotation: "removed"},
+				},
+			})
+			defaulter := &VirtualMachineCustomDefaulter{Client: newClient()}
+
+			vm := newVirtualMachine("prod-web-apps", "unfiled", 1, "1Gi")
+			Expect(defaulter.Default(userCtx("developer"), vm)).To(Succeed())
+			Expect(vm.Annotations).NotTo(HaveKey("folderview.kubevirt.io/folder"))
+			Expect(newAuthorizingValidator("").ValidateCreate(userCtx("developer"), vm)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should only let users who may create FolderMemberships file VMs", func() {
+			vm := newVirtualMachine("prod-web-apps", "unfiled", 1, "1Gi")
+			vm.Annotations = map[string]string{kubevirtfolderviewkubevirtiov1alpha1.FolderAnnotation: "web-apps"}
+			vm.Spec.Instancetype = &virtv1.InstancetypeMatcher{Name: "u1.small"}
+
+			Expect(newAuthorizingValidator("admin").ValidateCreate(userCtx("admin"), vm)).Error().NotTo(HaveOccurred())
+			Expect(newAuthorizingValidator("admin").ValidateCreate(userCtx("developer"), vm)).Error().To(MatchError(
+				"user [developer] is not allowed to file vm [unfiled] into namespaced folder [prod-web-apps/web-apps]"))
+		})
+
+		It("Should hold VMs to the limits of the folder they are filed into", func() {
+			objs = append(objs, newVirtualMachine("prod-web-apps", "web-app-b", 1, "1Gi"))
+			vm := newVirtualMachine("prod-web-apps", "unfiled", 1, "1Gi")
+			vm.Annotations = map[string]string{kubevirtfolderviewkubevirtiov1alpha1.FolderAnnotation: "web-apps"}
+			vm.Spec.Instancetype = &virtv1.InstancetypeMatcher{Name: "u1.small"}
+
+			Expect(newAuthorizingValidator("admin").ValidateCreate(userCtx("admin"), vm)).Error().To(MatchError(ContainSubstring(
+				"virtualMachines: 3, limited to 2")))
+		})
+
+		It("Should deny folders outside of the index and annotations set after creation", func() {
+			vm := newVirtualMachine("prod-web-apps", "unfiled", 1, "1Gi")
+			vm.Annotations = map[string]string{kubevirtfolderviewkubevirtiov1alpha1.FolderAnnotation: "missing"}
+			Expect(newAuthorizingValidator("admin").ValidateCreate(userCtx("admin"), vm)).Error().To(MatchError(
+				"namespaced folder [prod-web-apps/missing] of vm [unfiled] is not in the FolderIndex"))
+
+			oldVM := newVirtualMachine("prod-web-apps", "unfiled", 1, "1Gi")
+			vm.Annotations[kubevirtfolderviewkubevirtiov1alpha1.FolderAnnotation] = "web-apps"
+			Expect(newAuthorizingValidator("admin").ValidateUpdate(userCtx("admin"), oldVM, vm)).Error().To(MatchError(
+				"annotation [folderview.kubevirt.io/folder] can only be set when vm [unfiled] is created"))
+		})
+	})
+})
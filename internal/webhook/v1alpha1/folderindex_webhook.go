@@ -19,15 +19,18 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	v1beta1 "github.com/davidvossel/kubevirt-folder-view/api/v1beta1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
 )
 
 // nolint:unused
@@ -35,9 +38,10 @@ import (
 var folderindexlog = logf.Log.WithName("folderindex-resource")
 
 // SetupFolderIndexWebhookWithManager registers the webhook for FolderIndex in the manager.
+// As v1alpha1 is the conversion hub, this also serves FolderIndex conversion.
 func SetupFolderIndexWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).For(&v1alpha1.FolderIndex{}).
-		WithValidator(&FolderIndexCustomValidator{}).
+		WithValidator(&FolderIndexCustomValidator{Client: mgr.GetClient()}).
 		Complete()
 }
 
@@ -51,6 +55,7 @@ func SetupFolderIndexWebhookWithManager(mgr ctrl.Manager) error {
 // NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
 // as this struct is used only for temporary operations and does not need to be deeply copied.
 type FolderIndexCustomValidator struct {
+	Client client.Reader
 }
 
 var _ webhook.CustomValidator = &FolderIndexCustomValidator{}
@@ -65,8 +70,45 @@ var _ webhook.CustomValidator = &FolderIndexCustomValidator{}
 // Loops
 // 1. a child folder cannot also point to a parent in the same chain.
 //
+// References
+// 1. namespaced folders are referenced as "namespace/name".
+// 2. a namespaced folder's child folders are in its namespace.
+//
+// Limits
+// 1. VMs filed into a namespaced folder of the root index keep to the limits
+//    of the folder and of its ancestors.
+//
+// Quotas
+// 1. namespaces filed into a cluster folder of the root index keep the
+//    folder and its ancestors within their quotas.
+//
+
+// validateNamespacedReferences checks every NamespacedFolder key and child
+// folder reference parses into a namespace and name.
+func validateNamespacedReferences(folderIndex *v1alpha1.FolderIndex) error {
+	for folder, entry := range folderIndex.Spec.NamespacedFolderEntries {
+		ref, err := v1beta1.ParseNamespacedFolderReference(folder)
+		if err != nil {
+			return err
+		}
+		for _, child := range entry.ChildFolders {
+			childRef, err := v1beta1.ParseNamespacedFolderReference(child)
+			if err != nil {
+				return fmt.Errorf("child folder of folder [%s]: %w", folder, err)
+			}
+			if childRef.Namespace != ref.Namespace {
+				return fmt.Errorf("child folder [%s] of folder [%s] must be in namespace [%s]", child, folder, ref.Namespace)
+			}
+		}
+	}
+	return nil
+}
 
 func validateNamespacedEntries(folderIndex *v1alpha1.FolderIndex) error {
+	if err := validateNamespacedReferences(folderIndex); err != nil {
+		return err
+	}
+
 	visited := map[string]bool{}
 	onPath := map[string]bool{}
 	vmParentMap := map[string]string{}
@@ -92,7 +134,11 @@ func validateNamespacedEntries(folderIndex *v1alpha1.FolderIndex) error {
 			return nil
 		}
 
-		namespace := strings.Split(folder, "/")[0]
+		ref, err := v1beta1.ParseNamespacedFolderReference(folder)
+		if err != nil {
+			return err
+		}
+		namespace := ref.Namespace
 
 		for _, vm := range entry.VirtualMachines {
 			vmNamespaceName := fmt.Sprintf("%s/%s", namespace, vm)
@@ -187,6 +233,44 @@ func validateClusterEntries(folderIndex *v1alpha1.FolderIndex) error {
 	return nil
 }
 
+// folderViews returns the folder views of oldIndex and folderIndex, with the
+// FolderMemberships filed into them and without the members flagged as
+// deleted. oldIndex is nil on creation.
+func (v *FolderIndexCustomValidator) folderViews(ctx context.Context, oldIndex, folderIndex *v1alpha1.FolderIndex) (*v1alpha1.FolderIndex, *v1alpha1.FolderIndex, error) {
+	memberships := &v1alpha1.FolderMembershipList{}
+	if err := v.Client.List(ctx, memberships); err != nil {
+		return nil, nil, err
+	}
+
+	before := &v1alpha1.FolderIndex{}
+	if oldIndex != nil {
+		before = oldIndex.DeepCopy()
+	}
+	after := folderIndex.DeepCopy()
+	for _, view := range []*v1alpha1.FolderIndex{before, after} {
+		folderindex.RemoveDeletedMembers(view)
+		folderindex.AddMemberships(view, memberships.Items)
+	}
+	return before, after, nil
+}
+
+// validateFiling checks the VMs the root index files into a namespaced folder
+// keep to its limits, and the namespaces it files into a cluster folder keep
+// it within its quota. Other indexes grant nothing and are not checked.
+func (v *FolderIndexCustomValidator) validateFiling(ctx context.Context, oldIndex, folderIndex *v1alpha1.FolderIndex) error {
+	if folderIndex.Name != folderindex.RootName {
+		return nil
+	}
+	before, after, err := v.folderViews(ctx, oldIndex, folderIndex)
+	if err != nil {
+		return err
+	}
+	if err := controller.CheckFiledVirtualMachines(ctx, v.Client, before, after); err != nil {
+		return err
+	}
+	return controller.CheckFiledNamespaces(ctx, v.Client, before, after)
+}
+
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type FolderIndex.
 func (v *FolderIndexCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	folderIndex, ok := obj.(*v1alpha1.FolderIndex)
@@ -199,12 +283,20 @@ func (v *FolderIndexCustomValidator) ValidateCreate(ctx context.Context, obj run
 	if err != nil {
 		return nil, err
 	}
+	err = validateNamespacedEntries(folderIndex)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil, nil
+	return nil, v.validateFiling(ctx, nil, folderIndex)
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type FolderIndex.
 func (v *FolderIndexCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldIndex, ok := oldObj.(*v1alpha1.FolderIndex)
+	if !ok {
+		return nil, fmt.Errorf("expected a FolderIndex object for the oldObj but got %T", oldObj)
+	}
 	folderIndex, ok := newObj.(*v1alpha1.FolderIndex)
 	if !ok {
 		return nil, fmt.Errorf("expected a FolderIndex object for the newObj but got %T", newObj)
@@ -220,7 +312,7 @@ func (v *FolderIndexCustomValidator) ValidateUpdate(ctx context.Context, oldObj,
 		return nil, err
 	}
 
-	return nil, nil
+	return nil, v.validateFiling(ctx, oldIndex, folderIndex)
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type FolderIndex.
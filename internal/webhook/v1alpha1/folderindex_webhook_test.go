@@ -19,9 +19,10 @@ package v1alpha1
 import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 
 	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
-	// TODO (user): Add any additional imports if needed
 )
 
 var _ = Describe("FolderIndex Webhook", func() {
@@ -46,26 +47,81 @@ var _ = Describe("FolderIndex Webhook", func() {
 	})
 
 	Context("When creating or updating FolderIndex under Validating Webhook", func() {
-		// TODO (user): Add logic for validating webhooks
-		// Example:
-		// It("Should deny creation if a required field is missing", func() {
-		//     By("simulating an invalid creation scenario")
-		//     obj.SomeRequiredField = ""
-		//     Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
-		// })
-		//
-		// It("Should admit creation if all required fields are present", func() {
-		//     By("simulating an invalid creation scenario")
-		//     obj.SomeRequiredField = "valid_value"
-		//     Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
-		// })
-		//
-		// It("Should validate updates correctly", func() {
-		//     By("simulating a valid update scenario")
-		//     oldObj.SomeRequiredField = "updated_value"
-		//     obj.SomeRequiredField = "updated_value"
-		//     Expect(validator.ValidateUpdate(ctx, oldObj, obj)).To(BeNil())
-		// })
+		It("Should admit namespaced folders referenced as namespace/name", func() {
+			obj.Spec.NamespacedFolderEntries = map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				"prod-web-apps/prod-web-app-a": {ChildFolders: []string{"prod-web-apps/db"}},
+				"prod-web-apps/db":             {VirtualMachines: []string{"web-app-a-db"}},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny malformed namespaced folder keys", func() {
+			obj.Spec.NamespacedFolderEntries = map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				"prod-web-app-a": {VirtualMachines: []string{"web-app-a"}},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(HaveOccurred())
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().To(HaveOccurred())
+		})
+
+		It("Should deny malformed child folder references", func() {
+			obj.Spec.NamespacedFolderEntries = map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				"prod-web-apps/prod-web-app-a": {ChildFolders: []string{"db"}},
+			}
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().To(HaveOccurred())
+		})
+
+		It("Should deny child folders in another namespace", func() {
+			obj.Spec.NamespacedFolderEntries = map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				"prod-web-apps/prod-web-app-a": {ChildFolders: []string{"staging-web-apps/db"}},
+			}
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().To(HaveOccurred())
+		})
+
+		It("Should deny filing VMs past the limits of a namespaced folder", func() {
+			root, objs := limitedFolderObjects()
+			validator = FolderIndexCustomValidator{Client: newLimitsClient(objs...)}
+
+			moved := root.DeepCopy()
+			moved.Spec.NamespacedFolderEntries["prod-web-apps/web"] = kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				VirtualMachines: []string{"web-app-a", "web-app-b"},
+			}
+			moved.Spec.NamespacedFolderEntries["prod-web-apps/debug"] = kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{}
+			Expect(validator.ValidateUpdate(ctx, root, moved)).Error().To(MatchError(ContainSubstring(
+				"filing vms into namespaced folder [prod-web-apps/web] exceeds its limits")))
+
+			By("admitting VMs that do not exist yet")
+			moved.Spec.NamespacedFolderEntries["prod-web-apps/web"] = kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				VirtualMachines: []string{"web-app-a", "web-app-c"},
+			}
+			Expect(validator.ValidateUpdate(ctx, root, moved)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny filing namespaces past the quota of a cluster folder", func() {
+			root, objs := limitedFolderObjects()
+			root.Spec.ClusterFolderEntries = map[string]kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{
+				"prod":    {ChildFolders: []string{"web"}},
+				"web":     {},
+				"staging": {Namespaces: []string{"prod-web-apps"}},
+			}
+			objs = append(objs, &kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderSpec{
+					Quota: &kubevirtfolderviewkubevirtiov1alpha1.FolderQuota{VirtualMachines: ptr.To[int64](1)},
+				},
+			})
+			validator = FolderIndexCustomValidator{Client: newLimitsClient(objs...)}
+
+			moved := root.DeepCopy()
+			moved.Spec.ClusterFolderEntries["staging"] = kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{}
+			moved.Spec.ClusterFolderEntries["web"] = kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{Namespaces: []string{"prod-web-apps"}}
+			Expect(validator.ValidateUpdate(ctx, root, moved)).Error().To(MatchError(
+				"filing namespaces [prod-web-apps] into cluster folder [prod] exceeds its quota: virtualMachines: used 2, limited to 1"))
+			Expect(validator.ValidateCreate(ctx, moved)).Error().To(HaveOccurred())
+
+			By("admitting changes that file no namespaces into the folder")
+			Expect(validator.ValidateUpdate(ctx, moved, moved)).Error().NotTo(HaveOccurred())
+		})
 	})
 
 })
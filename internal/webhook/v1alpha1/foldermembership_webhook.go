@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var foldermembershiplog = logf.Log.WithName("foldermembership-resource")
+
+// SetupFolderMembershipWebhookWithManager registers the webhook for FolderMembership in the manager.
+func SetupFolderMembershipWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&v1alpha1.FolderMembership{}).
+		WithValidator(&FolderMembershipCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-kubevirtfolderview-kubevirt-io-github-com-v1alpha1-foldermembership,mutating=false,failurePolicy=fail,sideEffects=None,groups=kubevirtfolderview.kubevirt.io.github.com,resources=foldermemberships,verbs=create;update,versions=v1alpha1,name=vfoldermembership-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// FolderMembershipCustomValidator struct is responsible for validating the FolderMembership resource
+// when it is created, updated, or deleted.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type FolderMembershipCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &FolderMembershipCustomValidator{}
+
+// Validation rules
+//
+// Limits
+// 1. the VM a membership files into a namespaced folder keeps to the limits
+//    of the folder and of its ancestors.
+//
+
+// validateLimits checks the VM membership files keeps to the limits of its
+// new folder.
+func (v *FolderMembershipCustomValidator) validateLimits(ctx context.Context, membership *v1alpha1.FolderMembership) error {
+	root := &v1alpha1.FolderIndex{}
+	if err := v.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	folderindex.RemoveDeletedMembers(root)
+
+	memberships := &v1alpha1.FolderMembershipList{}
+	if err := v.Client.List(ctx, memberships, client.InNamespace(membership.Namespace)); err != nil {
+		return err
+	}
+	changed := []v1alpha1.FolderMembership{*membership}
+	for _, other := range memberships.Items {
+		if other.Name != membership.Name {
+			changed = append(changed, other)
+		}
+	}
+
+	before := root.DeepCopy()
+	folderindex.AddMemberships(before, memberships.Items)
+	after := root.DeepCopy()
+	folderindex.AddMemberships(after, changed)
+	return controller.CheckFiledVirtualMachines(ctx, v.Client, before, after)
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type FolderMembership.
+func (v *FolderMembershipCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	membership, ok := obj.(*v1alpha1.FolderMembership)
+	if !ok {
+		return nil, fmt.Errorf("expected a FolderMembership object but got %T", obj)
+	}
+	foldermembershiplog.Info("Validation for FolderMembership upon creation", "name", membership.GetName())
+
+	return nil, v.validateLimits(ctx, membership)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type FolderMembership.
+func (v *FolderMembershipCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	membership, ok := newObj.(*v1alpha1.FolderMembership)
+	if !ok {
+		return nil, fmt.Errorf("expected a FolderMembership object for the newObj but got %T", newObj)
+	}
+	foldermembershiplog.Info("Validation for FolderMembership upon update", "name", membership.GetName())
+
+	return nil, v.validateLimits(ctx, membership)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type FolderMembership.
+func (v *FolderMembershipCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	membership, ok := obj.(*v1alpha1.FolderMembership)
+	if !ok {
+		return nil, fmt.Errorf("expected a FolderMembership object but got %T", obj)
+	}
+	foldermembershiplog.Info("Validation for FolderMembership upon deletion", "name", membership.GetName())
+
+	return nil, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	virtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+// limitedFolderObjects returns a root index with a NamespacedFolder limited to
+// one VM of at most 2 vCPUs, holding web-app-a, and an unlimited folder
+// holding the 4 vCPU web-app-b.
+func limitedFolderObjects() (*kubevirtfolderviewkubevirtiov1alpha1.FolderIndex, []client.Object) {
+	root := &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{
+		ObjectMeta: metav1.ObjectMeta{Name: "root"},
+		Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderIndexSpec{
+			NamespacedFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderEntry{
+				"prod-web-apps/web":   {VirtualMachines: []string{"web-app-a"}},
+				"prod-web-apps/debug": {VirtualMachines: []string{"web-app-b"}},
+			},
+		},
+	}
+	vm := func(name string, cpus uint32) *virtv1.VirtualMachine {
+		return &virtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: name},
+			Spec: virtv1.VirtualMachineSpec{
+				Template: &virtv1.VirtualMachineInstanceTemplateSpec{
+					Spec: virtv1.VirtualMachineInstanceSpec{
+						Domain: virtv1.DomainSpec{CPU: &virtv1.CPU{Cores: cpus}},
+					},
+				},
+			},
+		}
+	}
+	objs := []client.Object{
+		root,
+		vm("web-app-a", 1),
+		vm("web-app-b", 4),
+		&kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web"},
+			Spec: kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderSpec{
+				Limits: &kubevirtfolderviewkubevirtiov1alpha1.FolderLimits{
+					VirtualMachines:      ptr.To[int64](1),
+					CPUPerVirtualMachine: ptr.To(resource.MustParse("2")),
+				},
+			},
+		},
+		&kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "debug"},
+		},
+	}
+	return root, objs
+}
+
+// newLimitsClient returns a fake client over objs that knows the
+// VirtualMachine type.
+func newLimitsClient(objs ...client.Object) client.Client {
+	s := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+	Expect(kubevirtfolderviewkubevirtiov1alpha1.AddToScheme(s)).To(Succeed())
+	Expect(virtv1.AddToScheme(s)).To(Succeed())
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+}
+
+var _ = Describe("FolderMembership Webhook", func() {
+	var validator FolderMembershipCustomValidator
+
+	BeforeEach(func() {
+		_, objs := limitedFolderObjects()
+		validator = FolderMembershipCustomValidator{Client: newLimitsClient(objs...)}
+	})
+
+	Context("When creating or updating FolderMembership under Validating Webhook", func() {
+		It("Should deny filing a VM past the limits of its new folder", func() {
+			membership := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-b"},
+				Spec:       kubevirtfolderviewkubevirtiov1alpha1.FolderMembershipSpec{Folder: "web"},
+			}
+			Expect(validator.ValidateCreate(ctx, membership)).Error().To(MatchError(
+				"filing vms into namespaced folder [prod-web-apps/web] exceeds its limits: " +
+					"virtualMachines: 2, limited to 1, vm [web-app-b] cpu: 4, limited to 2 per VM"))
+			Expect(validator.ValidateUpdate(ctx, membership, membership)).Error().To(HaveOccurred())
+		})
+
+		It("Should admit filing a VM into a folder without limits", func() {
+			membership := &kubevirtfolderviewkubevirtiov1alpha1.FolderMembership{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod-web-apps", Name: "web-app-a"},
+				Spec:       kubevirtfolderviewkubevirtiov1alpha1.FolderMembershipSpec{Folder: "debug"},
+			}
+			Expect(validator.ValidateCreate(ctx, membership)).Error().NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,158 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	v1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	"github.com/davidvossel/kubevirt-folder-view/internal/controller"
+	"github.com/davidvossel/kubevirt-folder-view/internal/folderindex"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var namespacedfolderlog = logf.Log.WithName("namespacedfolder-resource")
+
+// SetupNamespacedFolderWebhookWithManager registers the webhook for NamespacedFolder in the manager.
+// v1alpha1 is also the hub the v1beta1 NamespacedFolder converts to and from.
+func SetupNamespacedFolderWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&v1alpha1.NamespacedFolder{}).
+		WithValidator(&NamespacedFolderCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-kubevirtfolderview-kubevirt-io-github-com-v1alpha1-namespacedfolder,mutating=false,failurePolicy=fail,sideEffects=None,groups=kubevirtfolderview.kubevirt.io.github.com,resources=namespacedfolders,verbs=create;update,versions=v1alpha1,name=vnamespacedfolder-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// NamespacedFolderCustomValidator struct is responsible for validating the NamespacedFolder resource
+// when it is created, updated, or deleted.
+//
+// NOTE: The +kubebuilder:object:generate=false marker prevents controller-gen from generating DeepCopy methods,
+// as this struct is used only for temporary operations and does not need to be deeply copied.
+type NamespacedFolderCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &NamespacedFolderCustomValidator{}
+
+// Validation rules
+//
+// Inheritance
+// 1. a namespaced folder only starts blocking inheritance when no cluster
+//    folder above its namespace grants access to the whole namespace, as
+//    blocking cannot take that access away from its VMs.
+//
+
+// validateBlock checks no ClusterFolder permissions reach the namespace of
+// folder, which blocks inheritance.
+func (v *NamespacedFolderCustomValidator) validateBlock(ctx context.Context, folder *v1alpha1.NamespacedFolder) error {
+	root := &v1alpha1.FolderIndex{}
+	if err := v.Client.Get(ctx, client.ObjectKey{Name: folderindex.RootName}, root); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	folderindex.RemoveDeletedMembers(root)
+
+	parent, ok := folderindex.NamespaceParent(root, folder.Namespace)
+	if !ok {
+		return nil
+	}
+	blocked, err := controller.BlockedClusterFolders(ctx, v.Client)
+	if err != nil {
+		return err
+	}
+
+	// the ClusterFolder holding the namespace grants it all of its
+	// permissions
+	for i, name := range folderindex.InheritedAncestry(folderindex.ClusterFolderAncestry(root, parent), blocked) {
+		clusterFolder := &v1alpha1.ClusterFolder{}
+		if err := v.Client.Get(ctx, client.ObjectKey{Name: name}, clusterFolder); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if len(folderindex.Permissions(clusterFolder.Spec.FolderPermissions, i > 0)) != 0 {
+			return fmt.Errorf("namespaced folder [%s] cannot block inheritance, cluster folder [%s] grants access to all of namespace [%s]",
+				folderindex.NamespacedFolderKey(folder.Namespace, folder.Name), name, folder.Namespace)
+		}
+	}
+	return nil
+}
+
+// blocks reports whether folder blocks the permissions of its ancestors.
+func blocks(folder *v1alpha1.NamespacedFolder) bool {
+	return folder.Spec.Inheritance != nil && folder.Spec.Inheritance.Block
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type NamespacedFolder.
+func (v *NamespacedFolderCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	folder, ok := obj.(*v1alpha1.NamespacedFolder)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespacedFolder object but got %T", obj)
+	}
+	namespacedfolderlog.Info("Validation for NamespacedFolder upon creation", "name", folder.GetName())
+
+	if !blocks(folder) {
+		return nil, nil
+	}
+	return nil, v.validateBlock(ctx, folder)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type NamespacedFolder.
+// Only updates that start blocking inheritance are checked, so granting a ClusterFolder
+// permissions later does not block unrelated changes to the folder.
+func (v *NamespacedFolderCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldFolder, ok := oldObj.(*v1alpha1.NamespacedFolder)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespacedFolder object for the oldObj but got %T", oldObj)
+	}
+	folder, ok := newObj.(*v1alpha1.NamespacedFolder)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespacedFolder object for the newObj but got %T", newObj)
+	}
+	namespacedfolderlog.Info("Validation for NamespacedFolder upon update", "name", folder.GetName())
+
+	if !blocks(folder) || blocks(oldFolder) {
+		return nil, nil
+	}
+	return nil, v.validateBlock(ctx, folder)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type NamespacedFolder.
+func (v *NamespacedFolderCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	folder, ok := obj.(*v1alpha1.NamespacedFolder)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespacedFolder object but got %T", obj)
+	}
+	namespacedfolderlog.Info("Validation for NamespacedFolder upon deletion", "name", folder.GetName())
+
+	return nil, nil
+}
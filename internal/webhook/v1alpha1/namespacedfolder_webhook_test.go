@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+)
+
+var _ = Describe("NamespacedFolder Webhook", func() {
+	var validator NamespacedFolderCustomValidator
+
+	blocking := func(namespace string) *kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder {
+		return &kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolder{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "web"},
+			Spec: kubevirtfolderviewkubevirtiov1alpha1.NamespacedFolderSpec{
+				Inheritance: &kubevirtfolderviewkubevirtiov1alpha1.FolderInheritance{Block: true},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		root := &kubevirtfolderviewkubevirtiov1alpha1.FolderIndex{
+			ObjectMeta: metav1.ObjectMeta{Name: "root"},
+			Spec: kubevirtfolderviewkubevirtiov1alpha1.FolderIndexSpec{
+				ClusterFolderEntries: map[string]kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderEntry{
+					"prod":    {ChildFolders: []string{"quiet"}, Namespaces: []string{"prod-web-apps"}},
+					"quiet":   {Namespaces: []string{"prod-db"}},
+					"staging": {Namespaces: []string{"staging-web-apps"}},
+				},
+			},
+		}
+		validator = NamespacedFolderCustomValidator{Client: newLimitsClient(
+			root,
+			&kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{
+				ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+				Spec: kubevirtfolderviewkubevirtiov1alpha1.ClusterFolderSpec{
+					FolderPermissions: []kubevirtfolderviewkubevirtiov1alpha1.FolderPermission{{
+						Subject:             rbacv1.Subject{Kind: "Group", Name: "prod-admins"},
+						RoleRefs:            []rbacv1.RoleRef{{Kind: "ClusterRole", Name: "admin"}},
+						ExcludeFromChildren: true,
+					}},
+				},
+			},
+			&kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{ObjectMeta: metav1.ObjectMeta{Name: "quiet"}},
+			&kubevirtfolderviewkubevirtiov1alpha1.ClusterFolder{ObjectMeta: metav1.ObjectMeta{Name: "staging"}},
+		)}
+	})
+
+	Context("When creating or updating NamespacedFolder under Validating Webhook", func() {
+		It("Should deny blocking inheritance in a namespace a ClusterFolder grants access to", func() {
+			Expect(validator.ValidateCreate(ctx, blocking("prod-web-apps"))).Error().To(MatchError(
+				"namespaced folder [prod-web-apps/web] cannot block inheritance, " +
+					"cluster folder [prod] grants access to all of namespace [prod-web-apps]"))
+
+			unblocked := blocking("prod-web-apps")
+			unblocked.Spec.Inheritance = nil
+			Expect(validator.ValidateUpdate(ctx, unblocked, blocking("prod-web-apps"))).Error().To(HaveOccurred())
+		})
+
+		It("Should admit blocking inheritance where no ClusterFolder permissions reach", func() {
+			Expect(validator.ValidateCreate(ctx, blocking("prod-db"))).Error().NotTo(HaveOccurred())
+			Expect(validator.ValidateCreate(ctx, blocking("staging-web-apps"))).Error().NotTo(HaveOccurred())
+			Expect(validator.ValidateCreate(ctx, blocking("dev"))).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should admit updates to a folder that already blocks inheritance", func() {
+			Expect(validator.ValidateUpdate(ctx, blocking("prod-web-apps"), blocking("prod-web-apps"))).Error().NotTo(HaveOccurred())
+		})
+	})
+})
@@ -40,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	kubevirtfolderviewkubevirtiov1alpha1 "github.com/davidvossel/kubevirt-folder-view/api/v1alpha1"
+	kubevirtfolderviewkubevirtiov1beta1 "github.com/davidvossel/kubevirt-folder-view/api/v1beta1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -68,6 +69,8 @@ var _ = BeforeSuite(func() {
 	var err error
 	err = kubevirtfolderviewkubevirtiov1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
+	err = kubevirtfolderviewkubevirtiov1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:scheme
 
@@ -112,6 +115,12 @@ var _ = BeforeSuite(func() {
 	err = SetupFolderIndexWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = SetupClusterFolderWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = SetupNamespacedFolderWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
 	// +kubebuilder:scaffold:webhook
 
 	go func() {